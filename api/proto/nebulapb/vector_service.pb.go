@@ -21,6 +21,253 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// VectorEncoding describes how packed_vector bytes are laid out.
+// All packed encodings are little-endian.
+type VectorEncoding int32
+
+const (
+	VectorEncoding_FLOAT32  VectorEncoding = 0
+	VectorEncoding_FLOAT16  VectorEncoding = 1
+	VectorEncoding_BFLOAT16 VectorEncoding = 2
+	// Packed bits, bit 0 = lowest bit of the first byte. Only valid for
+	// indexes using a Hamming or Jaccard metric. The first insert fixes the
+	// byte length for the index, and Hamming distances count its bits.
+	VectorEncoding_BINARY VectorEncoding = 3
+)
+
+// Enum value maps for VectorEncoding.
+var (
+	VectorEncoding_name = map[int32]string{
+		0: "FLOAT32",
+		1: "FLOAT16",
+		2: "BFLOAT16",
+		3: "BINARY",
+	}
+	VectorEncoding_value = map[string]int32{
+		"FLOAT32":  0,
+		"FLOAT16":  1,
+		"BFLOAT16": 2,
+		"BINARY":   3,
+	}
+)
+
+func (x VectorEncoding) Enum() *VectorEncoding {
+	p := new(VectorEncoding)
+	*p = x
+	return p
+}
+
+func (x VectorEncoding) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (VectorEncoding) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_proto_nebulapb_vector_service_proto_enumTypes[0].Descriptor()
+}
+
+func (VectorEncoding) Type() protoreflect.EnumType {
+	return &file_api_proto_nebulapb_vector_service_proto_enumTypes[0]
+}
+
+func (x VectorEncoding) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use VectorEncoding.Descriptor instead.
+func (VectorEncoding) EnumDescriptor() ([]byte, []int) {
+	return file_api_proto_nebulapb_vector_service_proto_rawDescGZIP(), []int{0}
+}
+
+// FusionMethod selects how dense, sparse and text results are merged.
+type FusionMethod int32
+
+const (
+	// Min-max normalize each result list, then sum with weights.
+	FusionMethod_WEIGHTED_SUM FusionMethod = 0
+	// Reciprocal rank fusion: sum of weight / (rrf_k + rank).
+	FusionMethod_RECIPROCAL_RANK FusionMethod = 1
+)
+
+// Enum value maps for FusionMethod.
+var (
+	FusionMethod_name = map[int32]string{
+		0: "WEIGHTED_SUM",
+		1: "RECIPROCAL_RANK",
+	}
+	FusionMethod_value = map[string]int32{
+		"WEIGHTED_SUM":    0,
+		"RECIPROCAL_RANK": 1,
+	}
+)
+
+func (x FusionMethod) Enum() *FusionMethod {
+	p := new(FusionMethod)
+	*p = x
+	return p
+}
+
+func (x FusionMethod) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (FusionMethod) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_proto_nebulapb_vector_service_proto_enumTypes[1].Descriptor()
+}
+
+func (FusionMethod) Type() protoreflect.EnumType {
+	return &file_api_proto_nebulapb_vector_service_proto_enumTypes[1]
+}
+
+func (x FusionMethod) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use FusionMethod.Descriptor instead.
+func (FusionMethod) EnumDescriptor() ([]byte, []int) {
+	return file_api_proto_nebulapb_vector_service_proto_rawDescGZIP(), []int{1}
+}
+
+// SparseVector holds the non-zero entries of a sparse vector as parallel
+// index/value lists.
+type SparseVector struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Indices       []uint32               `protobuf:"varint,1,rep,packed,name=indices,proto3" json:"indices,omitempty"`
+	Values        []float32              `protobuf:"fixed32,2,rep,packed,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SparseVector) Reset() {
+	*x = SparseVector{}
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SparseVector) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SparseVector) ProtoMessage() {}
+
+func (x *SparseVector) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SparseVector.ProtoReflect.Descriptor instead.
+func (*SparseVector) Descriptor() ([]byte, []int) {
+	return file_api_proto_nebulapb_vector_service_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SparseVector) GetIndices() []uint32 {
+	if x != nil {
+		return x.Indices
+	}
+	return nil
+}
+
+func (x *SparseVector) GetValues() []float32 {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+type HybridOptions struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Method FusionMethod           `protobuf:"varint,1,opt,name=method,proto3,enum=nebulapb.FusionMethod" json:"method,omitempty"`
+	// Weights default to 1 when all are zero.
+	DenseWeight  float32 `protobuf:"fixed32,2,opt,name=dense_weight,json=denseWeight,proto3" json:"dense_weight,omitempty"`
+	SparseWeight float32 `protobuf:"fixed32,3,opt,name=sparse_weight,json=sparseWeight,proto3" json:"sparse_weight,omitempty"`
+	// RRF rank offset, defaults to 60.
+	RrfK int32 `protobuf:"varint,4,opt,name=rrf_k,json=rrfK,proto3" json:"rrf_k,omitempty"`
+	// Results fetched from each index before fusion, defaults to 4*k.
+	Candidates    int32   `protobuf:"varint,5,opt,name=candidates,proto3" json:"candidates,omitempty"`
+	TextWeight    float32 `protobuf:"fixed32,6,opt,name=text_weight,json=textWeight,proto3" json:"text_weight,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HybridOptions) Reset() {
+	*x = HybridOptions{}
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HybridOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HybridOptions) ProtoMessage() {}
+
+func (x *HybridOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HybridOptions.ProtoReflect.Descriptor instead.
+func (*HybridOptions) Descriptor() ([]byte, []int) {
+	return file_api_proto_nebulapb_vector_service_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *HybridOptions) GetMethod() FusionMethod {
+	if x != nil {
+		return x.Method
+	}
+	return FusionMethod_WEIGHTED_SUM
+}
+
+func (x *HybridOptions) GetDenseWeight() float32 {
+	if x != nil {
+		return x.DenseWeight
+	}
+	return 0
+}
+
+func (x *HybridOptions) GetSparseWeight() float32 {
+	if x != nil {
+		return x.SparseWeight
+	}
+	return 0
+}
+
+func (x *HybridOptions) GetRrfK() int32 {
+	if x != nil {
+		return x.RrfK
+	}
+	return 0
+}
+
+func (x *HybridOptions) GetCandidates() int32 {
+	if x != nil {
+		return x.Candidates
+	}
+	return 0
+}
+
+func (x *HybridOptions) GetTextWeight() float32 {
+	if x != nil {
+		return x.TextWeight
+	}
+	return 0
+}
+
 type Vector struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -31,7 +278,7 @@ type Vector struct {
 
 func (x *Vector) Reset() {
 	*x = Vector{}
-	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[0]
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -43,7 +290,7 @@ func (x *Vector) String() string {
 func (*Vector) ProtoMessage() {}
 
 func (x *Vector) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[0]
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -56,7 +303,7 @@ func (x *Vector) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Vector.ProtoReflect.Descriptor instead.
 func (*Vector) Descriptor() ([]byte, []int) {
-	return file_api_proto_nebulapb_vector_service_proto_rawDescGZIP(), []int{0}
+	return file_api_proto_nebulapb_vector_service_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *Vector) GetId() string {
@@ -74,16 +321,24 @@ func (x *Vector) GetValues() []float32 {
 }
 
 type InsertRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Vector        []float32              `protobuf:"fixed32,2,rep,packed,name=vector,proto3" json:"vector,omitempty"`
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Id     string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Vector []float32              `protobuf:"fixed32,2,rep,packed,name=vector,proto3" json:"vector,omitempty"`
+	// Alternative to vector: raw bytes in the given encoding.
+	PackedVector []byte         `protobuf:"bytes,3,opt,name=packed_vector,json=packedVector,proto3" json:"packed_vector,omitempty"`
+	Encoding     VectorEncoding `protobuf:"varint,4,opt,name=encoding,proto3,enum=nebulapb.VectorEncoding" json:"encoding,omitempty"`
+	// Optional sparse representation, stored in the sparse index. A record
+	// may carry a dense vector, a sparse vector, or both.
+	Sparse *SparseVector `protobuf:"bytes,5,opt,name=sparse,proto3" json:"sparse,omitempty"`
+	// Optional text field, indexed for BM25 keyword search.
+	Text          string `protobuf:"bytes,6,opt,name=text,proto3" json:"text,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *InsertRequest) Reset() {
 	*x = InsertRequest{}
-	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[1]
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -95,7 +350,7 @@ func (x *InsertRequest) String() string {
 func (*InsertRequest) ProtoMessage() {}
 
 func (x *InsertRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[1]
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -108,7 +363,7 @@ func (x *InsertRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use InsertRequest.ProtoReflect.Descriptor instead.
 func (*InsertRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_nebulapb_vector_service_proto_rawDescGZIP(), []int{1}
+	return file_api_proto_nebulapb_vector_service_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *InsertRequest) GetId() string {
@@ -125,6 +380,34 @@ func (x *InsertRequest) GetVector() []float32 {
 	return nil
 }
 
+func (x *InsertRequest) GetPackedVector() []byte {
+	if x != nil {
+		return x.PackedVector
+	}
+	return nil
+}
+
+func (x *InsertRequest) GetEncoding() VectorEncoding {
+	if x != nil {
+		return x.Encoding
+	}
+	return VectorEncoding_FLOAT32
+}
+
+func (x *InsertRequest) GetSparse() *SparseVector {
+	if x != nil {
+		return x.Sparse
+	}
+	return nil
+}
+
+func (x *InsertRequest) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
 type InsertResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
@@ -135,7 +418,7 @@ type InsertResponse struct {
 
 func (x *InsertResponse) Reset() {
 	*x = InsertResponse{}
-	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[2]
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -147,7 +430,7 @@ func (x *InsertResponse) String() string {
 func (*InsertResponse) ProtoMessage() {}
 
 func (x *InsertResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[2]
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -160,7 +443,7 @@ func (x *InsertResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use InsertResponse.ProtoReflect.Descriptor instead.
 func (*InsertResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_nebulapb_vector_service_proto_rawDescGZIP(), []int{2}
+	return file_api_proto_nebulapb_vector_service_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *InsertResponse) GetSuccess() bool {
@@ -178,16 +461,31 @@ func (x *InsertResponse) GetError() string {
 }
 
 type SearchRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Vector        []float32              `protobuf:"fixed32,1,rep,packed,name=vector,proto3" json:"vector,omitempty"`
-	K             int32                  `protobuf:"varint,2,opt,name=k,proto3" json:"k,omitempty"`
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Vector []float32              `protobuf:"fixed32,1,rep,packed,name=vector,proto3" json:"vector,omitempty"`
+	K      int32                  `protobuf:"varint,2,opt,name=k,proto3" json:"k,omitempty"`
+	// Alternative to vector: raw bytes in the given encoding.
+	PackedVector []byte         `protobuf:"bytes,3,opt,name=packed_vector,json=packedVector,proto3" json:"packed_vector,omitempty"`
+	Encoding     VectorEncoding `protobuf:"varint,4,opt,name=encoding,proto3,enum=nebulapb.VectorEncoding" json:"encoding,omitempty"`
+	// Sparse query. With no dense vector this is a pure sparse search; with
+	// both set the results are fused as described by hybrid.
+	Sparse *SparseVector  `protobuf:"bytes,5,opt,name=sparse,proto3" json:"sparse,omitempty"`
+	Hybrid *HybridOptions `protobuf:"bytes,6,opt,name=hybrid,proto3" json:"hybrid,omitempty"`
+	// BM25 keyword query over the text field. Fused like sparse.
+	TextQuery string `protobuf:"bytes,7,opt,name=text_query,json=textQuery,proto3" json:"text_query,omitempty"`
+	// With a deadline set, return the best dense matches found shortly
+	// before it instead of failing with DEADLINE_EXCEEDED.
+	BestEffort bool `protobuf:"varint,8,opt,name=best_effort,json=bestEffort,proto3" json:"best_effort,omitempty"`
+	// HNSW search width; 0 uses the server's index.ef_search. Raised to k
+	// when smaller.
+	Ef            int32 `protobuf:"varint,9,opt,name=ef,proto3" json:"ef,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *SearchRequest) Reset() {
 	*x = SearchRequest{}
-	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[3]
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -199,7 +497,7 @@ func (x *SearchRequest) String() string {
 func (*SearchRequest) ProtoMessage() {}
 
 func (x *SearchRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[3]
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -212,7 +510,7 @@ func (x *SearchRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SearchRequest.ProtoReflect.Descriptor instead.
 func (*SearchRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_nebulapb_vector_service_proto_rawDescGZIP(), []int{3}
+	return file_api_proto_nebulapb_vector_service_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *SearchRequest) GetVector() []float32 {
@@ -229,16 +527,68 @@ func (x *SearchRequest) GetK() int32 {
 	return 0
 }
 
+func (x *SearchRequest) GetPackedVector() []byte {
+	if x != nil {
+		return x.PackedVector
+	}
+	return nil
+}
+
+func (x *SearchRequest) GetEncoding() VectorEncoding {
+	if x != nil {
+		return x.Encoding
+	}
+	return VectorEncoding_FLOAT32
+}
+
+func (x *SearchRequest) GetSparse() *SparseVector {
+	if x != nil {
+		return x.Sparse
+	}
+	return nil
+}
+
+func (x *SearchRequest) GetHybrid() *HybridOptions {
+	if x != nil {
+		return x.Hybrid
+	}
+	return nil
+}
+
+func (x *SearchRequest) GetTextQuery() string {
+	if x != nil {
+		return x.TextQuery
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetBestEffort() bool {
+	if x != nil {
+		return x.BestEffort
+	}
+	return false
+}
+
+func (x *SearchRequest) GetEf() int32 {
+	if x != nil {
+		return x.Ef
+	}
+	return 0
+}
+
 type SearchResponse struct {
-	state         protoimpl.MessageState  `protogen:"open.v1"`
-	Matches       []*SearchResponse_Match `protobuf:"bytes,1,rep,name=matches,proto3" json:"matches,omitempty"`
+	state   protoimpl.MessageState  `protogen:"open.v1"`
+	Matches []*SearchResponse_Match `protobuf:"bytes,1,rep,name=matches,proto3" json:"matches,omitempty"`
+	// Set when a best_effort search ran out of time; matches may be missing
+	// closer vectors.
+	Partial       bool `protobuf:"varint,2,opt,name=partial,proto3" json:"partial,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *SearchResponse) Reset() {
 	*x = SearchResponse{}
-	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[4]
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -250,7 +600,7 @@ func (x *SearchResponse) String() string {
 func (*SearchResponse) ProtoMessage() {}
 
 func (x *SearchResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[4]
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -263,7 +613,7 @@ func (x *SearchResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SearchResponse.ProtoReflect.Descriptor instead.
 func (*SearchResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_nebulapb_vector_service_proto_rawDescGZIP(), []int{4}
+	return file_api_proto_nebulapb_vector_service_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *SearchResponse) GetMatches() []*SearchResponse_Match {
@@ -273,29 +623,35 @@ func (x *SearchResponse) GetMatches() []*SearchResponse_Match {
 	return nil
 }
 
-type SearchResponse_Match struct {
+func (x *SearchResponse) GetPartial() bool {
+	if x != nil {
+		return x.Partial
+	}
+	return false
+}
+
+type GetRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Score         float32                `protobuf:"fixed32,2,opt,name=score,proto3" json:"score,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SearchResponse_Match) Reset() {
-	*x = SearchResponse_Match{}
-	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[5]
+func (x *GetRequest) Reset() {
+	*x = GetRequest{}
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SearchResponse_Match) String() string {
+func (x *GetRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SearchResponse_Match) ProtoMessage() {}
+func (*GetRequest) ProtoMessage() {}
 
-func (x *SearchResponse_Match) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[5]
+func (x *GetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -306,50 +662,718 @@ func (x *SearchResponse_Match) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SearchResponse_Match.ProtoReflect.Descriptor instead.
-func (*SearchResponse_Match) Descriptor() ([]byte, []int) {
-	return file_api_proto_nebulapb_vector_service_proto_rawDescGZIP(), []int{4, 0}
+// Deprecated: Use GetRequest.ProtoReflect.Descriptor instead.
+func (*GetRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_nebulapb_vector_service_proto_rawDescGZIP(), []int{7}
 }
 
-func (x *SearchResponse_Match) GetId() string {
+func (x *GetRequest) GetId() string {
 	if x != nil {
 		return x.Id
 	}
 	return ""
 }
 
-func (x *SearchResponse_Match) GetScore() float32 {
-	if x != nil {
-		return x.Score
-	}
-	return 0
+type GetResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// The vector at the magnitude it was inserted with, as Export returns it,
+	// rounded to the configured precision.
+	Vector []float32 `protobuf:"fixed32,2,rep,packed,name=vector,proto3" json:"vector,omitempty"`
+	// Set instead of vector for BINARY indexes.
+	PackedVector  []byte         `protobuf:"bytes,3,opt,name=packed_vector,json=packedVector,proto3" json:"packed_vector,omitempty"`
+	Encoding      VectorEncoding `protobuf:"varint,4,opt,name=encoding,proto3,enum=nebulapb.VectorEncoding" json:"encoding,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-var File_api_proto_nebulapb_vector_service_proto protoreflect.FileDescriptor
+func (x *GetResponse) Reset() {
+	*x = GetResponse{}
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
 
-const file_api_proto_nebulapb_vector_service_proto_rawDesc = "" +
-	"\n" +
-	"'api/proto/nebulapb/vector_service.proto\x12\bnebulapb\"0\n" +
-	"\x06Vector\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
-	"\x06values\x18\x02 \x03(\x02R\x06values\"7\n" +
-	"\rInsertRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
-	"\x06vector\x18\x02 \x03(\x02R\x06vector\"@\n" +
+func (x *GetResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetResponse) ProtoMessage() {}
+
+func (x *GetResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetResponse.ProtoReflect.Descriptor instead.
+func (*GetResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_nebulapb_vector_service_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *GetResponse) GetVector() []float32 {
+	if x != nil {
+		return x.Vector
+	}
+	return nil
+}
+
+func (x *GetResponse) GetPackedVector() []byte {
+	if x != nil {
+		return x.PackedVector
+	}
+	return nil
+}
+
+func (x *GetResponse) GetEncoding() VectorEncoding {
+	if x != nil {
+		return x.Encoding
+	}
+	return VectorEncoding_FLOAT32
+}
+
+type DeleteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRequest) Reset() {
+	*x = DeleteRequest{}
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRequest) ProtoMessage() {}
+
+func (x *DeleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRequest.ProtoReflect.Descriptor instead.
+func (*DeleteRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_nebulapb_vector_service_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DeleteRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DeleteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteResponse) Reset() {
+	*x = DeleteResponse{}
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteResponse) ProtoMessage() {}
+
+func (x *DeleteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteResponse.ProtoReflect.Descriptor instead.
+func (*DeleteResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_nebulapb_vector_service_proto_rawDescGZIP(), []int{10}
+}
+
+type StatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatsRequest) Reset() {
+	*x = StatsRequest{}
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsRequest) ProtoMessage() {}
+
+func (x *StatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsRequest.ProtoReflect.Descriptor instead.
+func (*StatsRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_nebulapb_vector_service_proto_rawDescGZIP(), []int{11}
+}
+
+type StatsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Live dense vectors, and deleted ones still linked in the graph.
+	Vectors       int64 `protobuf:"varint,1,opt,name=vectors,proto3" json:"vectors,omitempty"`
+	Deleted       int64 `protobuf:"varint,2,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	Dimension     int32 `protobuf:"varint,3,opt,name=dimension,proto3" json:"dimension,omitempty"`
+	MaxLevel      int32 `protobuf:"varint,4,opt,name=max_level,json=maxLevel,proto3" json:"max_level,omitempty"`
+	SparseVectors int64 `protobuf:"varint,5,opt,name=sparse_vectors,json=sparseVectors,proto3" json:"sparse_vectors,omitempty"`
+	TextDocuments int64 `protobuf:"varint,6,opt,name=text_documents,json=textDocuments,proto3" json:"text_documents,omitempty"`
+	// Bytes and records written to the WAL since the server started.
+	WalBytes      uint64 `protobuf:"varint,7,opt,name=wal_bytes,json=walBytes,proto3" json:"wal_bytes,omitempty"`
+	WalRecords    uint64 `protobuf:"varint,8,opt,name=wal_records,json=walRecords,proto3" json:"wal_records,omitempty"`
+	Searches      uint64 `protobuf:"varint,9,opt,name=searches,proto3" json:"searches,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatsResponse) Reset() {
+	*x = StatsResponse{}
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsResponse) ProtoMessage() {}
+
+func (x *StatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsResponse.ProtoReflect.Descriptor instead.
+func (*StatsResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_nebulapb_vector_service_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *StatsResponse) GetVectors() int64 {
+	if x != nil {
+		return x.Vectors
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetDeleted() int64 {
+	if x != nil {
+		return x.Deleted
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetDimension() int32 {
+	if x != nil {
+		return x.Dimension
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetMaxLevel() int32 {
+	if x != nil {
+		return x.MaxLevel
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetSparseVectors() int64 {
+	if x != nil {
+		return x.SparseVectors
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetTextDocuments() int64 {
+	if x != nil {
+		return x.TextDocuments
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetWalBytes() uint64 {
+	if x != nil {
+		return x.WalBytes
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetWalRecords() uint64 {
+	if x != nil {
+		return x.WalRecords
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetSearches() uint64 {
+	if x != nil {
+		return x.Searches
+	}
+	return 0
+}
+
+type SnapshotRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotRequest) Reset() {
+	*x = SnapshotRequest{}
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotRequest) ProtoMessage() {}
+
+func (x *SnapshotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotRequest.ProtoReflect.Descriptor instead.
+func (*SnapshotRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_nebulapb_vector_service_proto_rawDescGZIP(), []int{13}
+}
+
+type SnapshotResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	SizeBytes     int64                  `protobuf:"varint,2,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`
+	DurationMs    int64                  `protobuf:"varint,3,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotResponse) Reset() {
+	*x = SnapshotResponse{}
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotResponse) ProtoMessage() {}
+
+func (x *SnapshotResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotResponse.ProtoReflect.Descriptor instead.
+func (*SnapshotResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_nebulapb_vector_service_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *SnapshotResponse) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *SnapshotResponse) GetSizeBytes() int64 {
+	if x != nil {
+		return x.SizeBytes
+	}
+	return 0
+}
+
+func (x *SnapshotResponse) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+type BulkInsertResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Inserted int64                  `protobuf:"varint,1,opt,name=inserted,proto3" json:"inserted,omitempty"`
+	Failed   int64                  `protobuf:"varint,2,opt,name=failed,proto3" json:"failed,omitempty"`
+	// The first failures, at most 100.
+	Errors        []*BulkInsertError `protobuf:"bytes,3,rep,name=errors,proto3" json:"errors,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BulkInsertResponse) Reset() {
+	*x = BulkInsertResponse{}
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BulkInsertResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BulkInsertResponse) ProtoMessage() {}
+
+func (x *BulkInsertResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BulkInsertResponse.ProtoReflect.Descriptor instead.
+func (*BulkInsertResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_nebulapb_vector_service_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *BulkInsertResponse) GetInserted() int64 {
+	if x != nil {
+		return x.Inserted
+	}
+	return 0
+}
+
+func (x *BulkInsertResponse) GetFailed() int64 {
+	if x != nil {
+		return x.Failed
+	}
+	return 0
+}
+
+func (x *BulkInsertResponse) GetErrors() []*BulkInsertError {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+type BulkInsertError struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BulkInsertError) Reset() {
+	*x = BulkInsertError{}
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BulkInsertError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BulkInsertError) ProtoMessage() {}
+
+func (x *BulkInsertError) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BulkInsertError.ProtoReflect.Descriptor instead.
+func (*BulkInsertError) Descriptor() ([]byte, []int) {
+	return file_api_proto_nebulapb_vector_service_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *BulkInsertError) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *BulkInsertError) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type ExportRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportRequest) Reset() {
+	*x = ExportRequest{}
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportRequest) ProtoMessage() {}
+
+func (x *ExportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportRequest.ProtoReflect.Descriptor instead.
+func (*ExportRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_nebulapb_vector_service_proto_rawDescGZIP(), []int{17}
+}
+
+type SearchResponse_Match struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Score         float32                `protobuf:"fixed32,2,opt,name=score,proto3" json:"score,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchResponse_Match) Reset() {
+	*x = SearchResponse_Match{}
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchResponse_Match) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchResponse_Match) ProtoMessage() {}
+
+func (x *SearchResponse_Match) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_nebulapb_vector_service_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchResponse_Match.ProtoReflect.Descriptor instead.
+func (*SearchResponse_Match) Descriptor() ([]byte, []int) {
+	return file_api_proto_nebulapb_vector_service_proto_rawDescGZIP(), []int{6, 0}
+}
+
+func (x *SearchResponse_Match) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *SearchResponse_Match) GetScore() float32 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+var File_api_proto_nebulapb_vector_service_proto protoreflect.FileDescriptor
+
+const file_api_proto_nebulapb_vector_service_proto_rawDesc = "" +
+	"\n" +
+	"'api/proto/nebulapb/vector_service.proto\x12\bnebulapb\"@\n" +
+	"\fSparseVector\x12\x18\n" +
+	"\aindices\x18\x01 \x03(\rR\aindices\x12\x16\n" +
+	"\x06values\x18\x02 \x03(\x02R\x06values\"\xdd\x01\n" +
+	"\rHybridOptions\x12.\n" +
+	"\x06method\x18\x01 \x01(\x0e2\x16.nebulapb.FusionMethodR\x06method\x12!\n" +
+	"\fdense_weight\x18\x02 \x01(\x02R\vdenseWeight\x12#\n" +
+	"\rsparse_weight\x18\x03 \x01(\x02R\fsparseWeight\x12\x13\n" +
+	"\x05rrf_k\x18\x04 \x01(\x05R\x04rrfK\x12\x1e\n" +
+	"\n" +
+	"candidates\x18\x05 \x01(\x05R\n" +
+	"candidates\x12\x1f\n" +
+	"\vtext_weight\x18\x06 \x01(\x02R\n" +
+	"textWeight\"0\n" +
+	"\x06Vector\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06values\x18\x02 \x03(\x02R\x06values\"\xd6\x01\n" +
+	"\rInsertRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06vector\x18\x02 \x03(\x02R\x06vector\x12#\n" +
+	"\rpacked_vector\x18\x03 \x01(\fR\fpackedVector\x124\n" +
+	"\bencoding\x18\x04 \x01(\x0e2\x18.nebulapb.VectorEncodingR\bencoding\x12.\n" +
+	"\x06sparse\x18\x05 \x01(\v2\x16.nebulapb.SparseVectorR\x06sparse\x12\x12\n" +
+	"\x04text\x18\x06 \x01(\tR\x04text\"@\n" +
 	"\x0eInsertResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
-	"\x05error\x18\x02 \x01(\tR\x05error\"5\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"\xc1\x02\n" +
 	"\rSearchRequest\x12\x16\n" +
 	"\x06vector\x18\x01 \x03(\x02R\x06vector\x12\f\n" +
-	"\x01k\x18\x02 \x01(\x05R\x01k\"y\n" +
+	"\x01k\x18\x02 \x01(\x05R\x01k\x12#\n" +
+	"\rpacked_vector\x18\x03 \x01(\fR\fpackedVector\x124\n" +
+	"\bencoding\x18\x04 \x01(\x0e2\x18.nebulapb.VectorEncodingR\bencoding\x12.\n" +
+	"\x06sparse\x18\x05 \x01(\v2\x16.nebulapb.SparseVectorR\x06sparse\x12/\n" +
+	"\x06hybrid\x18\x06 \x01(\v2\x17.nebulapb.HybridOptionsR\x06hybrid\x12\x1d\n" +
+	"\n" +
+	"text_query\x18\a \x01(\tR\ttextQuery\x12\x1f\n" +
+	"\vbest_effort\x18\b \x01(\bR\n" +
+	"bestEffort\x12\x0e\n" +
+	"\x02ef\x18\t \x01(\x05R\x02ef\"\x93\x01\n" +
 	"\x0eSearchResponse\x128\n" +
-	"\amatches\x18\x01 \x03(\v2\x1e.nebulapb.SearchResponse.MatchR\amatches\x1a-\n" +
+	"\amatches\x18\x01 \x03(\v2\x1e.nebulapb.SearchResponse.MatchR\amatches\x12\x18\n" +
+	"\apartial\x18\x02 \x01(\bR\apartial\x1a-\n" +
 	"\x05Match\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
-	"\x05score\x18\x02 \x01(\x02R\x05score2\x89\x01\n" +
+	"\x05score\x18\x02 \x01(\x02R\x05score\"\x1c\n" +
+	"\n" +
+	"GetRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x90\x01\n" +
+	"\vGetResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06vector\x18\x02 \x03(\x02R\x06vector\x12#\n" +
+	"\rpacked_vector\x18\x03 \x01(\fR\fpackedVector\x124\n" +
+	"\bencoding\x18\x04 \x01(\x0e2\x18.nebulapb.VectorEncodingR\bencoding\"\x1f\n" +
+	"\rDeleteRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x10\n" +
+	"\x0eDeleteResponse\"\x0e\n" +
+	"\fStatsRequest\"\xa6\x02\n" +
+	"\rStatsResponse\x12\x18\n" +
+	"\avectors\x18\x01 \x01(\x03R\avectors\x12\x18\n" +
+	"\adeleted\x18\x02 \x01(\x03R\adeleted\x12\x1c\n" +
+	"\tdimension\x18\x03 \x01(\x05R\tdimension\x12\x1b\n" +
+	"\tmax_level\x18\x04 \x01(\x05R\bmaxLevel\x12%\n" +
+	"\x0esparse_vectors\x18\x05 \x01(\x03R\rsparseVectors\x12%\n" +
+	"\x0etext_documents\x18\x06 \x01(\x03R\rtextDocuments\x12\x1b\n" +
+	"\twal_bytes\x18\a \x01(\x04R\bwalBytes\x12\x1f\n" +
+	"\vwal_records\x18\b \x01(\x04R\n" +
+	"walRecords\x12\x1a\n" +
+	"\bsearches\x18\t \x01(\x04R\bsearches\"\x11\n" +
+	"\x0fSnapshotRequest\"f\n" +
+	"\x10SnapshotResponse\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x1d\n" +
+	"\n" +
+	"size_bytes\x18\x02 \x01(\x03R\tsizeBytes\x12\x1f\n" +
+	"\vduration_ms\x18\x03 \x01(\x03R\n" +
+	"durationMs\"{\n" +
+	"\x12BulkInsertResponse\x12\x1a\n" +
+	"\binserted\x18\x01 \x01(\x03R\binserted\x12\x16\n" +
+	"\x06failed\x18\x02 \x01(\x03R\x06failed\x121\n" +
+	"\x06errors\x18\x03 \x03(\v2\x19.nebulapb.BulkInsertErrorR\x06errors\"7\n" +
+	"\x0fBulkInsertError\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"\x0f\n" +
+	"\rExportRequest*D\n" +
+	"\x0eVectorEncoding\x12\v\n" +
+	"\aFLOAT32\x10\x00\x12\v\n" +
+	"\aFLOAT16\x10\x01\x12\f\n" +
+	"\bBFLOAT16\x10\x02\x12\n" +
+	"\n" +
+	"\x06BINARY\x10\x03*5\n" +
+	"\fFusionMethod\x12\x10\n" +
+	"\fWEIGHTED_SUM\x10\x00\x12\x13\n" +
+	"\x0fRECIPROCAL_RANK\x10\x012\xfc\x03\n" +
 	"\rVectorService\x12;\n" +
 	"\x06Insert\x12\x17.nebulapb.InsertRequest\x1a\x18.nebulapb.InsertResponse\x12;\n" +
-	"\x06Search\x12\x17.nebulapb.SearchRequest\x1a\x18.nebulapb.SearchResponseB5Z3github.com/sandeep89846/nebuladb/api/proto/nebulapbb\x06proto3"
+	"\x06Search\x12\x17.nebulapb.SearchRequest\x1a\x18.nebulapb.SearchResponse\x122\n" +
+	"\x03Get\x12\x14.nebulapb.GetRequest\x1a\x15.nebulapb.GetResponse\x12;\n" +
+	"\x06Delete\x12\x17.nebulapb.DeleteRequest\x1a\x18.nebulapb.DeleteResponse\x128\n" +
+	"\x05Stats\x12\x16.nebulapb.StatsRequest\x1a\x17.nebulapb.StatsResponse\x12A\n" +
+	"\bSnapshot\x12\x19.nebulapb.SnapshotRequest\x1a\x1a.nebulapb.SnapshotResponse\x12E\n" +
+	"\n" +
+	"BulkInsert\x12\x17.nebulapb.InsertRequest\x1a\x1c.nebulapb.BulkInsertResponse(\x01\x12<\n" +
+	"\x06Export\x12\x17.nebulapb.ExportRequest\x1a\x17.nebulapb.InsertRequest0\x01B5Z3github.com/sandeep89846/nebuladb/api/proto/nebulapbb\x06proto3"
 
 var (
 	file_api_proto_nebulapb_vector_service_proto_rawDescOnce sync.Once
@@ -363,26 +1387,62 @@ func file_api_proto_nebulapb_vector_service_proto_rawDescGZIP() []byte {
 	return file_api_proto_nebulapb_vector_service_proto_rawDescData
 }
 
-var file_api_proto_nebulapb_vector_service_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_api_proto_nebulapb_vector_service_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_api_proto_nebulapb_vector_service_proto_msgTypes = make([]protoimpl.MessageInfo, 19)
 var file_api_proto_nebulapb_vector_service_proto_goTypes = []any{
-	(*Vector)(nil),               // 0: nebulapb.Vector
-	(*InsertRequest)(nil),        // 1: nebulapb.InsertRequest
-	(*InsertResponse)(nil),       // 2: nebulapb.InsertResponse
-	(*SearchRequest)(nil),        // 3: nebulapb.SearchRequest
-	(*SearchResponse)(nil),       // 4: nebulapb.SearchResponse
-	(*SearchResponse_Match)(nil), // 5: nebulapb.SearchResponse.Match
+	(VectorEncoding)(0),          // 0: nebulapb.VectorEncoding
+	(FusionMethod)(0),            // 1: nebulapb.FusionMethod
+	(*SparseVector)(nil),         // 2: nebulapb.SparseVector
+	(*HybridOptions)(nil),        // 3: nebulapb.HybridOptions
+	(*Vector)(nil),               // 4: nebulapb.Vector
+	(*InsertRequest)(nil),        // 5: nebulapb.InsertRequest
+	(*InsertResponse)(nil),       // 6: nebulapb.InsertResponse
+	(*SearchRequest)(nil),        // 7: nebulapb.SearchRequest
+	(*SearchResponse)(nil),       // 8: nebulapb.SearchResponse
+	(*GetRequest)(nil),           // 9: nebulapb.GetRequest
+	(*GetResponse)(nil),          // 10: nebulapb.GetResponse
+	(*DeleteRequest)(nil),        // 11: nebulapb.DeleteRequest
+	(*DeleteResponse)(nil),       // 12: nebulapb.DeleteResponse
+	(*StatsRequest)(nil),         // 13: nebulapb.StatsRequest
+	(*StatsResponse)(nil),        // 14: nebulapb.StatsResponse
+	(*SnapshotRequest)(nil),      // 15: nebulapb.SnapshotRequest
+	(*SnapshotResponse)(nil),     // 16: nebulapb.SnapshotResponse
+	(*BulkInsertResponse)(nil),   // 17: nebulapb.BulkInsertResponse
+	(*BulkInsertError)(nil),      // 18: nebulapb.BulkInsertError
+	(*ExportRequest)(nil),        // 19: nebulapb.ExportRequest
+	(*SearchResponse_Match)(nil), // 20: nebulapb.SearchResponse.Match
 }
 var file_api_proto_nebulapb_vector_service_proto_depIdxs = []int32{
-	5, // 0: nebulapb.SearchResponse.matches:type_name -> nebulapb.SearchResponse.Match
-	1, // 1: nebulapb.VectorService.Insert:input_type -> nebulapb.InsertRequest
-	3, // 2: nebulapb.VectorService.Search:input_type -> nebulapb.SearchRequest
-	2, // 3: nebulapb.VectorService.Insert:output_type -> nebulapb.InsertResponse
-	4, // 4: nebulapb.VectorService.Search:output_type -> nebulapb.SearchResponse
-	3, // [3:5] is the sub-list for method output_type
-	1, // [1:3] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	1,  // 0: nebulapb.HybridOptions.method:type_name -> nebulapb.FusionMethod
+	0,  // 1: nebulapb.InsertRequest.encoding:type_name -> nebulapb.VectorEncoding
+	2,  // 2: nebulapb.InsertRequest.sparse:type_name -> nebulapb.SparseVector
+	0,  // 3: nebulapb.SearchRequest.encoding:type_name -> nebulapb.VectorEncoding
+	2,  // 4: nebulapb.SearchRequest.sparse:type_name -> nebulapb.SparseVector
+	3,  // 5: nebulapb.SearchRequest.hybrid:type_name -> nebulapb.HybridOptions
+	20, // 6: nebulapb.SearchResponse.matches:type_name -> nebulapb.SearchResponse.Match
+	0,  // 7: nebulapb.GetResponse.encoding:type_name -> nebulapb.VectorEncoding
+	18, // 8: nebulapb.BulkInsertResponse.errors:type_name -> nebulapb.BulkInsertError
+	5,  // 9: nebulapb.VectorService.Insert:input_type -> nebulapb.InsertRequest
+	7,  // 10: nebulapb.VectorService.Search:input_type -> nebulapb.SearchRequest
+	9,  // 11: nebulapb.VectorService.Get:input_type -> nebulapb.GetRequest
+	11, // 12: nebulapb.VectorService.Delete:input_type -> nebulapb.DeleteRequest
+	13, // 13: nebulapb.VectorService.Stats:input_type -> nebulapb.StatsRequest
+	15, // 14: nebulapb.VectorService.Snapshot:input_type -> nebulapb.SnapshotRequest
+	5,  // 15: nebulapb.VectorService.BulkInsert:input_type -> nebulapb.InsertRequest
+	19, // 16: nebulapb.VectorService.Export:input_type -> nebulapb.ExportRequest
+	6,  // 17: nebulapb.VectorService.Insert:output_type -> nebulapb.InsertResponse
+	8,  // 18: nebulapb.VectorService.Search:output_type -> nebulapb.SearchResponse
+	10, // 19: nebulapb.VectorService.Get:output_type -> nebulapb.GetResponse
+	12, // 20: nebulapb.VectorService.Delete:output_type -> nebulapb.DeleteResponse
+	14, // 21: nebulapb.VectorService.Stats:output_type -> nebulapb.StatsResponse
+	16, // 22: nebulapb.VectorService.Snapshot:output_type -> nebulapb.SnapshotResponse
+	17, // 23: nebulapb.VectorService.BulkInsert:output_type -> nebulapb.BulkInsertResponse
+	5,  // 24: nebulapb.VectorService.Export:output_type -> nebulapb.InsertRequest
+	17, // [17:25] is the sub-list for method output_type
+	9,  // [9:17] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
 }
 
 func init() { file_api_proto_nebulapb_vector_service_proto_init() }
@@ -395,13 +1455,14 @@ func file_api_proto_nebulapb_vector_service_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_proto_nebulapb_vector_service_proto_rawDesc), len(file_api_proto_nebulapb_vector_service_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   6,
+			NumEnums:      2,
+			NumMessages:   19,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_api_proto_nebulapb_vector_service_proto_goTypes,
 		DependencyIndexes: file_api_proto_nebulapb_vector_service_proto_depIdxs,
+		EnumInfos:         file_api_proto_nebulapb_vector_service_proto_enumTypes,
 		MessageInfos:      file_api_proto_nebulapb_vector_service_proto_msgTypes,
 	}.Build()
 	File_api_proto_nebulapb_vector_service_proto = out.File
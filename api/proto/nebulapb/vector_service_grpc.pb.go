@@ -19,8 +19,14 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	VectorService_Insert_FullMethodName = "/nebulapb.VectorService/Insert"
-	VectorService_Search_FullMethodName = "/nebulapb.VectorService/Search"
+	VectorService_Insert_FullMethodName     = "/nebulapb.VectorService/Insert"
+	VectorService_Search_FullMethodName     = "/nebulapb.VectorService/Search"
+	VectorService_Get_FullMethodName        = "/nebulapb.VectorService/Get"
+	VectorService_Delete_FullMethodName     = "/nebulapb.VectorService/Delete"
+	VectorService_Stats_FullMethodName      = "/nebulapb.VectorService/Stats"
+	VectorService_Snapshot_FullMethodName   = "/nebulapb.VectorService/Snapshot"
+	VectorService_BulkInsert_FullMethodName = "/nebulapb.VectorService/BulkInsert"
+	VectorService_Export_FullMethodName     = "/nebulapb.VectorService/Export"
 )
 
 // VectorServiceClient is the client API for VectorService service.
@@ -29,6 +35,22 @@ const (
 type VectorServiceClient interface {
 	Insert(ctx context.Context, in *InsertRequest, opts ...grpc.CallOption) (*InsertResponse, error)
 	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error)
+	// Get returns the stored dense vector for an id, or NOT_FOUND.
+	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error)
+	// Delete removes an id from every index, or returns NOT_FOUND.
+	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
+	// Stats reports index sizes and WAL counters.
+	Stats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsResponse, error)
+	// Snapshot writes a snapshot of every index to the data directory and
+	// empties the WAL. Writes wait while it runs.
+	Snapshot(ctx context.Context, in *SnapshotRequest, opts ...grpc.CallOption) (*SnapshotResponse, error)
+	// BulkInsert loads a stream of records, logging and indexing them in
+	// chunks. A failed record is reported and does not end the stream.
+	BulkInsert(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[InsertRequest, BulkInsertResponse], error)
+	// Export streams every live record with its original values, in a form
+	// BulkInsert accepts back. Writes continue while it runs; records
+	// written or deleted meanwhile may or may not be included.
+	Export(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[InsertRequest], error)
 }
 
 type vectorServiceClient struct {
@@ -59,12 +81,100 @@ func (c *vectorServiceClient) Search(ctx context.Context, in *SearchRequest, opt
 	return out, nil
 }
 
+func (c *vectorServiceClient) Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetResponse)
+	err := c.cc.Invoke(ctx, VectorService_Get_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *vectorServiceClient) Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteResponse)
+	err := c.cc.Invoke(ctx, VectorService_Delete_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *vectorServiceClient) Stats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StatsResponse)
+	err := c.cc.Invoke(ctx, VectorService_Stats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *vectorServiceClient) Snapshot(ctx context.Context, in *SnapshotRequest, opts ...grpc.CallOption) (*SnapshotResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SnapshotResponse)
+	err := c.cc.Invoke(ctx, VectorService_Snapshot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *vectorServiceClient) BulkInsert(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[InsertRequest, BulkInsertResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &VectorService_ServiceDesc.Streams[0], VectorService_BulkInsert_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[InsertRequest, BulkInsertResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VectorService_BulkInsertClient = grpc.ClientStreamingClient[InsertRequest, BulkInsertResponse]
+
+func (c *vectorServiceClient) Export(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[InsertRequest], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &VectorService_ServiceDesc.Streams[1], VectorService_Export_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ExportRequest, InsertRequest]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VectorService_ExportClient = grpc.ServerStreamingClient[InsertRequest]
+
 // VectorServiceServer is the server API for VectorService service.
 // All implementations must embed UnimplementedVectorServiceServer
 // for forward compatibility.
 type VectorServiceServer interface {
 	Insert(context.Context, *InsertRequest) (*InsertResponse, error)
 	Search(context.Context, *SearchRequest) (*SearchResponse, error)
+	// Get returns the stored dense vector for an id, or NOT_FOUND.
+	Get(context.Context, *GetRequest) (*GetResponse, error)
+	// Delete removes an id from every index, or returns NOT_FOUND.
+	Delete(context.Context, *DeleteRequest) (*DeleteResponse, error)
+	// Stats reports index sizes and WAL counters.
+	Stats(context.Context, *StatsRequest) (*StatsResponse, error)
+	// Snapshot writes a snapshot of every index to the data directory and
+	// empties the WAL. Writes wait while it runs.
+	Snapshot(context.Context, *SnapshotRequest) (*SnapshotResponse, error)
+	// BulkInsert loads a stream of records, logging and indexing them in
+	// chunks. A failed record is reported and does not end the stream.
+	BulkInsert(grpc.ClientStreamingServer[InsertRequest, BulkInsertResponse]) error
+	// Export streams every live record with its original values, in a form
+	// BulkInsert accepts back. Writes continue while it runs; records
+	// written or deleted meanwhile may or may not be included.
+	Export(*ExportRequest, grpc.ServerStreamingServer[InsertRequest]) error
 	mustEmbedUnimplementedVectorServiceServer()
 }
 
@@ -81,6 +191,24 @@ func (UnimplementedVectorServiceServer) Insert(context.Context, *InsertRequest)
 func (UnimplementedVectorServiceServer) Search(context.Context, *SearchRequest) (*SearchResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method Search not implemented")
 }
+func (UnimplementedVectorServiceServer) Get(context.Context, *GetRequest) (*GetResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Get not implemented")
+}
+func (UnimplementedVectorServiceServer) Delete(context.Context, *DeleteRequest) (*DeleteResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedVectorServiceServer) Stats(context.Context, *StatsRequest) (*StatsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Stats not implemented")
+}
+func (UnimplementedVectorServiceServer) Snapshot(context.Context, *SnapshotRequest) (*SnapshotResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Snapshot not implemented")
+}
+func (UnimplementedVectorServiceServer) BulkInsert(grpc.ClientStreamingServer[InsertRequest, BulkInsertResponse]) error {
+	return status.Error(codes.Unimplemented, "method BulkInsert not implemented")
+}
+func (UnimplementedVectorServiceServer) Export(*ExportRequest, grpc.ServerStreamingServer[InsertRequest]) error {
+	return status.Error(codes.Unimplemented, "method Export not implemented")
+}
 func (UnimplementedVectorServiceServer) mustEmbedUnimplementedVectorServiceServer() {}
 func (UnimplementedVectorServiceServer) testEmbeddedByValue()                       {}
 
@@ -138,6 +266,96 @@ func _VectorService_Search_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _VectorService_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VectorServiceServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VectorService_Get_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VectorServiceServer).Get(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VectorService_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VectorServiceServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VectorService_Delete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VectorServiceServer).Delete(ctx, req.(*DeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VectorService_Stats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VectorServiceServer).Stats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VectorService_Stats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VectorServiceServer).Stats(ctx, req.(*StatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VectorService_Snapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VectorServiceServer).Snapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VectorService_Snapshot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VectorServiceServer).Snapshot(ctx, req.(*SnapshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VectorService_BulkInsert_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(VectorServiceServer).BulkInsert(&grpc.GenericServerStream[InsertRequest, BulkInsertResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VectorService_BulkInsertServer = grpc.ClientStreamingServer[InsertRequest, BulkInsertResponse]
+
+func _VectorService_Export_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(VectorServiceServer).Export(m, &grpc.GenericServerStream[ExportRequest, InsertRequest]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VectorService_ExportServer = grpc.ServerStreamingServer[InsertRequest]
+
 // VectorService_ServiceDesc is the grpc.ServiceDesc for VectorService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -153,7 +371,34 @@ var VectorService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Search",
 			Handler:    _VectorService_Search_Handler,
 		},
+		{
+			MethodName: "Get",
+			Handler:    _VectorService_Get_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _VectorService_Delete_Handler,
+		},
+		{
+			MethodName: "Stats",
+			Handler:    _VectorService_Stats_Handler,
+		},
+		{
+			MethodName: "Snapshot",
+			Handler:    _VectorService_Snapshot_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "BulkInsert",
+			Handler:       _VectorService_BulkInsert_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Export",
+			Handler:       _VectorService_Export_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "api/proto/nebulapb/vector_service.proto",
 }
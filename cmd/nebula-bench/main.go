@@ -0,0 +1,512 @@
+// Command nebula-bench measures the dense indexes on a dataset with known
+// nearest neighbors. It builds each index configuration in a sweep over
+// M, efConstruction and precision, searches it at each efSearch, and
+// reports build time, memory, recall@k and QPS as CSV or JSON.
+//
+// The diskann index takes R = 2*M, HNSW's layer-0 degree, and uses
+// efConstruction and efSearch as its build and search list sizes. It is
+// built in a temporary directory under -tmp and removed afterwards, in
+// shards of -diskann-shard vectors when that is set; its memory figure
+// covers only what it keeps in RAM, and searches read the graph through the
+// page cache, so QPS reflects a warm cache unless the file exceeds memory.
+//
+// Base and query vectors are read from .fvecs, .bvecs or .npy files, and
+// ground truth from an .ivecs file of base row numbers, as shipped with
+// SIFT1M and GIST1M. ann-benchmarks HDF5 files convert with a few lines of
+// h5py+numpy: save "train" and "test" with numpy.save and "neighbors" as
+// ivecs. Indexes rank by cosine similarity, so Euclidean ground truth only
+// applies to normalized data; without -gt the exact cosine neighbors are
+// computed by brute force.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sandeep89846/nebuladb/internal/index"
+	"github.com/sandeep89846/nebuladb/pkg/dataset"
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+func main() {
+	var (
+		basePath   = flag.String("base", "", "base vectors to index (.fvecs, .bvecs or .npy)")
+		queryPath  = flag.String("queries", "", "query vectors")
+		gtPath     = flag.String("gt", "", "ground truth .ivecs (default: exact cosine neighbors, computed)")
+		limit      = flag.Int("limit", 0, "index only the first N base vectors (0: all)")
+		numQueries = flag.Int("num-queries", 0, "run only the first N queries (0: all)")
+		k          = flag.Int("k", 10, "neighbors per query, the k of recall@k")
+		indexes    = flag.String("index", "hnsw", "comma-separated indexes: hnsw, diskann, naive")
+		ms         = flag.String("m", "16", "comma-separated HNSW M values")
+		efcs       = flag.String("ef-construction", "200", "comma-separated HNSW efConstruction values")
+		efss       = flag.String("ef-search", "10,20,40,80,160,320", "comma-separated HNSW efSearch values")
+		precisions = flag.String("precision", "float32", "comma-separated storage precisions")
+		workers    = flag.Int("workers", runtime.GOMAXPROCS(0), "insert goroutines during builds")
+		seed       = flag.Uint64("seed", 0, "HNSW level seed; with -workers 1 builds are reproducible")
+		searchConc = flag.Int("search-workers", 1, "concurrent searchers while measuring QPS")
+		format     = flag.String("format", "csv", "output format: csv or json")
+		outPath    = flag.String("o", "", "output file (default: stdout)")
+		tmpDir     = flag.String("tmp", os.TempDir(), "directory for diskann index files")
+		shard      = flag.Int("diskann-shard", 0, "vectors per diskann build shard (0: build the whole graph at once)")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: nebula-bench -base FILE -queries FILE [-gt FILE] [flags]\n\nFlags:\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	log.SetFlags(0)
+	log.SetPrefix("nebula-bench: ")
+
+	if *basePath == "" || *queryPath == "" || flag.NArg() > 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *k < 1 || *workers < 1 || *searchConc < 1 {
+		log.Fatal("-k, -workers and -search-workers must be positive")
+	}
+	if *shard < 0 {
+		log.Fatal("-diskann-shard must not be negative")
+	}
+	if *limit > 0 && *gtPath != "" {
+		log.Fatal("-gt covers the full base set; drop it with -limit to compute ground truth for the subset")
+	}
+	sweep, err := parseSweep(*indexes, *ms, *efcs, *efss, *precisions)
+	if err != nil {
+		log.Fatal(err)
+	}
+	report, err := newReporter(*format, *outPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	base, err := load(*basePath, *limit)
+	if err != nil {
+		log.Fatal(err)
+	}
+	queries, err := load(*queryPath, *numQueries)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(base) == 0 || len(queries) == 0 {
+		log.Fatal("no base or query vectors")
+	}
+	log.Printf("%d base vectors of dimension %d, %d queries", len(base), len(base[0]), len(queries))
+
+	var truth [][]int32
+	if *gtPath != "" {
+		if truth, err = loadTruth(*gtPath, len(queries), *k); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		start := time.Now()
+		if truth, err = exactNeighbors(base, queries, *k); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("computed ground truth in %s", time.Since(start).Round(time.Millisecond))
+	}
+
+	b := &bench{base: base, queries: queries, truth: truth, k: *k, workers: *workers, searchers: *searchConc, seed: *seed, tmp: *tmpDir, shard: *shard}
+	for _, c := range sweep {
+		results, err := b.run(c)
+		if err != nil {
+			log.Fatalf("%s: %v", c, err)
+		}
+		for _, r := range results {
+			log.Printf("%s ef_search=%d: recall %.4f, %.0f QPS", c, r.EfSearch, r.Recall, r.QPS)
+			if err := report.add(r); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+	if err := report.close(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// load reads up to limit vectors (all when 0) from path.
+func load(path string, limit int) ([]vec.Vector, error) {
+	f, err := dataset.Open(path, "")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var r dataset.Reader = f
+	if limit > 0 {
+		r = dataset.Limit(r, limit)
+	}
+	rows, err := dataset.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	vs := make([]vec.Vector, len(rows))
+	for i, row := range rows {
+		vs[i] = row.Vector
+	}
+	return vs, nil
+}
+
+// loadTruth reads the first k neighbors of each of the first n queries.
+func loadTruth(path string, n, k int) ([][]int32, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gt, err := dataset.ReadIvecs(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(gt) < n {
+		return nil, fmt.Errorf("%s: %d rows for %d queries", path, len(gt), n)
+	}
+	for i, row := range gt[:n] {
+		if len(row) < k {
+			return nil, fmt.Errorf("%s: row %d has %d neighbors, -k is %d", path, i, len(row), k)
+		}
+		gt[i] = row[:k]
+	}
+	return gt[:n], nil
+}
+
+// exactNeighbors ranks base by cosine similarity to each query with the
+// brute-force index, a block of queries per distance matrix.
+func exactNeighbors(base, queries []vec.Vector, k int) ([][]int32, error) {
+	naive := index.NewNaiveIndex()
+	for i, v := range base {
+		if err := naive.Insert(strconv.Itoa(i), v); err != nil {
+			return nil, err
+		}
+	}
+	block := max(1, (16<<20)/len(base)) // 64 MiB of scores
+	truth := make([][]int32, 0, len(queries))
+	for start := 0; start < len(queries); start += block {
+		res, err := naive.SearchBatch(queries[start:min(start+block, len(queries))], k)
+		if err != nil {
+			return nil, err
+		}
+		for _, matches := range res {
+			row := make([]int32, len(matches))
+			for i, m := range matches {
+				n, _ := strconv.Atoi(m.ID)
+				row[i] = int32(n)
+			}
+			truth = append(truth, row)
+		}
+	}
+	return truth, nil
+}
+
+// config is one index build in the sweep.
+type config struct {
+	Index          string
+	M              int
+	EfConstruction int
+	Precision      index.Precision
+	EfSearch       []int // one measurement each; ignored by naive
+}
+
+func (c config) String() string {
+	switch c.Index {
+	case "naive":
+		return "naive"
+	case "diskann":
+		return fmt.Sprintf("diskann R=%d L=%d", 2*c.M, c.EfConstruction)
+	}
+	return fmt.Sprintf("hnsw M=%d ef_construction=%d precision=%s", c.M, c.EfConstruction, c.Precision)
+}
+
+func parseSweep(indexes, ms, efcs, efss, precisions string) ([]config, error) {
+	mv, err := parseInts("-m", ms)
+	if err != nil {
+		return nil, err
+	}
+	efcv, err := parseInts("-ef-construction", efcs)
+	if err != nil {
+		return nil, err
+	}
+	efsv, err := parseInts("-ef-search", efss)
+	if err != nil {
+		return nil, err
+	}
+	var pv []index.Precision
+	for _, s := range strings.Split(precisions, ",") {
+		p, err := index.ParsePrecision(strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("-precision: %v", err)
+		}
+		pv = append(pv, p)
+	}
+
+	var sweep []config
+	for _, name := range strings.Split(indexes, ",") {
+		switch name = strings.TrimSpace(name); name {
+		case "naive":
+			sweep = append(sweep, config{Index: name})
+		case "diskann":
+			for _, m := range mv {
+				for _, efc := range efcv {
+					sweep = append(sweep, config{Index: name, M: m, EfConstruction: efc, EfSearch: efsv})
+				}
+			}
+		case "hnsw":
+			for _, p := range pv {
+				for _, m := range mv {
+					for _, efc := range efcv {
+						sweep = append(sweep, config{Index: name, M: m, EfConstruction: efc, Precision: p, EfSearch: efsv})
+					}
+				}
+			}
+		default:
+			return nil, fmt.Errorf("-index: unknown index %q", name)
+		}
+	}
+	return sweep, nil
+}
+
+func parseInts(flag, s string) ([]int, error) {
+	var out []int
+	for _, f := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(f))
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("%s: %q is not a positive integer", flag, f)
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+type bench struct {
+	base, queries []vec.Vector
+	truth         [][]int32
+	k             int
+	workers       int
+	searchers     int
+	seed          uint64
+	tmp           string
+	shard         int // diskann build shard size
+}
+
+// searchFunc runs one query at search width ef.
+type searchFunc func(q vec.Vector, ef int) ([]index.Match, error)
+
+// run builds c and measures a search at each of its efSearch values.
+func (b *bench) run(c config) ([]result, error) {
+	runtime.GC()
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	start := time.Now()
+	search, keep, err := b.build(c)
+	if closer, ok := keep.(io.Closer); ok {
+		defer closer.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+	buildTime := time.Since(start)
+
+	runtime.GC()
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+	memory := int64(after.HeapAlloc) - int64(before.HeapAlloc)
+
+	efs := c.EfSearch
+	if c.Index == "naive" {
+		efs = []int{0}
+	}
+	var results []result
+	for _, ef := range efs {
+		r, err := b.measure(search, ef)
+		if err != nil {
+			return nil, err
+		}
+		r.Index, r.M, r.EfConstruction, r.EfSearch = c.Index, c.M, c.EfConstruction, ef
+		if c.Index == "hnsw" {
+			r.Precision = c.Precision.String()
+		}
+		r.K, r.Vectors, r.Queries = b.k, len(b.base), len(b.queries)
+		r.BuildSeconds = buildTime.Seconds()
+		r.BuildRowsPerSec = float64(len(b.base)) / buildTime.Seconds()
+		r.MemoryBytes = memory
+		results = append(results, r)
+	}
+	runtime.KeepAlive(keep)
+	return results, nil
+}
+
+// build indexes the base vectors with b.workers goroutines, each row under
+// its row number. keep is the index, to hold it live while memory is read.
+func (b *bench) build(c config) (search searchFunc, keep any, err error) {
+	var insert func(id string, v vec.Vector) error
+	switch c.Index {
+	case "diskann":
+		return b.buildDiskANN(c)
+	case "naive":
+		idx := index.NewNaiveIndex()
+		insert, keep = idx.Insert, idx
+		search = func(q vec.Vector, _ int) ([]index.Match, error) { return idx.Search(q, b.k) }
+	case "hnsw":
+		cfg := index.DefaultConfig()
+		cfg.M, cfg.M0 = c.M, 2*c.M
+		cfg.LevelMultiplier = 1 / math.Log(float64(c.M))
+		cfg.EfConstruction = c.EfConstruction
+		cfg.Precision = c.Precision
+		cfg.Seed = b.seed
+		idx := index.NewHNSW(cfg)
+		insert, keep = idx.Insert, idx
+		search = func(q vec.Vector, ef int) ([]index.Match, error) {
+			return idx.SearchContext(index.WithEf(context.Background(), ef), q, b.k)
+		}
+	}
+
+	var (
+		next  = make(chan int, 4*b.workers)
+		wg    sync.WaitGroup
+		once  sync.Once
+		first error
+	)
+	for range b.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if err := insert(strconv.Itoa(i), b.base[i]); err != nil {
+					once.Do(func() { first = fmt.Errorf("base row %d: %w", i, err) })
+				}
+			}
+		}()
+	}
+	for i := range b.base {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return search, keep, first
+}
+
+// diskIndex is a DiskANN index in a directory of its own, removed on Close.
+type diskIndex struct {
+	*index.DiskANN
+	dir string
+}
+
+func (d diskIndex) Close() error {
+	if d.DiskANN != nil {
+		d.DiskANN.Close()
+	}
+	return os.RemoveAll(d.dir)
+}
+
+// buildDiskANN builds the base vectors into a new directory under b.tmp.
+func (b *bench) buildDiskANN(c config) (searchFunc, any, error) {
+	dir, err := os.MkdirTemp(b.tmp, "nebula-bench-diskann-")
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg := index.DefaultDiskANNConfig()
+	cfg.R, cfg.L = 2*c.M, c.EfConstruction
+	cfg.Seed = b.seed
+	idx, err := index.BuildDiskANNFrom(dir, cfg, &baseSource{base: b.base}, b.shard, b.workers)
+	keep := diskIndex{idx, dir}
+	if err != nil {
+		return nil, keep, err
+	}
+	return func(q vec.Vector, ef int) ([]index.Match, error) {
+		return idx.SearchContext(index.WithEf(context.Background(), ef), q, b.k)
+	}, keep, nil
+}
+
+// baseSource yields the base vectors to a diskann build, each under its
+// row number.
+type baseSource struct {
+	base []vec.Vector
+	next int
+}
+
+func (s *baseSource) Next() (string, vec.Vector, error) {
+	if s.next == len(s.base) {
+		return "", nil, io.EOF
+	}
+	s.next++
+	return strconv.Itoa(s.next - 1), s.base[s.next-1], nil
+}
+
+// measure runs every query at ef on b.searchers goroutines.
+func (b *bench) measure(search searchFunc, ef int) (result, error) {
+	latencies := make([]time.Duration, len(b.queries))
+	hits := make([]int, len(b.queries))
+	var (
+		next  = make(chan int, len(b.queries))
+		wg    sync.WaitGroup
+		once  sync.Once
+		first error
+	)
+	for i := range b.queries {
+		next <- i
+	}
+	close(next)
+
+	start := time.Now()
+	for range b.searchers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				t := time.Now()
+				matches, err := search(b.queries[i], ef)
+				latencies[i] = time.Since(t)
+				if err != nil {
+					once.Do(func() { first = err })
+					continue
+				}
+				hits[i] = b.hits(i, matches)
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	if first != nil {
+		return result{}, first
+	}
+
+	total := 0
+	for _, h := range hits {
+		total += h
+	}
+	slices.Sort(latencies)
+	return result{
+		Recall: float64(total) / float64(b.k*len(b.queries)),
+		QPS:    float64(len(b.queries)) / elapsed.Seconds(),
+		P50Ms:  percentile(latencies, 0.50),
+		P99Ms:  percentile(latencies, 0.99),
+	}, nil
+}
+
+// hits counts the matches of query i among its true k nearest neighbors.
+func (b *bench) hits(i int, matches []index.Match) int {
+	n := 0
+	for _, m := range matches {
+		row, err := strconv.Atoi(m.ID)
+		if err == nil && slices.Contains(b.truth[i], int32(row)) {
+			n++
+		}
+	}
+	return n
+}
+
+// percentile returns the p-th latency of sorted in milliseconds.
+func percentile(sorted []time.Duration, p float64) float64 {
+	i := min(len(sorted)-1, int(p*float64(len(sorted))))
+	return float64(sorted[i].Microseconds()) / 1000
+}
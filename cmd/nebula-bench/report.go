@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+)
+
+// result is one row of the report: an index configuration searched at one
+// efSearch.
+type result struct {
+	Index           string  `json:"index"`
+	M               int     `json:"m,omitempty"`
+	EfConstruction  int     `json:"ef_construction,omitempty"`
+	EfSearch        int     `json:"ef_search,omitempty"`
+	Precision       string  `json:"precision,omitempty"`
+	K               int     `json:"k"`
+	Vectors         int     `json:"vectors"`
+	Queries         int     `json:"queries"`
+	BuildSeconds    float64 `json:"build_seconds"`
+	BuildRowsPerSec float64 `json:"build_rows_per_sec"`
+	// MemoryBytes is the growth of the live heap over the build.
+	MemoryBytes int64   `json:"memory_bytes"`
+	Recall      float64 `json:"recall"`
+	QPS         float64 `json:"qps"`
+	P50Ms       float64 `json:"p50_ms"`
+	P99Ms       float64 `json:"p99_ms"`
+}
+
+var csvHeader = []string{
+	"index", "m", "ef_construction", "ef_search", "precision", "k", "vectors", "queries",
+	"build_seconds", "build_rows_per_sec", "memory_bytes", "recall", "qps", "p50_ms", "p99_ms",
+}
+
+func (r result) csv() []string {
+	f := func(v float64, prec int) string { return strconv.FormatFloat(v, 'f', prec, 64) }
+	opt := func(n int) string { // HNSW parameters, blank for other indexes
+		if n == 0 {
+			return ""
+		}
+		return strconv.Itoa(n)
+	}
+	return []string{
+		r.Index, opt(r.M), opt(r.EfConstruction), opt(r.EfSearch), r.Precision,
+		strconv.Itoa(r.K), strconv.Itoa(r.Vectors), strconv.Itoa(r.Queries),
+		f(r.BuildSeconds, 3), f(r.BuildRowsPerSec, 0), strconv.FormatInt(r.MemoryBytes, 10),
+		f(r.Recall, 4), f(r.QPS, 1), f(r.P50Ms, 3), f(r.P99Ms, 3),
+	}
+}
+
+// reporter writes CSV rows as results arrive, or a JSON array at close.
+type reporter struct {
+	json    bool
+	w       io.WriteCloser
+	csv     *csv.Writer
+	results []result
+}
+
+// newReporter writes results to path, or stdout when empty.
+func newReporter(format, path string) (*reporter, error) {
+	if format != "csv" && format != "json" {
+		return nil, errors.New("-format must be csv or json")
+	}
+	var w io.WriteCloser = os.Stdout
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, err
+		}
+		w = f
+	}
+	r := &reporter{json: format == "json", w: w}
+	if !r.json {
+		r.csv = csv.NewWriter(w)
+		r.csv.Write(csvHeader)
+	}
+	return r, nil
+}
+
+func (r *reporter) add(res result) error {
+	if r.json {
+		r.results = append(r.results, res)
+		return nil
+	}
+	r.csv.Write(res.csv())
+	r.csv.Flush() // a long sweep reports as it goes
+	return r.csv.Error()
+}
+
+func (r *reporter) close() error {
+	var err error
+	if r.json {
+		enc := json.NewEncoder(r.w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(r.results)
+	}
+	if r.w != os.Stdout {
+		if cerr := r.w.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
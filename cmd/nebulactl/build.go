@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/sandeep89846/nebuladb/internal/config"
+	"github.com/sandeep89846/nebuladb/internal/index"
+	"github.com/sandeep89846/nebuladb/internal/server"
+	"github.com/sandeep89846/nebuladb/internal/storage"
+	"github.com/sandeep89846/nebuladb/pkg/dataset"
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+// buildChunk is how many rows build reads before indexing them together.
+const buildChunk = 10000
+
+// maxBuildErrors bounds the failed rows build lists.
+const maxBuildErrors = 100
+
+// runBuild indexes a dataset file without a server and leaves a data
+// directory the server starts from: a snapshot and an empty WAL. The index
+// parameters come from the server's configuration, so the snapshot matches
+// what the server will load it with.
+func runBuild(g *globals, args []string) error {
+	fs := flag.NewFlagSet("build", flag.ExitOnError)
+	data := addDatasetFlags(fs)
+	configFile := fs.String("config", os.Getenv(config.EnvPrefix+"CONFIG"), "server config file with the index settings ($NEBULA_CONFIG)")
+	dataDir := fs.String("data-dir", "", "output directory (default: data_dir from the config)")
+	workers := fs.Int("workers", runtime.GOMAXPROCS(0), "insert goroutines")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 || *workers < 1 {
+		return errUsage
+	}
+
+	var confArgs []string
+	if *configFile != "" {
+		confArgs = append(confArgs, "-config", *configFile)
+	}
+	if *dataDir != "" {
+		confArgs = append(confArgs, "-data_dir", *dataDir)
+	}
+	conf, err := config.Load(confArgs, os.Getenv)
+	if err != nil {
+		return err
+	}
+	cfg := conf.HNSW()
+	if cfg.Metric.Binary() {
+		return fmt.Errorf("index.metric %s needs binary vectors, which dataset files do not hold", cfg.Metric)
+	}
+	walPath, _ := conf.WAL()
+	if err := checkEmptyDataDir(conf.SnapshotPath(), walPath); err != nil {
+		return err
+	}
+
+	r, closeInput, err := data.open(args[0])
+	if err != nil {
+		return err
+	}
+	defer closeInput()
+
+	idx := index.NewHNSW(cfg)
+	sparse := index.NewSparseIndex()
+	text := index.NewTextIndex()
+	start := time.Now()
+
+	var indexed, failed int
+	fail := func(id string, err error) {
+		if failed++; failed <= maxBuildErrors {
+			fmt.Fprintf(os.Stderr, "row %q: %v\n", id, err)
+		}
+	}
+	chunk := make([]dataset.Row, 0, buildChunk)
+	for {
+		row, err := r.Next()
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err // nothing written, the directory stays empty
+		}
+		if err == nil {
+			chunk = append(chunk, row)
+		}
+		if len(chunk) == buildChunk || (err != nil && len(chunk) > 0) {
+			n, errs := indexChunk(idx, sparse, text, chunk, *workers)
+			indexed += n
+			for _, e := range errs {
+				fail(e.id, e.err)
+			}
+			chunk = chunk[:0]
+			fmt.Fprintf(os.Stderr, "\r%d rows indexed (%.0f/s)", indexed, float64(indexed)/time.Since(start).Seconds())
+		}
+		if err != nil {
+			break
+		}
+	}
+	fmt.Fprintln(os.Stderr)
+	buildTime := time.Since(start)
+
+	if err := os.MkdirAll(conf.DataDir, 0755); err != nil {
+		return err
+	}
+	if err := server.WriteSnapshot(conf.SnapshotPath(), idx, sparse, text); err != nil {
+		return err
+	}
+	wal, err := storage.OpenWAL(walPath)
+	if err != nil {
+		return err
+	}
+	if err := wal.Close(); err != nil {
+		return err
+	}
+	info, err := os.Stat(conf.SnapshotPath())
+	if err != nil {
+		return err
+	}
+
+	err = g.printer().print([]string{"INDEXED", "FAILED", "SECONDS", "ROWS/S", "SNAPSHOT", "BYTES"},
+		[][]string{{
+			strconv.Itoa(indexed), strconv.Itoa(failed),
+			strconv.FormatFloat(buildTime.Seconds(), 'f', 1, 64),
+			strconv.FormatFloat(float64(indexed)/buildTime.Seconds(), 'f', 0, 64),
+			conf.SnapshotPath(), strconv.FormatInt(info.Size(), 10),
+		}},
+		map[string]any{
+			"indexed": indexed, "failed": failed, "seconds": buildTime.Seconds(),
+			"snapshot": conf.SnapshotPath(), "size_bytes": info.Size(),
+		})
+	if err == nil && failed > 0 {
+		err = fmt.Errorf("%d rows failed", failed)
+	}
+	return err
+}
+
+type rowError struct {
+	id  string
+	err error
+}
+
+// indexChunk adds the parts of each row to the indexes they belong in, as
+// the server's Insert does: dense vectors in one parallel batch, then the
+// sparse vectors and text of the rows whose vector went in.
+func indexChunk(idx *index.HNSW, sparse *index.SparseIndex, text *index.TextIndex, rows []dataset.Row, workers int) (int, []rowError) {
+	var (
+		ids []string
+		vs  []vec.Vector
+	)
+	for _, row := range rows {
+		if len(row.Vector) > 0 {
+			ids, vs = append(ids, row.ID), append(vs, row.Vector)
+		}
+	}
+	denseErrs := idx.InsertBatch(context.Background(), ids, vs, workers)
+
+	var (
+		indexed int
+		failed  []rowError
+		next    int
+	)
+	for _, row := range rows {
+		var err error
+		switch {
+		case len(row.Vector) > 0:
+			err = denseErrs[next]
+			next++
+		case row.Sparse.Len() == 0 && row.Text == "":
+			err = errors.New("row has no vector, sparse vector or text")
+		}
+		if err == nil && row.Sparse.Len() > 0 {
+			err = sparse.InsertSparse(row.ID, row.Sparse)
+		}
+		if err == nil && row.Text != "" {
+			err = text.Insert(row.ID, row.Text)
+		}
+		if err != nil {
+			failed = append(failed, rowError{row.ID, err})
+		} else {
+			indexed++
+		}
+	}
+	return indexed, failed
+}
+
+// checkEmptyDataDir refuses to build over a directory a server has used:
+// the new snapshot would replace its data and its WAL would be replayed on
+// top.
+func checkEmptyDataDir(snapshotPath, walPath string) error {
+	if _, err := os.Stat(snapshotPath); err == nil {
+		return fmt.Errorf("%s already exists", snapshotPath)
+	}
+	if info, err := os.Stat(walPath); err == nil && info.Size() > 0 {
+		return fmt.Errorf("%s is not empty", walPath)
+	}
+	return nil
+}
@@ -0,0 +1,470 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sandeep89846/nebuladb/api/proto/nebulapb"
+	"github.com/sandeep89846/nebuladb/internal/storage"
+	"github.com/sandeep89846/nebuladb/pkg/client"
+)
+
+func runInsert(g *globals, args []string) error {
+	fs := flag.NewFlagSet("insert", flag.ExitOnError)
+	id := fs.String("id", "", "insert a single vector, given as the argument, under this id")
+	text := fs.String("text", "", "text field for -id")
+	batch := fs.Int("batch", 256, "records per batch")
+	concurrency := fs.Int("concurrency", client.DefaultConcurrency, "inserts in flight")
+	encoding := fs.String("encoding", "float32", "wire encoding: float32, float16 or bfloat16")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	enc, err := parseEncoding(*encoding)
+	if err != nil {
+		return err
+	}
+	c, err := g.dial(client.WithEncoding(enc))
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if *id != "" {
+		if len(args) != 1 {
+			return errUsage
+		}
+		v, err := readVector(args[0])
+		if err != nil {
+			return err
+		}
+		ctx, cancel := g.context()
+		defer cancel()
+		if err := c.InsertItem(ctx, client.Item{ID: *id, Vector: v, Text: *text}); err != nil {
+			return err
+		}
+		return g.printer().print([]string{"INSERTED"}, [][]string{{*id}}, map[string]any{"inserted": 1})
+	}
+
+	if len(args) > 1 {
+		return errUsage
+	}
+	in, err := openInput(strings.Join(args, ""))
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	// -timeout applies to each batch, the whole load may take longer.
+	sig, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	flush := func(items []client.Item) (failed int) {
+		ctx, cancel := context.WithTimeout(sig, g.timeout)
+		defer cancel()
+		var be *client.BatchError
+		err := c.InsertBatch(ctx, items, *concurrency)
+		if errors.As(err, &be) {
+			for _, f := range be.Failed {
+				fmt.Fprintf(os.Stderr, "insert %q: %v\n", f.ID, f.Err)
+			}
+			return len(be.Failed)
+		}
+		return 0
+	}
+
+	var (
+		pending          []client.Item
+		total, failed, n int
+	)
+	sc := bufio.NewScanner(in)
+	sc.Buffer(make([]byte, 1<<20), 64<<20)
+	for sc.Scan() {
+		n++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		var r record
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			return fmt.Errorf("line %d: %v", n, err)
+		}
+		it, err := r.item()
+		if err != nil {
+			return fmt.Errorf("line %d: %v", n, err)
+		}
+		pending = append(pending, it)
+		if len(pending) == *batch {
+			total += len(pending)
+			failed += flush(pending)
+			pending = pending[:0]
+		}
+		if sig.Err() != nil {
+			break
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	if len(pending) > 0 && sig.Err() == nil {
+		total += len(pending)
+		failed += flush(pending)
+	}
+
+	err = g.printer().print([]string{"INSERTED", "FAILED"},
+		[][]string{{strconv.Itoa(total - failed), strconv.Itoa(failed)}},
+		map[string]any{"inserted": total - failed, "failed": failed})
+	if err == nil && failed > 0 {
+		err = fmt.Errorf("%d of %d inserts failed", failed, total)
+	}
+	if err == nil {
+		err = sig.Err()
+	}
+	return err
+}
+
+// parseEncoding maps float32, float16 or bfloat16 to a wire encoding.
+func parseEncoding(s string) (nebulapb.VectorEncoding, error) {
+	enc, ok := nebulapb.VectorEncoding_value[strings.ToUpper(s)]
+	if !ok || nebulapb.VectorEncoding(enc) == nebulapb.VectorEncoding_BINARY {
+		return 0, fmt.Errorf("unknown encoding %q", s)
+	}
+	return nebulapb.VectorEncoding(enc), nil
+}
+
+func runSearch(g *globals, args []string) error {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	k := fs.Int("k", 10, "number of matches")
+	ef := fs.Int("ef", 0, "HNSW search width (0: server default)")
+	text := fs.String("text", "", "BM25 keyword query, alone or fused with the vector")
+	bestEffort := fs.Bool("best-effort", false, "return what was found when the deadline nears")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) > 1 || (len(args) == 0 && *text == "") {
+		return errUsage
+	}
+
+	opts := []client.SearchOption{client.K(*k), client.Ef(*ef)}
+	if *text != "" {
+		opts = append(opts, client.Text(*text))
+	}
+	if *bestEffort {
+		opts = append(opts, client.BestEffort())
+	}
+	var q []float32
+	if len(args) == 1 {
+		if q, err = readVector(args[0]); err != nil {
+			return err
+		}
+	}
+
+	c, err := g.dial()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	ctx, cancel := g.context()
+	defer cancel()
+	matches, err := c.Search(ctx, q, opts...)
+	partial := errors.Is(err, client.ErrPartial)
+	if err != nil && !partial {
+		return err
+	}
+	if partial {
+		fmt.Fprintln(os.Stderr, "warning: deadline reached, results may be incomplete")
+	}
+
+	rows := make([][]string, len(matches))
+	out := make([]map[string]any, len(matches))
+	for i, m := range matches {
+		rows[i] = []string{m.ID, strconv.FormatFloat(float64(m.Score), 'f', 6, 32)}
+		out[i] = map[string]any{"id": m.ID, "score": m.Score}
+	}
+	return g.printer().print([]string{"ID", "SCORE"}, rows, map[string]any{"matches": out, "partial": partial})
+}
+
+func runGet(g *globals, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	c, err := g.dial()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	ctx, cancel := g.context()
+	defer cancel()
+
+	var rows [][]string
+	var out []map[string]any
+	for _, id := range args {
+		resp, err := c.Raw().Get(ctx, &nebulapb.GetRequest{Id: id})
+		if err != nil {
+			return fmt.Errorf("%s: %v", id, err)
+		}
+		if resp.Encoding == nebulapb.VectorEncoding_BINARY {
+			bits := fmt.Sprintf("%x", resp.PackedVector)
+			rows = append(rows, []string{id, strconv.Itoa(8 * len(resp.PackedVector)), bits})
+			out = append(out, map[string]any{"id": id, "bits": bits})
+			continue
+		}
+		v, err := client.DecodeVector(resp.Vector, resp.PackedVector, resp.Encoding)
+		if err != nil {
+			return fmt.Errorf("%s: %v", id, err)
+		}
+		rows = append(rows, []string{id, strconv.Itoa(len(v)), formatVector(v, 8)})
+		out = append(out, map[string]any{"id": id, "vector": v})
+	}
+	return g.printer().print([]string{"ID", "DIM", "VECTOR"}, rows, out)
+}
+
+func runDelete(g *globals, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	c, err := g.dial()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	ctx, cancel := g.context()
+	defer cancel()
+
+	var rows [][]string
+	var deleted []string
+	failed := 0
+	for _, id := range args {
+		if err := c.Delete(ctx, id); err != nil {
+			fmt.Fprintf(os.Stderr, "delete %q: %v\n", id, err)
+			failed++
+			continue
+		}
+		rows = append(rows, []string{id})
+		deleted = append(deleted, id)
+	}
+	if err := g.printer().print([]string{"DELETED"}, rows, map[string]any{"deleted": deleted}); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d deletes failed", failed, len(args))
+	}
+	return nil
+}
+
+func runStats(g *globals, args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+	c, err := g.dial()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	ctx, cancel := g.context()
+	defer cancel()
+	st, err := c.Stats(ctx)
+	if err != nil {
+		return err
+	}
+
+	rows := [][]string{
+		{"vectors", strconv.FormatInt(st.Vectors, 10)},
+		{"deleted", strconv.FormatInt(st.Deleted, 10)},
+		{"dimension", strconv.Itoa(int(st.Dimension))},
+		{"max_level", strconv.Itoa(int(st.MaxLevel))},
+		{"sparse_vectors", strconv.FormatInt(st.SparseVectors, 10)},
+		{"text_documents", strconv.FormatInt(st.TextDocuments, 10)},
+		{"wal_bytes", strconv.FormatUint(st.WalBytes, 10)},
+		{"wal_records", strconv.FormatUint(st.WalRecords, 10)},
+		{"searches", strconv.FormatUint(st.Searches, 10)},
+	}
+	return g.printer().print([]string{"STAT", "VALUE"}, rows, st)
+}
+
+func runSnapshot(g *globals, args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+	c, err := g.dial()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	ctx, cancel := g.context()
+	defer cancel()
+	snap, err := c.Snapshot(ctx)
+	if err != nil {
+		return err
+	}
+	return g.printer().print([]string{"PATH", "BYTES", "MS"},
+		[][]string{{snap.Path, strconv.FormatInt(snap.SizeBytes, 10), strconv.FormatInt(snap.DurationMs, 10)}}, snap)
+}
+
+func runWAL(g *globals, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	switch args[0] {
+	case "dump":
+		return walDump(g, args[1:])
+	case "verify":
+		return walVerify(g, args[1:])
+	case "repair":
+		return walRepair(g, args[1:])
+	}
+	return errUsage
+}
+
+func walDump(g *globals, args []string) error {
+	fs := flag.NewFlagSet("wal dump", flag.ExitOnError)
+	limit := fs.Int("n", 0, "print at most N records (0: all)")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	var (
+		rows [][]string
+		out  []map[string]any
+	)
+	errStop := errors.New("limit reached")
+	err = storage.InspectWAL(args[0], func(e storage.WALEntry) error {
+		op, dim, crc := storage.OpName(e.Record.Op), recordDim(e.Record), "ok"
+		if !e.CRCOK {
+			crc = "BAD"
+		}
+		rows = append(rows, []string{strconv.FormatInt(e.Offset, 10), op, e.Record.ID, strconv.Itoa(dim), crc})
+		out = append(out, map[string]any{"offset": e.Offset, "op": op, "id": e.Record.ID, "dim": dim, "crc_ok": e.CRCOK})
+		if len(rows) == *limit {
+			return errStop
+		}
+		return nil
+	})
+	if perr := g.printer().print([]string{"OFFSET", "OP", "ID", "DIM", "CRC"}, rows, out); perr != nil {
+		return perr
+	}
+	if err == errStop {
+		return nil
+	}
+	return err
+}
+
+func walVerify(g *globals, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	fi, err := os.Stat(args[0])
+	if err != nil {
+		return err
+	}
+
+	var (
+		records, bad int
+		ops          = map[string]int{}
+		ids          = map[string]struct{}{}
+		minDim       = -1
+		maxDim       int
+	)
+	err = storage.InspectWAL(args[0], func(e storage.WALEntry) error {
+		records++
+		if !e.CRCOK {
+			bad++
+			return nil
+		}
+		ops[storage.OpName(e.Record.Op)]++
+		ids[e.Record.ID] = struct{}{}
+		if e.Record.Op == storage.OpInsert {
+			d := len(e.Record.Vector)
+			if minDim < 0 || d < minDim {
+				minDim = d
+			}
+			maxDim = max(maxDim, d)
+		}
+		return nil
+	})
+	var ce *storage.CorruptError
+	if err != nil && !errors.As(err, &ce) {
+		return err
+	}
+
+	report := map[string]any{
+		"bytes":         fi.Size(),
+		"records":       records,
+		"bad_checksums": bad,
+		"ids":           len(ids),
+		"ops":           ops,
+	}
+	rows := [][]string{
+		{"bytes", strconv.FormatInt(fi.Size(), 10)},
+		{"records", strconv.Itoa(records)},
+		{"bad_checksums", strconv.Itoa(bad)},
+		{"ids", strconv.Itoa(len(ids))},
+	}
+	if minDim >= 0 {
+		report["min_dim"], report["max_dim"] = minDim, maxDim
+		rows = append(rows, []string{"dimension", fmt.Sprintf("%d..%d", minDim, maxDim)})
+	}
+	names := make([]string, 0, len(ops))
+	for op := range ops {
+		names = append(names, op)
+	}
+	sort.Strings(names)
+	for _, op := range names {
+		rows = append(rows, []string{"op " + op, strconv.Itoa(ops[op])})
+	}
+	if ce != nil {
+		report["torn_offset"], report["torn_bytes"] = ce.Offset, fi.Size()-ce.Offset
+		rows = append(rows, []string{"torn_tail", fmt.Sprintf("%d bytes at offset %d", fi.Size()-ce.Offset, ce.Offset)})
+	}
+	if err := g.printer().print([]string{"CHECK", "VALUE"}, rows, report); err != nil {
+		return err
+	}
+
+	if bad > 0 || ce != nil {
+		return fmt.Errorf("%s is damaged; 'nebulactl wal repair' writes a copy without the bad records", args[0])
+	}
+	return nil
+}
+
+func walRepair(g *globals, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	st, err := storage.RepairWAL(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	err = g.printer().print([]string{"KEPT", "BAD_CHECKSUMS", "TORN_BYTES"},
+		[][]string{{strconv.Itoa(st.Kept), strconv.Itoa(st.BadChecksum), strconv.FormatInt(st.TornBytes, 10)}},
+		map[string]any{"kept": st.Kept, "bad_checksums": st.BadChecksum, "torn_bytes": st.TornBytes})
+	if err == nil {
+		fmt.Fprintf(os.Stderr, "Wrote %s. Stop the server and move it over %s to use it.\n", args[1], args[0])
+	}
+	return err
+}
+
+// recordDim is the vector dimension, sparse entry count or text length of r.
+func recordDim(r storage.Record) int {
+	switch r.Op {
+	case storage.OpInsertBinary:
+		return r.BitLen
+	case storage.OpInsertSparse:
+		return r.Sparse.Len()
+	case storage.OpInsertText:
+		return len(r.Text)
+	}
+	return len(r.Vector)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+
+	"github.com/sandeep89846/nebuladb/pkg/client"
+	"github.com/sandeep89846/nebuladb/pkg/dataset"
+)
+
+func runExport(g *globals, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", "", "jsonl, npy or fvecs (default: from the file extension)")
+	idsFile := fs.String("ids", "", "with npy or fvecs, also write the IDs to FILE, one per line")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 || (args[0] == "-" && *format == "") {
+		return errUsage // stdout needs -format
+	}
+	f := dataset.Format(*format)
+	if f == "" {
+		if f, err = dataset.FormatOf(args[0]); err != nil {
+			return err
+		}
+	}
+	switch {
+	case f != dataset.FormatJSONL && f != dataset.FormatNpy && f != dataset.FormatFvecs:
+		return fmt.Errorf("cannot export to %s", f)
+	case f == dataset.FormatNpy && args[0] == "-":
+		return errors.New("npy output must be a file")
+	case f == dataset.FormatJSONL && *idsFile != "":
+		return errors.New("-ids is for npy and fvecs; JSONL rows carry their IDs")
+	}
+
+	var out io.WriteSeeker = os.Stdout
+	if args[0] != "-" {
+		file, err := os.Create(args[0])
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	var w dataset.Writer
+	switch f {
+	case dataset.FormatJSONL:
+		w = dataset.NewJSONLWriter(out, dataset.DefaultJSONLFields)
+	case dataset.FormatNpy:
+		w = dataset.NewNpyWriter(out)
+	case dataset.FormatFvecs:
+		w = dataset.NewFvecsWriter(out)
+	}
+	if *idsFile != "" {
+		idf, err := os.Create(*idsFile)
+		if err != nil {
+			return err
+		}
+		defer idf.Close()
+		w = dataset.WithLineIDs(w, idf)
+	}
+
+	c, err := g.dial()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	// One stream for the whole index, so -timeout does not apply.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	start := time.Now()
+	var written, skipped int
+	err = c.Export(ctx, func(it client.Item) error {
+		if it.Bits != nil {
+			return fmt.Errorf("%q is a binary vector, which %s cannot hold", it.ID, f)
+		}
+		if f != dataset.FormatJSONL && len(it.Vector) == 0 {
+			skipped++ // sparse or text only
+			return nil
+		}
+		if err := w.Write(dataset.Row{ID: it.ID, Vector: it.Vector, Sparse: it.Sparse, Text: it.Text}); err != nil {
+			return err
+		}
+		if written++; written%10000 == 0 {
+			fmt.Fprintf(os.Stderr, "\r%d rows written (%.0f/s)", written, float64(written)/time.Since(start).Seconds())
+		}
+		return nil
+	})
+	if written >= 10000 {
+		fmt.Fprintln(os.Stderr)
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		return err
+	}
+	if skipped > 0 {
+		fmt.Fprintf(os.Stderr, "skipped %d records without a dense vector\n", skipped)
+	}
+	if args[0] == "-" {
+		return nil // stdout holds the data
+	}
+
+	elapsed := time.Since(start)
+	return g.printer().print([]string{"WRITTEN", "SKIPPED", "SECONDS"},
+		[][]string{{
+			strconv.Itoa(written), strconv.Itoa(skipped),
+			strconv.FormatFloat(elapsed.Seconds(), 'f', 1, 64),
+		}},
+		map[string]any{"written": written, "skipped": skipped, "seconds": elapsed.Seconds()})
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+
+	"github.com/sandeep89846/nebuladb/pkg/client"
+	"github.com/sandeep89846/nebuladb/pkg/dataset"
+)
+
+// datasetFlags select and name the rows of a dataset file, for import and
+// build.
+type datasetFlags struct {
+	format      *string
+	idsFile     *string
+	idPrefix    *string
+	idStart     *int
+	idField     *string
+	vectorField *string
+	textField   *string
+	limit       *int
+}
+
+func addDatasetFlags(fs *flag.FlagSet) *datasetFlags {
+	return &datasetFlags{
+		format:      fs.String("format", "", "fvecs, bvecs, ivecs, npy or jsonl (default: from the file extension)"),
+		idsFile:     fs.String("ids", "", "file with one ID per row, for formats without IDs"),
+		idPrefix:    fs.String("id-prefix", "", "prefix of generated IDs"),
+		idStart:     fs.Int("id-start", 0, "first generated ID number"),
+		idField:     fs.String("id-field", "id", "JSONL key holding the ID"),
+		vectorField: fs.String("vector-field", "vector", "JSONL key holding the vector"),
+		textField:   fs.String("text-field", "text", "JSONL key holding the text field"),
+		limit:       fs.Int("limit", 0, "read at most N rows (0: all)"),
+	}
+}
+
+// open reads name, with "-" meaning stdin, which needs -format. The
+// returned closer closes the input and the ID file.
+func (d *datasetFlags) open(name string) (dataset.Reader, func(), error) {
+	if name == "-" && *d.format == "" {
+		return nil, nil, errUsage
+	}
+	f := dataset.Format(*d.format)
+	if f == "" {
+		var err error
+		if f, err = dataset.FormatOf(name); err != nil {
+			return nil, nil, err
+		}
+	}
+	in, err := openInput(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	closers := []io.Closer{in}
+	closeAll := func() {
+		for _, c := range closers {
+			c.Close()
+		}
+	}
+
+	var r dataset.Reader
+	if f == dataset.FormatJSONL {
+		r = dataset.NewJSONLReader(in, dataset.JSONLFields{
+			ID: *d.idField, Vector: *d.vectorField, Sparse: "sparse", Text: *d.textField,
+		})
+	} else if r, err = dataset.NewReader(bufio.NewReaderSize(in, 1<<20), f); err != nil {
+		closeAll()
+		return nil, nil, err
+	}
+
+	ids := dataset.SequentialIDs(*d.idPrefix, *d.idStart)
+	if *d.idsFile != "" {
+		idf, err := os.Open(*d.idsFile)
+		if err != nil {
+			closeAll()
+			return nil, nil, err
+		}
+		closers = append(closers, idf)
+		ids = dataset.LineIDs(idf)
+	}
+	r = dataset.WithIDs(r, ids)
+	if *d.limit > 0 {
+		r = dataset.Limit(r, *d.limit)
+	}
+	return r, closeAll, nil
+}
+
+func runImport(g *globals, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	data := addDatasetFlags(fs)
+	encoding := fs.String("encoding", "float32", "wire encoding: float32, float16 or bfloat16")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return errUsage
+	}
+	enc, err := parseEncoding(*encoding)
+	if err != nil {
+		return err
+	}
+	r, closeInput, err := data.open(args[0])
+	if err != nil {
+		return err
+	}
+	defer closeInput()
+
+	c, err := g.dial(client.WithEncoding(enc))
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	// One stream for the whole file, so -timeout does not apply.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	start := time.Now()
+	res, err := dataset.Import(ctx, c, r, func(sent int) {
+		fmt.Fprintf(os.Stderr, "\r%d rows sent (%.0f/s)", sent, float64(sent)/time.Since(start).Seconds())
+	})
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return err
+	}
+	for _, e := range res.Errors {
+		fmt.Fprintf(os.Stderr, "insert %q: %v\n", e.ID, e.Err)
+	}
+
+	elapsed := time.Since(start)
+	err = g.printer().print([]string{"INSERTED", "FAILED", "SECONDS", "ROWS/S"},
+		[][]string{{
+			strconv.FormatInt(res.Inserted, 10), strconv.FormatInt(res.Failed, 10),
+			strconv.FormatFloat(elapsed.Seconds(), 'f', 1, 64),
+			strconv.FormatFloat(float64(res.Inserted)/elapsed.Seconds(), 'f', 0, 64),
+		}},
+		map[string]any{"inserted": res.Inserted, "failed": res.Failed, "seconds": elapsed.Seconds()})
+	if err == nil && res.Failed > 0 {
+		err = fmt.Errorf("%d rows failed", res.Failed)
+	}
+	return err
+}
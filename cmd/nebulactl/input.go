@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sandeep89846/nebuladb/pkg/client"
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+// record is one line of insert input.
+type record struct {
+	ID     string    `json:"id"`
+	Vector []float32 `json:"vector"`
+	Sparse *struct {
+		Indices []uint32  `json:"indices"`
+		Values  []float32 `json:"values"`
+	} `json:"sparse"`
+	Text string `json:"text"`
+}
+
+func (r record) item() (client.Item, error) {
+	if r.ID == "" {
+		return client.Item{}, fmt.Errorf("record has no id")
+	}
+	it := client.Item{ID: r.ID, Vector: r.Vector, Text: r.Text}
+	if r.Sparse != nil {
+		sp, err := vec.NewSparseVector(r.Sparse.Indices, r.Sparse.Values)
+		if err != nil {
+			return client.Item{}, fmt.Errorf("record %q: %v", r.ID, err)
+		}
+		it.Sparse = sp
+	}
+	return it, nil
+}
+
+// openInput opens name, with "" and "-" meaning stdin.
+func openInput(name string) (io.ReadCloser, error) {
+	if name == "" || name == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(name)
+}
+
+// parseVector accepts a JSON array or comma/space separated numbers.
+func parseVector(s string) (vec.Vector, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "[") {
+		var v vec.Vector
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			return nil, fmt.Errorf("invalid vector: %v", err)
+		}
+		return v, nil
+	}
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' || r == '\n' })
+	v := make(vec.Vector, len(fields))
+	for i, f := range fields {
+		x, err := strconv.ParseFloat(f, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vector value %q", f)
+		}
+		v[i] = float32(x)
+	}
+	if len(v) == 0 {
+		return nil, fmt.Errorf("empty vector")
+	}
+	return v, nil
+}
+
+// readVector parses arg, or stdin when arg is "-".
+func readVector(arg string) (vec.Vector, error) {
+	if arg != "-" {
+		return parseVector(arg)
+	}
+	b, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return nil, err
+	}
+	return parseVector(string(b))
+}
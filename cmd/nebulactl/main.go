@@ -0,0 +1,163 @@
+// Command nebulactl talks to a running NebulaDB server: inserting, searching,
+// fetching and deleting vectors, importing and exporting datasets, reading
+// stats and triggering snapshots. The wal and build commands work on files
+// directly and need no server.
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+
+	"github.com/sandeep89846/nebuladb/pkg/client"
+)
+
+// globals are the flags given before the command.
+type globals struct {
+	addr    string
+	apiKey  string
+	tls     bool
+	caFile  string
+	cert    string
+	key     string
+	timeout time.Duration
+	output  string
+}
+
+type command struct {
+	usage string // arguments, after the command name
+	help  string
+	run   func(g *globals, args []string) error
+}
+
+var commands = map[string]command{
+	"build":    {"[-config FILE] [-data-dir DIR] [-workers N] [-format F] [-ids FILE | -id-prefix P] FILE|-", "index a dataset file into a new data directory (offline)", runBuild},
+	"export":   {"[-format F] [-ids FILE] FILE|-", "write every record to a JSONL, npy or fvecs file", runExport},
+	"import":   {"[-format F] [-ids FILE | -id-prefix P] [-limit N] FILE|-", "bulk load fvecs, bvecs, ivecs, npy or JSONL data", runImport},
+	"insert":   {"[-id ID VECTOR | FILE|-]", "insert one vector, or JSONL records from a file or stdin", runInsert},
+	"search":   {"[-k N] [-ef N] [-text QUERY] [VECTOR|-]", "search by vector and/or keywords", runSearch},
+	"get":      {"ID...", "print stored vectors", runGet},
+	"delete":   {"ID...", "delete vectors from every index", runDelete},
+	"stats":    {"", "print index sizes and WAL counters", runStats},
+	"snapshot": {"", "checkpoint the server's indexes and empty its WAL (admin)", runSnapshot},
+	"wal":      {"dump [-n N] FILE | verify FILE | repair SRC DST", "inspect, check or repair a WAL file (offline)", runWAL},
+}
+
+func main() {
+	g := &globals{}
+	fs := flag.NewFlagSet("nebulactl", flag.ExitOnError)
+	fs.StringVar(&g.addr, "addr", envOr("NEBULA_ADDR", "localhost:50051"), "server address ($NEBULA_ADDR)")
+	fs.StringVar(&g.apiKey, "api-key", os.Getenv("NEBULA_API_KEY"), "API key ($NEBULA_API_KEY)")
+	fs.BoolVar(&g.tls, "tls", false, "connect with TLS")
+	fs.StringVar(&g.caFile, "ca", "", "CA bundle for the server certificate (implies -tls)")
+	fs.StringVar(&g.cert, "cert", "", "client certificate for mTLS (implies -tls)")
+	fs.StringVar(&g.key, "key", "", "client certificate key")
+	fs.DurationVar(&g.timeout, "timeout", 30*time.Second, "deadline for each request")
+	fs.StringVar(&g.output, "o", "table", "output format: table or json")
+	fs.Usage = func() { usage(fs) }
+	fs.Parse(os.Args[1:])
+
+	if fs.NArg() == 0 {
+		usage(fs)
+		os.Exit(2)
+	}
+	cmd, ok := commands[fs.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "nebulactl: unknown command %q\n", fs.Arg(0))
+		usage(fs)
+		os.Exit(2)
+	}
+	if g.output != "table" && g.output != "json" {
+		fmt.Fprintf(os.Stderr, "nebulactl: -o must be table or json\n")
+		os.Exit(2)
+	}
+	if err := cmd.run(g, fs.Args()[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "nebulactl %s: %v\n", fs.Arg(0), err)
+		os.Exit(1)
+	}
+}
+
+func usage(fs *flag.FlagSet) {
+	fmt.Fprintf(os.Stderr, "Usage: nebulactl [flags] COMMAND [args]\n\nCommands:\n")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		c := commands[name]
+		fmt.Fprintf(os.Stderr, "  %-9s %s\n  %-9s   %s\n", name, c.usage, "", c.help)
+	}
+	fmt.Fprintf(os.Stderr, "\nFlags:\n")
+	fs.PrintDefaults()
+}
+
+func envOr(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
+
+// dial connects to the server with the global flags.
+func (g *globals) dial(opts ...client.Option) (*client.Client, error) {
+	if g.apiKey != "" {
+		opts = append(opts, client.WithAPIKey(g.apiKey))
+	}
+	if g.tls || g.caFile != "" || g.cert != "" {
+		conf := &tls.Config{MinVersion: tls.VersionTLS12}
+		if g.caFile != "" {
+			pem, err := os.ReadFile(g.caFile)
+			if err != nil {
+				return nil, err
+			}
+			conf.RootCAs = x509.NewCertPool()
+			if !conf.RootCAs.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates in %s", g.caFile)
+			}
+		}
+		if g.cert != "" {
+			cert, err := tls.LoadX509KeyPair(g.cert, g.key)
+			if err != nil {
+				return nil, err
+			}
+			conf.Certificates = []tls.Certificate{cert}
+		}
+		opts = append(opts, client.WithTransportCredentials(credentials.NewTLS(conf)))
+	}
+	return client.New(g.addr, opts...)
+}
+
+// context is cancelled by an interrupt or after -timeout.
+func (g *globals) context() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	ctx, cancel := context.WithTimeout(ctx, g.timeout)
+	return ctx, func() { cancel(); stop() }
+}
+
+// parseFlags parses a command's flags, allowing them after the arguments
+// too, as in "search '[1,2]' -k 5".
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var rest []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return rest, nil
+		}
+		rest = append(rest, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+var errUsage = errors.New("wrong arguments, see nebulactl -h")
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// printer writes a result either as an aligned table or as JSON.
+type printer struct {
+	json bool
+	w    io.Writer
+}
+
+func (g *globals) printer() *printer {
+	return &printer{json: g.output == "json", w: os.Stdout}
+}
+
+// print writes rows under header, or v as indented JSON. Proto messages
+// are written with protojson, zero values included.
+func (p *printer) print(header []string, rows [][]string, v any) error {
+	if m, ok := v.(proto.Message); ok && p.json {
+		b, err := protojson.MarshalOptions{Multiline: true, EmitUnpopulated: true}.Marshal(m)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(p.w, "%s\n", b)
+		return err
+	}
+	if p.json {
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// formatVector shows up to max values of v.
+func formatVector(v []float32, max int) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, f := range v {
+		if i == max {
+			fmt.Fprintf(&b, " ... +%d", len(v)-max)
+			break
+		}
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(strconv.FormatFloat(float64(f), 'g', 6, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
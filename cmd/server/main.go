@@ -1,60 +1,305 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
 	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/sandeep89846/nebuladb/api/proto/nebulapb"
-	"github.com/sandeep89846/nebuladb/internal/index"
+	"github.com/sandeep89846/nebuladb/internal/admission"
+	"github.com/sandeep89846/nebuladb/internal/auth"
+	"github.com/sandeep89846/nebuladb/internal/config"
+	"github.com/sandeep89846/nebuladb/internal/gateway"
+	"github.com/sandeep89846/nebuladb/internal/metrics"
 	"github.com/sandeep89846/nebuladb/internal/server"
 	"github.com/sandeep89846/nebuladb/internal/storage"
-	"github.com/sandeep89846/nebuladb/pkg/vec"
+	"github.com/sandeep89846/nebuladb/internal/tracing"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 )
 
 func main() {
-	port := ":50051"
-	walPath := "nebula.wal"
+	conf, err := config.Load(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintf(os.Stderr, "Usage of %s:\n%s", os.Args[0], config.Usage())
+		os.Exit(0)
+	}
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
 
 	log.Println(" Starting NebulaDB...")
+	log.Printf(" Configuration:\n%s", conf)
 
-	cfg := index.DefaultConfig()
-	cfg.EfConstruction = 200 // Higher quality graph
-	cfg.M = 32               // for better recall
+	if err := os.MkdirAll(conf.DataDir, 0755); err != nil {
+		log.Fatalf("Failed to create data dir: %v", err)
+	}
 
-	idx := index.NewHNSW(cfg)
+	shutdownTracing, err := tracing.Setup(context.Background(), conf.TracingOptions())
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
 
-	wal, err := storage.OpenWAL(walPath)
+	walPath, walOpts := conf.WAL()
+	wal, err := storage.OpenWALWithOptions(walPath, walOpts)
 	if err != nil {
 		log.Fatalf("Failed to open WAL: %v", err)
 	}
-	defer wal.Close()
 
-	log.Println(" Replaying WAL to restore state...")
-	count := 0
-	err = wal.Replay(func(id string, v vec.Vector) {
+	m := metrics.New()
+	m.WatchWAL(wal)
 
-		if err := idx.Insert(id, v); err != nil {
-			log.Printf("Replay error for ID %s: %v", id, err)
+	// Serve health checks while the snapshot and WAL are loaded, so
+	// orchestrators can tell a booting server from a dead one.
+	srv := server.NewRestoringServer(wal)
+	unary := []grpc.UnaryServerInterceptor{m.UnaryServerInterceptor(), tracing.UnaryServerInterceptor()}
+	stream := []grpc.StreamServerInterceptor{m.StreamServerInterceptor(), tracing.StreamServerInterceptor()}
+	opts := []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(conf.Limits.MaxRecvMsgBytes),
+		grpc.MaxSendMsgSize(conf.Limits.MaxSendMsgBytes),
+		// Stop must not return while a handler can still write to the WAL.
+		grpc.WaitForHandlers(true),
+	}
+
+	var tlsConf *tls.Config
+	if conf.Security.TLSCert != "" {
+		tlsConf, err = auth.ServerTLS(conf.Security.TLSCert, conf.Security.TLSKey, conf.Security.ClientCA)
+		if err != nil {
+			log.Fatalf("Failed to set up TLS: %v", err)
 		}
-		count++
-	})
-	if err != nil {
-		log.Fatalf("WAL Replay failed: %v", err)
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConf)))
+		log.Printf(" TLS enabled (client certificates required: %t)", conf.Security.ClientCA != "")
+	} else {
+		log.Println(" WARNING: TLS is disabled, traffic is unencrypted")
+	}
+
+	stopKeys := make(chan struct{})
+	defer close(stopKeys)
+	if conf.Security.APIKeysFile != "" {
+		keys, err := auth.LoadKeyStore(conf.Security.APIKeysFile)
+		if err != nil {
+			log.Fatalf("Failed to load API keys: %v", err)
+		}
+		go keys.Watch(conf.Security.APIKeysReload, stopKeys)
+		a := auth.NewAuthenticator(keys, server.MethodRoles)
+		unary = append(unary, a.UnaryInterceptor())
+		stream = append(stream, a.StreamInterceptor())
+		log.Printf(" Loaded %d API keys from %s", keys.Len(), conf.Security.APIKeysFile)
+	} else {
+		log.Println(" WARNING: API keys are disabled, any client may read and write")
 	}
-	log.Printf(" Restored %d vectors from disk.", count)
 
-	lis, err := net.Listen("tcp", port)
+	// Admission runs after auth so rate limits apply per API key.
+	admit := admission.New(admission.Options{
+		Concurrency: map[string]int{
+			nebulapb.VectorService_Search_FullMethodName: conf.Limits.MaxConcurrentSearches,
+			nebulapb.VectorService_Insert_FullMethodName: conf.Limits.MaxConcurrentInserts,
+		},
+		Rate:  conf.Limits.Rate,
+		Burst: conf.Limits.Burst,
+	})
+	srv.SetLimits(server.Limits{MaxK: conf.Limits.MaxK, MaxDimension: conf.Limits.MaxDimension})
+
+	unary = append(unary, admit.UnaryInterceptor(), srv.UnaryInterceptor())
+	stream = append(stream, admit.StreamInterceptor(), srv.StreamInterceptor())
+	opts = append(opts, grpc.ChainUnaryInterceptor(unary...), grpc.ChainStreamInterceptor(stream...))
+	grpcServer := grpc.NewServer(opts...)
+	nebulapb.RegisterVectorServiceServer(grpcServer, srv)
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	reflection.Register(grpcServer)
+	setServing(healthServer, healthpb.HealthCheckResponse_NOT_SERVING)
+
+	lis, err := net.Listen("tcp", conf.ListenAddr)
 	if err != nil {
 		log.Fatalf("Failed to listen: %v", err)
 	}
 
-	grpcServer := grpc.NewServer()
-	srv := server.NewServer(idx, wal)
-	nebulapb.RegisterVectorServiceServer(grpcServer, srv)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- grpcServer.Serve(lis) }()
+	log.Printf(" Listening on %s, restoring state...", conf.ListenAddr)
+
+	var metricsServer *http.Server
+	if conf.MetricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", m.Handler())
+		metricsServer = &http.Server{Addr: conf.MetricsAddr, Handler: mux}
+		go func() {
+			if err := metricsServer.ListenAndServe(); err != http.ErrServerClosed {
+				serveErr <- fmt.Errorf("metrics: %v", err)
+			}
+		}()
+		log.Printf(" Metrics on http://%s/metrics", conf.MetricsAddr)
+	}
+
+	// The JSON gateway shares the gRPC unary interceptors and TLS setup.
+	var gw *gateway.Gateway
+	var gatewayServer *http.Server
+	if conf.GatewayAddr != "" {
+		gw = gateway.New(srv, unary...)
+		gatewayServer = &http.Server{Addr: conf.GatewayAddr, Handler: gw.Handler(), TLSConfig: tlsConf}
+		go func() {
+			var err error
+			if tlsConf != nil {
+				err = gatewayServer.ListenAndServeTLS("", "")
+			} else {
+				err = gatewayServer.ListenAndServe()
+			}
+			if err != http.ErrServerClosed {
+				serveErr <- fmt.Errorf("gateway: %v", err)
+			}
+		}()
+		log.Printf(" JSON API on %s, spec at /openapi.json", conf.GatewayAddr)
+	}
+
+	restoreStart := time.Now()
+	restored := make(chan error, 1)
+	go func() {
+		count, err := srv.Restore(conf.SnapshotPath(), conf.HNSW())
+		if err == nil {
+			log.Printf(" Replayed %d records from the WAL in %s.", count, time.Since(restoreStart).Round(time.Millisecond))
+		}
+		restored <- err
+	}()
+
+	progress := time.NewTicker(2 * time.Second)
+	for waiting := true; waiting; {
+		select {
+		case err := <-restored:
+			var corrupt *storage.CorruptError
+			if errors.As(err, &corrupt) {
+				log.Fatalf("Failed to restore state: %v\n"+
+					"Check the WAL with 'nebulactl wal verify %s'; 'nebulactl wal repair' writes a copy without the bad records.", err, walPath)
+			}
+			if err != nil {
+				log.Fatalf("Failed to restore state: %v", err)
+			}
+			waiting = false
+		case <-progress.C:
+			p := wal.ReplayProgress()
+			log.Printf(" Replay progress: %d records, %d/%d bytes", p.Records, p.Bytes, p.TotalBytes)
+		case err := <-serveErr:
+			log.Fatalf("Failed to serve: %v", err)
+		case <-ctx.Done():
+			// Nothing has been written yet; the WAL is intact for the next start.
+			log.Fatalf("Interrupted while restoring state")
+		}
+	}
+	progress.Stop()
+	m.ObserveReplay(time.Since(restoreStart))
+
+	idx, sparse, text := srv.Indexes()
+	if conf.Index.Prefetch {
+		// Serving starts right away; searches hit disk until this catches up.
+		go func() {
+			start := time.Now()
+			if err := idx.Prefetch(ctx); err != nil {
+				log.Printf("Vector prefetch stopped: %v", err)
+				return
+			}
+			log.Printf(" Prefetched vectors in %s.", time.Since(start).Round(time.Millisecond))
+		}()
+	}
+	m.WatchHNSW(idx)
+	m.WatchSize("sparse", sparse.Len)
+	m.WatchSize("text", text.Len)
 
-	log.Printf(" NebulaDB Engine ready on %s", port)
-	if err := grpcServer.Serve(lis); err != nil {
+	setServing(healthServer, healthpb.HealthCheckResponse_SERVING)
+	log.Printf(" NebulaDB Engine ready on %s", conf.ListenAddr)
+
+	select {
+	case err := <-serveErr:
 		log.Fatalf("Failed to serve: %v", err)
+	case <-ctx.Done():
+	}
+	stop() // a second signal kills the process
+
+	healthServer.Shutdown() // NOT_SERVING, so load balancers stop routing here
+	log.Printf(" Shutting down, draining RPCs for up to %s...", conf.Shutdown.Timeout)
+	gatewayDone := make(chan struct{})
+	go func() {
+		defer close(gatewayDone)
+		if gatewayServer == nil {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), conf.Shutdown.Timeout)
+		defer cancel()
+		if err := gatewayServer.Shutdown(ctx); err != nil {
+			gatewayServer.Close()
+		}
+		// Close leaves handlers running; they must be done before the
+		// snapshot and the WAL close below.
+		gw.Drain()
+	}()
+	if !drain(grpcServer, conf.Shutdown.Timeout) {
+		log.Println(" Drain timed out, cancelled remaining RPCs.")
+	}
+	<-gatewayDone
+	if metricsServer != nil {
+		metricsServer.Close()
+	}
+
+	if conf.Shutdown.Snapshot {
+		start := time.Now()
+		if err := srv.Checkpoint(conf.SnapshotPath()); err != nil {
+			log.Printf("Snapshot failed, keeping the WAL: %v", err)
+		} else {
+			log.Printf(" Wrote snapshot in %s.", time.Since(start).Round(time.Millisecond))
+		}
+	}
+	if err := wal.Close(); err != nil {
+		log.Fatalf("Failed to close WAL: %v", err)
+	}
+	if err := idx.Close(); err != nil {
+		log.Printf("Failed to close the vector file: %v", err)
+	}
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := shutdownTracing(flushCtx); err != nil {
+		log.Printf("Failed to flush traces: %v", err)
+	}
+	cancel()
+	log.Println(" Shutdown complete.")
+}
+
+// setServing reports st for the server as a whole and for VectorService.
+func setServing(h *health.Server, st healthpb.HealthCheckResponse_ServingStatus) {
+	h.SetServingStatus("", st)
+	h.SetServingStatus(nebulapb.VectorService_ServiceDesc.ServiceName, st)
+}
+
+// drain stops accepting RPCs and waits for in-flight ones to finish. After
+// timeout the rest are cancelled and their handlers awaited. It reports
+// whether the drain completed in time.
+func drain(s *grpc.Server, timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(done)
+	}()
+
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case <-done:
+		return true
+	case <-t.C:
+		s.Stop()
+		<-done
+		return false
 	}
 }
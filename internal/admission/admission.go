@@ -0,0 +1,165 @@
+// Package admission sheds load before it reaches the handlers: it caps
+// concurrent calls per method and rate-limits each client with a token
+// bucket. Rejected calls fail with ResourceExhausted so clients back off.
+package admission
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/sandeep89846/nebuladb/internal/auth"
+)
+
+type Options struct {
+	// Concurrency caps in-flight calls per full method name. Methods not
+	// listed, or listed with 0, are unlimited.
+	Concurrency map[string]int
+	// Rate is the sustained requests per second allowed per client, and
+	// Burst the bucket size. Rate 0 disables rate limiting.
+	Rate  float64
+	Burst int
+}
+
+// Controller applies Options to gRPC calls.
+type Controller struct {
+	slots   map[string]chan struct{}
+	clients *RateLimiter
+}
+
+func New(opts Options) *Controller {
+	c := &Controller{slots: make(map[string]chan struct{})}
+	for method, n := range opts.Concurrency {
+		if n > 0 {
+			c.slots[method] = make(chan struct{}, n)
+		}
+	}
+	if opts.Rate > 0 {
+		c.clients = NewRateLimiter(opts.Rate, opts.Burst)
+	}
+	return c
+}
+
+// admit reserves capacity for a call and returns the function releasing it.
+func (c *Controller) admit(ctx context.Context, method string) (func(), error) {
+	if c.clients != nil && !c.clients.Allow(clientKey(ctx), time.Now()) {
+		return nil, status.Error(codes.ResourceExhausted, "rate limit exceeded")
+	}
+
+	slots, ok := c.slots[method]
+	if !ok {
+		return func() {}, nil
+	}
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	default:
+		return nil, status.Errorf(codes.ResourceExhausted, "too many concurrent %s calls", method)
+	}
+}
+
+// clientKey identifies the caller for rate limiting: the API key name when
+// authenticated, else the peer's IP address.
+func clientKey(ctx context.Context) string {
+	if p := auth.FromContext(ctx); p != nil {
+		return "key:" + p.Name
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		addr := p.Addr.String()
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			addr = host
+		}
+		return "ip:" + addr
+	}
+	return ""
+}
+
+func (c *Controller) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		release, err := c.admit(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		return handler(ctx, req)
+	}
+}
+
+func (c *Controller) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		release, err := c.admit(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		defer release()
+		return handler(srv, ss)
+	}
+}
+
+// RateLimiter keeps a token bucket per client key.
+type RateLimiter struct {
+	rate  float64 // tokens per second
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter allows rate requests per second per client with bursts of
+// up to burst (at least 1).
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		rate:    rate,
+		burst:   float64(max(burst, 1)),
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow takes a token from key's bucket, reporting false if it is empty.
+func (r *RateLimiter) Allow(key string, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sweep(now)
+	b, ok := r.buckets[key]
+	if !ok {
+		b = &bucket{tokens: r.burst, last: now}
+		r.buckets[key] = b
+	}
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = min(r.burst, b.tokens+elapsed*r.rate)
+		b.last = now
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// sweep drops buckets that have refilled completely, since a fresh bucket
+// behaves the same. It runs at most once a minute.
+func (r *RateLimiter) sweep(now time.Time) {
+	if now.Sub(r.swept) < time.Minute {
+		return
+	}
+	r.swept = now
+	full := time.Duration(r.burst / r.rate * float64(time.Second))
+	for key, b := range r.buckets {
+		if now.Sub(b.last) >= full {
+			delete(r.buckets, key)
+		}
+	}
+}
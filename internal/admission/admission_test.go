@@ -0,0 +1,89 @@
+package admission
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRateLimiter(t *testing.T) {
+	r := NewRateLimiter(10, 3)
+	now := time.Unix(0, 0)
+
+	for i := range 3 {
+		if !r.Allow("a", now) {
+			t.Fatalf("request %d within burst rejected", i)
+		}
+	}
+	if r.Allow("a", now) {
+		t.Error("request beyond burst allowed")
+	}
+	if !r.Allow("b", now) {
+		t.Error("one client's burst limited another")
+	}
+	if !r.Allow("a", now.Add(100*time.Millisecond)) {
+		t.Error("bucket did not refill at the configured rate")
+	}
+	if r.Allow("a", now.Add(100*time.Millisecond)) {
+		t.Error("refill granted more than one token")
+	}
+
+	// Idle buckets are dropped once full, and behave like new ones.
+	later := now.Add(time.Hour)
+	r.Allow("c", later)
+	if _, ok := r.buckets["a"]; ok {
+		t.Error("idle bucket was not swept")
+	}
+}
+
+func TestController_Concurrency(t *testing.T) {
+	c := New(Options{Concurrency: map[string]int{"/svc/Search": 1}})
+	intercept := c.UnaryInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/svc/Search"}
+
+	entered, release := make(chan struct{}), make(chan struct{})
+	done := make(chan error)
+	go func() {
+		_, err := intercept(context.Background(), nil, info, func(context.Context, any) (any, error) {
+			close(entered)
+			<-release
+			return nil, nil
+		})
+		done <- err
+	}()
+	<-entered
+
+	noop := func(context.Context, any) (any, error) { return nil, nil }
+	if _, err := intercept(context.Background(), nil, info, noop); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("second concurrent call: got %v, want ResourceExhausted", err)
+	}
+	if _, err := intercept(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/svc/Insert"}, noop); err != nil {
+		t.Errorf("unlimited method rejected: %v", err)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := intercept(context.Background(), nil, info, noop); err != nil {
+		t.Errorf("slot not released: %v", err)
+	}
+}
+
+func TestController_RateLimit(t *testing.T) {
+	c := New(Options{Rate: 1, Burst: 1})
+	intercept := c.UnaryInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/svc/Search"}
+	noop := func(context.Context, any) (any, error) { return nil, nil }
+
+	if _, err := intercept(context.Background(), nil, info, noop); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := intercept(context.Background(), nil, info, noop); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("got %v, want ResourceExhausted", err)
+	}
+}
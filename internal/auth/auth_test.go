@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAuthenticator_Roles(t *testing.T) {
+	digest := sha256.Sum256([]byte("reader-key"))
+	path := filepath.Join(t.TempDir(), "keys.yaml")
+	writeFile(t, path, `
+keys:
+  - name: reader
+    sha256: `+hex.EncodeToString(digest[:])+`
+    roles: [read]
+  - name: writer
+    key: writer-key
+    roles: [read, write]
+  - name: ops
+    key: admin-key
+    roles: [admin]
+`)
+	keys, err := LoadKeyStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewAuthenticator(keys, MethodRoles{"/svc/Search": RoleRead, "/svc/Insert": RoleWrite})
+	intercept := a.UnaryInterceptor()
+
+	tests := []struct {
+		name   string
+		md     metadata.MD
+		method string
+		want   codes.Code
+	}{
+		{"no key", nil, "/svc/Search", codes.Unauthenticated},
+		{"unknown key", metadata.Pairs("x-api-key", "nope"), "/svc/Search", codes.Unauthenticated},
+		{"reader search", metadata.Pairs("x-api-key", "reader-key"), "/svc/Search", codes.OK},
+		{"reader insert", metadata.Pairs("x-api-key", "reader-key"), "/svc/Insert", codes.PermissionDenied},
+		{"writer bearer", metadata.Pairs("authorization", "Bearer writer-key"), "/svc/Insert", codes.OK},
+		{"writer unlisted", metadata.Pairs("x-api-key", "writer-key"), "/svc/Snapshot", codes.PermissionDenied},
+		{"admin unlisted", metadata.Pairs("x-api-key", "admin-key"), "/svc/Snapshot", codes.OK},
+		{"health is public", nil, "/grpc.health.v1.Health/Check", codes.OK},
+	}
+	for _, tt := range tests {
+		ctx := context.Background()
+		if tt.md != nil {
+			ctx = metadata.NewIncomingContext(ctx, tt.md)
+		}
+		var principal *Principal
+		_, err := intercept(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method},
+			func(ctx context.Context, req any) (any, error) {
+				principal = FromContext(ctx)
+				return nil, nil
+			})
+		if got := status.Code(err); got != tt.want {
+			t.Errorf("%s: code = %v, want %v (%v)", tt.name, got, tt.want, err)
+		}
+		if tt.want == codes.OK && tt.md != nil && principal == nil {
+			t.Errorf("%s: handler saw no principal", tt.name)
+		}
+	}
+}
+
+func TestKeyStore_Invalid(t *testing.T) {
+	tests := []string{
+		"keys: [{name: a, roles: [read]}]",
+		"keys: [{name: a, key: k, sha256: ab, roles: [read]}]",
+		"keys: [{name: a, sha256: zz, roles: [read]}]",
+		"keys: [{name: a, key: k}]",
+		"keys: [{name: a, key: k, roles: [root]}]",
+		"keys: [{name: a, key: k, roles: [read]}, {name: b, key: k, roles: [write]}]",
+	}
+	for _, data := range tests {
+		if _, err := parseKeys([]byte(data)); err == nil {
+			t.Errorf("parseKeys(%q) accepted invalid file", data)
+		}
+	}
+}
+
+func TestKeyStore_Watch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.yaml")
+	writeFile(t, path, "keys: [{name: old, key: one, roles: [read]}]")
+	keys, err := LoadKeyStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go keys.Watch(5*time.Millisecond, stop)
+
+	// A broken file keeps the previous keys.
+	writeFile(t, path, "keys: [{name: bad, key: two}]")
+	os.Chtimes(path, time.Now(), time.Now().Add(time.Second))
+	time.Sleep(50 * time.Millisecond)
+	if keys.Lookup("one") == nil {
+		t.Fatal("invalid reload dropped the previous keys")
+	}
+
+	writeFile(t, path, "keys: [{name: new, key: two, roles: [write]}]")
+	os.Chtimes(path, time.Now(), time.Now().Add(2*time.Second))
+	deadline := time.Now().Add(2 * time.Second)
+	for keys.Lookup("two") == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("key file change was not picked up")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if keys.Lookup("one") != nil {
+		t.Error("removed key still accepted after reload")
+	}
+}
+
+func TestPrincipal_CanAccess(t *testing.T) {
+	all := &Principal{}
+	scoped := &Principal{Collections: []string{"docs"}}
+	wild := &Principal{Collections: []string{"*"}}
+	if !all.CanAccess("x") || !wild.CanAccess("x") {
+		t.Error("unscoped key denied a collection")
+	}
+	if !scoped.CanAccess("docs") || scoped.CanAccess("images") {
+		t.Error("scoped key access is wrong")
+	}
+}
+
+func TestServerTLS(t *testing.T) {
+	dir := t.TempDir()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeFile(t, certFile, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})))
+	writeFile(t, keyFile, string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})))
+
+	cfg, err := ServerTLS(certFile, keyFile, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ClientAuth != tls.NoClientCert {
+		t.Errorf("TLS without client_ca requires client certs")
+	}
+
+	cfg, err = ServerTLS(certFile, keyFile, certFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ClientAuth != tls.RequireAndVerifyClientCert || cfg.ClientCAs == nil {
+		t.Errorf("mTLS not enforced: %v", cfg.ClientAuth)
+	}
+
+	if _, err := ServerTLS(certFile, keyFile, keyFile); err == nil {
+		t.Error("accepted a client CA file without certificates")
+	}
+}
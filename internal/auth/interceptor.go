@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// MethodRoles maps a full gRPC method name to the role it requires.
+// Methods not listed need RoleAdmin, so new RPCs are closed by default.
+type MethodRoles map[string]Role
+
+// Public methods skip authentication entirely; health checks must work for
+// orchestrators that hold no key.
+var Public = map[string]bool{
+	"/grpc.health.v1.Health/Check": true,
+	"/grpc.health.v1.Health/Watch": true,
+}
+
+type principalKey struct{}
+
+// FromContext returns the principal set by the interceptors, or nil.
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// Authenticator checks API keys against a KeyStore.
+type Authenticator struct {
+	keys  *KeyStore
+	roles MethodRoles
+}
+
+func NewAuthenticator(keys *KeyStore, roles MethodRoles) *Authenticator {
+	return &Authenticator{keys: keys, roles: roles}
+}
+
+// authorize authenticates the caller of method and returns ctx carrying
+// the principal.
+func (a *Authenticator) authorize(ctx context.Context, method string) (context.Context, error) {
+	if Public[method] {
+		return ctx, nil
+	}
+
+	key := keyFromMetadata(ctx)
+	if key == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing API key")
+	}
+	p := a.keys.Lookup(key)
+	if p == nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid API key")
+	}
+
+	need, ok := a.roles[method]
+	if !ok {
+		need = RoleAdmin
+	}
+	if !p.Has(need) {
+		return nil, status.Errorf(codes.PermissionDenied, "key %q lacks the %s role", p.Name, need)
+	}
+	return context.WithValue(ctx, principalKey{}, p), nil
+}
+
+// keyFromMetadata reads "authorization: Bearer <key>" or "x-api-key: <key>".
+func keyFromMetadata(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if v := md.Get("x-api-key"); len(v) > 0 {
+		return v[0]
+	}
+	for _, v := range md.Get("authorization") {
+		if key, ok := strings.CutPrefix(v, "Bearer "); ok {
+			return key
+		}
+	}
+	return ""
+}
+
+func (a *Authenticator) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := a.authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (a *Authenticator) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.authorize(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+type authedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authedStream) Context() context.Context { return s.ctx }
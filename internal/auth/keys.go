@@ -0,0 +1,191 @@
+// Package auth authenticates clients by API key and authorizes each RPC
+// against the roles attached to the key. Transport security (TLS, mTLS)
+// lives in tls.go.
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Role is a permission granted to a key.
+type Role string
+
+const (
+	RoleRead  Role = "read"  // Search and other lookups
+	RoleWrite Role = "write" // Insert and other mutations
+	RoleAdmin Role = "admin" // operational RPCs; implies read and write
+)
+
+// Principal is the identity a request was authenticated as.
+type Principal struct {
+	Name  string
+	Roles []Role
+	// Collections limits the key to the named collections; empty or "*"
+	// means all. There is a single implicit collection today, so scopes
+	// are parsed and checked by CanAccess but no RPC passes a name yet.
+	Collections []string
+}
+
+// Has reports whether p holds role, counting admin as every role.
+func (p *Principal) Has(role Role) bool {
+	return slices.Contains(p.Roles, role) || slices.Contains(p.Roles, RoleAdmin)
+}
+
+// CanAccess reports whether p is scoped to collection.
+func (p *Principal) CanAccess(collection string) bool {
+	if len(p.Collections) == 0 {
+		return true
+	}
+	return slices.Contains(p.Collections, "*") || slices.Contains(p.Collections, collection)
+}
+
+// keyFile is the on-disk format:
+//
+//	keys:
+//	  - name: ingest
+//	    sha256: 9f86d08...   # hex digest of the key, or
+//	    key: s3cret          # the key itself
+//	    roles: [read, write]
+//	    collections: ["*"]
+type keyFile struct {
+	Keys []struct {
+		Name        string   `yaml:"name"`
+		Key         string   `yaml:"key"`
+		SHA256      string   `yaml:"sha256"`
+		Roles       []Role   `yaml:"roles"`
+		Collections []string `yaml:"collections"`
+	} `yaml:"keys"`
+}
+
+// keySet maps the SHA-256 of each key to its principal, so plaintext keys
+// are not kept in memory.
+type keySet map[[sha256.Size]byte]*Principal
+
+func parseKeys(data []byte) (keySet, error) {
+	var f keyFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+
+	set := make(keySet, len(f.Keys))
+	var errs []error
+	for i, k := range f.Keys {
+		where := fmt.Sprintf("key %d (%s)", i, k.Name)
+
+		var digest [sha256.Size]byte
+		switch {
+		case k.Key != "" && k.SHA256 != "":
+			errs = append(errs, fmt.Errorf("%s: set only one of key and sha256", where))
+			continue
+		case k.Key != "":
+			digest = sha256.Sum256([]byte(k.Key))
+		case k.SHA256 != "":
+			b, err := hex.DecodeString(k.SHA256)
+			if err != nil || len(b) != sha256.Size {
+				errs = append(errs, fmt.Errorf("%s: sha256 must be %d hex bytes", where, sha256.Size))
+				continue
+			}
+			copy(digest[:], b)
+		default:
+			errs = append(errs, fmt.Errorf("%s: missing key or sha256", where))
+			continue
+		}
+
+		if len(k.Roles) == 0 {
+			errs = append(errs, fmt.Errorf("%s: no roles", where))
+		}
+		for _, r := range k.Roles {
+			if r != RoleRead && r != RoleWrite && r != RoleAdmin {
+				errs = append(errs, fmt.Errorf("%s: unknown role %q", where, r))
+			}
+		}
+		if _, dup := set[digest]; dup {
+			errs = append(errs, fmt.Errorf("%s: duplicate key", where))
+		}
+		set[digest] = &Principal{Name: k.Name, Roles: k.Roles, Collections: k.Collections}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// KeyStore holds the keys from a file and reloads them when it changes.
+type KeyStore struct {
+	path    string
+	keys    atomic.Pointer[keySet]
+	modTime time.Time // guarded by the Watch goroutine
+}
+
+// LoadKeyStore reads the key file at path.
+func LoadKeyStore(path string) (*KeyStore, error) {
+	s := &KeyStore{path: path}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload re-reads the key file. On error the current keys stay in place.
+func (s *KeyStore) Reload() error {
+	st, err := os.Stat(s.path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	set, err := parseKeys(data)
+	if err != nil {
+		return fmt.Errorf("key file %s: %v", s.path, err)
+	}
+	s.keys.Store(&set)
+	s.modTime = st.ModTime()
+	return nil
+}
+
+// Watch reloads the file whenever its modification time changes, checking
+// every interval until stop is closed. Failed reloads are logged and the
+// previous keys kept.
+func (s *KeyStore) Watch(interval time.Duration, stop <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+			st, err := os.Stat(s.path)
+			if err != nil || st.ModTime().Equal(s.modTime) {
+				continue
+			}
+			if err := s.Reload(); err != nil {
+				log.Printf("Keeping previous API keys: %v", err)
+				continue
+			}
+			log.Printf(" Reloaded API keys from %s", s.path)
+		}
+	}
+}
+
+// Lookup returns the principal for key, or nil.
+func (s *KeyStore) Lookup(key string) *Principal {
+	set := *s.keys.Load()
+	return set[sha256.Sum256([]byte(key))]
+}
+
+// Len returns the number of loaded keys.
+func (s *KeyStore) Len() int {
+	return len(*s.keys.Load())
+}
@@ -0,0 +1,441 @@
+// Package config loads the server configuration. Values are layered, each
+// overriding the one before: built-in defaults, a YAML file, NEBULA_*
+// environment variables, then command-line flags.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sandeep89846/nebuladb/internal/index"
+	"github.com/sandeep89846/nebuladb/internal/storage"
+	"github.com/sandeep89846/nebuladb/internal/tracing"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix prefixes every environment variable override.
+const EnvPrefix = "NEBULA_"
+
+type Config struct {
+	ListenAddr  string `yaml:"listen_addr"`
+	MetricsAddr string `yaml:"metrics_addr"` // empty disables /metrics
+	GatewayAddr string `yaml:"gateway_addr"` // empty disables the HTTP/JSON API
+	DataDir     string `yaml:"data_dir"`
+
+	Index      IndexConfig      `yaml:"index"`
+	Durability DurabilityConfig `yaml:"durability"`
+	Limits     LimitsConfig     `yaml:"limits"`
+	Shutdown   ShutdownConfig   `yaml:"shutdown"`
+	Tracing    TracingConfig    `yaml:"tracing"`
+	Security   SecurityConfig   `yaml:"security"`
+}
+
+type IndexConfig struct {
+	Type           string `yaml:"type"` // only "hnsw"; DiskANN is not served yet, see nebula-bench
+	M              int    `yaml:"m"`
+	M0             int    `yaml:"m0"` // 0 means 2*M
+	EfConstruction int    `yaml:"ef_construction"`
+	EfSearch       int    `yaml:"ef_search"`
+	Metric         string `yaml:"metric"`
+	Precision      string `yaml:"precision"`
+	Seed           int    `yaml:"seed"`
+	VectorStorage  string `yaml:"vector_storage"` // "heap" or "mmap", a file in data_dir
+	Prefetch       bool   `yaml:"prefetch"`       // page mmap'd vectors in after startup
+}
+
+type DurabilityConfig struct {
+	// WALSync is one of "none", "always" or "interval".
+	WALSync         string        `yaml:"wal_sync"`
+	WALSyncInterval time.Duration `yaml:"wal_sync_interval"`
+}
+
+type LimitsConfig struct {
+	MaxRecvMsgBytes int `yaml:"max_recv_msg_bytes"`
+	MaxSendMsgBytes int `yaml:"max_send_msg_bytes"`
+
+	// The limits below are unlimited when 0.
+	MaxK                  int `yaml:"max_k"`
+	MaxDimension          int `yaml:"max_dimension"`
+	MaxConcurrentSearches int `yaml:"max_concurrent_searches"`
+	MaxConcurrentInserts  int `yaml:"max_concurrent_inserts"`
+	// Rate is the requests per second allowed per client (API key, or IP
+	// without keys); Burst is how many may arrive at once.
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
+}
+
+type ShutdownConfig struct {
+	// Timeout bounds how long in-flight RPCs may run after a stop signal
+	// before they are cancelled.
+	Timeout time.Duration `yaml:"timeout"`
+	// Snapshot writes an index snapshot and empties the WAL on exit, so
+	// the next start loads the snapshot instead of replaying the log.
+	Snapshot bool `yaml:"snapshot"`
+}
+
+type TracingConfig struct {
+	// Exporter is one of "none", "stdout", "file" or "otlp".
+	Exporter string `yaml:"exporter"`
+	// File receives JSON spans for the file exporter; relative paths are
+	// under data_dir.
+	File        string  `yaml:"file"`
+	Endpoint    string  `yaml:"endpoint"` // OTLP/gRPC collector host:port
+	Insecure    bool    `yaml:"insecure"` // plaintext OTLP
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+type SecurityConfig struct {
+	// TLSCert and TLSKey enable TLS on the gRPC port when both are set.
+	TLSCert string `yaml:"tls_cert"`
+	TLSKey  string `yaml:"tls_key"`
+	// ClientCA requires clients to present a certificate it signed (mTLS).
+	ClientCA string `yaml:"client_ca"`
+	// APIKeysFile enables API-key authentication; see package auth for
+	// the format. It is re-read when it changes.
+	APIKeysFile   string        `yaml:"api_keys_file"`
+	APIKeysReload time.Duration `yaml:"api_keys_reload"`
+}
+
+// Default returns the configuration used for anything not set in a file,
+// the environment or flags. It serves gRPC on :50051, metrics on :2112 and
+// the JSON gateway on :8080, and leaves tracing off: pick an exporter to
+// turn it on.
+func Default() Config {
+	return Config{
+		ListenAddr:  ":50051",
+		MetricsAddr: ":2112",
+		GatewayAddr: ":8080",
+		DataDir:     ".",
+		Index: IndexConfig{
+			Type:           "hnsw",
+			M:              32,
+			EfConstruction: 200,
+			EfSearch:       50,
+			Metric:         index.MetricCosine.String(),
+			Precision:      index.PrecisionFloat32.String(),
+			VectorStorage:  "heap",
+		},
+		Durability: DurabilityConfig{
+			WALSync:         storage.SyncNone.String(),
+			WALSyncInterval: time.Second,
+		},
+		Limits: LimitsConfig{
+			MaxRecvMsgBytes: 4 << 20, // grpc default
+			MaxSendMsgBytes: math.MaxInt32,
+			MaxK:            10000,
+			MaxDimension:    65536,
+			Burst:           100,
+		},
+		Shutdown: ShutdownConfig{
+			Timeout: 30 * time.Second,
+		},
+		Tracing: TracingConfig{
+			Exporter:    tracing.ExporterNone,
+			File:        "traces.jsonl",
+			Endpoint:    "localhost:4317",
+			SampleRatio: 1,
+		},
+		Security: SecurityConfig{
+			APIKeysReload: 10 * time.Second,
+		},
+	}
+}
+
+// setting binds one config key to its field. The key doubles as the flag
+// name and, upper-cased with dots turned into underscores, the env var.
+type setting struct {
+	key   string
+	usage string
+	ptr   any // *string, *int, *bool, *float64 or *time.Duration
+}
+
+func (c *Config) settings() []setting {
+	return []setting{
+		{"listen_addr", "gRPC listen address", &c.ListenAddr},
+		{"metrics_addr", "HTTP address serving /metrics (empty disables)", &c.MetricsAddr},
+		{"gateway_addr", "HTTP address serving the JSON API (empty disables)", &c.GatewayAddr},
+		{"data_dir", "directory holding the WAL and snapshot", &c.DataDir},
+		{"index.type", "index implementation (hnsw; diskann is only in nebula-bench)", &c.Index.Type},
+		{"index.m", "HNSW max connections per layer", &c.Index.M},
+		{"index.m0", "HNSW max connections at layer 0 (0 = 2*m)", &c.Index.M0},
+		{"index.ef_construction", "HNSW search width during insert", &c.Index.EfConstruction},
+		{"index.ef_search", "HNSW default search width", &c.Index.EfSearch},
+		{"index.metric", "distance metric (cosine, hamming, jaccard)", &c.Index.Metric},
+		{"index.precision", "vector storage precision (float32, float16, bfloat16)", &c.Index.Precision},
+		{"index.seed", "HNSW level seed; a snapshot keeps the seed it was built with", &c.Index.Seed},
+		{"index.vector_storage", "where vectors live (heap, mmap: a file in data_dir paged in on demand)", &c.Index.VectorStorage},
+		{"index.prefetch", "with mmap storage, read the vectors into the page cache after startup", &c.Index.Prefetch},
+		{"durability.wal_sync", "WAL fsync policy (none, always, interval)", &c.Durability.WALSync},
+		{"durability.wal_sync_interval", "fsync period for the interval policy", &c.Durability.WALSyncInterval},
+		{"limits.max_recv_msg_bytes", "largest accepted gRPC message", &c.Limits.MaxRecvMsgBytes},
+		{"limits.max_send_msg_bytes", "largest gRPC message sent", &c.Limits.MaxSendMsgBytes},
+		{"limits.max_k", "most results a search may request (0 = unlimited)", &c.Limits.MaxK},
+		{"limits.max_dimension", "most components a vector may have (0 = unlimited)", &c.Limits.MaxDimension},
+		{"limits.max_concurrent_searches", "searches served at once before rejecting (0 = unlimited)", &c.Limits.MaxConcurrentSearches},
+		{"limits.max_concurrent_inserts", "inserts served at once before rejecting (0 = unlimited)", &c.Limits.MaxConcurrentInserts},
+		{"limits.rate", "requests per second allowed per client (0 = unlimited)", &c.Limits.Rate},
+		{"limits.burst", "requests a client may send at once above limits.rate", &c.Limits.Burst},
+		{"shutdown.timeout", "how long to drain in-flight RPCs on shutdown", &c.Shutdown.Timeout},
+		{"shutdown.snapshot", "write an index snapshot and empty the WAL on shutdown", &c.Shutdown.Snapshot},
+		{"tracing.exporter", "span exporter (none, stdout, file, otlp)", &c.Tracing.Exporter},
+		{"tracing.file", "span file for the file exporter, relative to data_dir", &c.Tracing.File},
+		{"tracing.endpoint", "OTLP/gRPC collector address", &c.Tracing.Endpoint},
+		{"tracing.insecure", "connect to the OTLP collector without TLS", &c.Tracing.Insecure},
+		{"tracing.sample_ratio", "fraction of traces to keep (0..1)", &c.Tracing.SampleRatio},
+		{"security.tls_cert", "PEM server certificate (enables TLS with tls_key)", &c.Security.TLSCert},
+		{"security.tls_key", "PEM server private key", &c.Security.TLSKey},
+		{"security.client_ca", "PEM CA bundle clients must be signed by (mTLS)", &c.Security.ClientCA},
+		{"security.api_keys_file", "YAML file of API keys and roles (empty disables)", &c.Security.APIKeysFile},
+		{"security.api_keys_reload", "how often to check the API key file for changes", &c.Security.APIKeysReload},
+	}
+}
+
+func envName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+func set(ptr any, raw string) error {
+	switch p := ptr.(type) {
+	case *string:
+		*p = raw
+	case *int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		*p = n
+	case *float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		*p = f
+	case *bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		*p = b
+	case *time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		*p = d
+	default:
+		return fmt.Errorf("unsupported setting type %T", ptr)
+	}
+	return nil
+}
+
+// Load builds the configuration from defaults, the file named by -config
+// (or NEBULA_CONFIG), the environment and args, then validates it.
+// getenv is os.Getenv outside of tests.
+func Load(args []string, getenv func(string) string) (Config, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet("nebuladb", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	configPath := fs.String("config", getenv(EnvPrefix+"CONFIG"), "path to a YAML config file")
+
+	// Flags are collected first and applied last so they win over the
+	// file and the environment.
+	flagged := make(map[string]string)
+	for _, s := range cfg.settings() {
+		key := s.key
+		record := func(v string) error {
+			flagged[key] = v
+			return nil
+		}
+		if _, ok := s.ptr.(*bool); ok {
+			fs.BoolFunc(key, s.usage, record) // allow a bare -key
+		} else {
+			fs.Func(key, s.usage, record)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+
+	if *configPath != "" {
+		if err := cfg.loadFile(*configPath); err != nil {
+			return cfg, err
+		}
+	}
+
+	for _, s := range cfg.settings() {
+		name := envName(s.key)
+		if v := getenv(name); v != "" {
+			if err := set(s.ptr, v); err != nil {
+				return cfg, fmt.Errorf("%s: %v", name, err)
+			}
+		}
+	}
+
+	for _, s := range cfg.settings() {
+		if v, ok := flagged[s.key]; ok {
+			if err := set(s.ptr, v); err != nil {
+				return cfg, fmt.Errorf("-%s: %v", s.key, err)
+			}
+		}
+	}
+
+	return cfg, cfg.Validate()
+}
+
+func (c *Config) loadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open config: %v", err)
+	}
+	defer f.Close()
+
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && err != io.EOF {
+		return fmt.Errorf("parse config %s: %v", path, err)
+	}
+	return nil
+}
+
+// Validate reports every invalid setting at once.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.ListenAddr != "", "listen_addr must be set")
+	check(c.DataDir != "", "data_dir must be set")
+
+	check(c.Index.Type == "hnsw", "index.type %q is not supported: the server serves only hnsw, diskann is only in nebula-bench", c.Index.Type)
+	check(c.Index.M >= 2, "index.m must be at least 2, got %d", c.Index.M)
+	check(c.Index.M0 == 0 || c.Index.M0 >= c.Index.M, "index.m0 must be 0 or >= index.m, got %d", c.Index.M0)
+	check(c.Index.EfConstruction >= 1, "index.ef_construction must be positive, got %d", c.Index.EfConstruction)
+	check(c.Index.EfSearch >= 1, "index.ef_search must be positive, got %d", c.Index.EfSearch)
+	if _, err := index.ParseMetric(c.Index.Metric); err != nil {
+		errs = append(errs, fmt.Errorf("index.metric: %v", err))
+	}
+	if _, err := index.ParsePrecision(c.Index.Precision); err != nil {
+		errs = append(errs, fmt.Errorf("index.precision: %v", err))
+	}
+	check(c.Index.VectorStorage == "heap" || c.Index.VectorStorage == "mmap",
+		"index.vector_storage must be heap or mmap, got %q", c.Index.VectorStorage)
+
+	policy, err := storage.ParseSyncPolicy(c.Durability.WALSync)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("durability.wal_sync: %v", err))
+	}
+	check(policy != storage.SyncInterval || c.Durability.WALSyncInterval > 0,
+		"durability.wal_sync_interval must be positive")
+
+	check(c.Limits.MaxRecvMsgBytes > 0, "limits.max_recv_msg_bytes must be positive")
+	check(c.Limits.MaxSendMsgBytes > 0, "limits.max_send_msg_bytes must be positive")
+	check(c.Limits.MaxK >= 0, "limits.max_k must not be negative")
+	check(c.Limits.MaxDimension >= 0, "limits.max_dimension must not be negative")
+	check(c.Limits.MaxConcurrentSearches >= 0, "limits.max_concurrent_searches must not be negative")
+	check(c.Limits.MaxConcurrentInserts >= 0, "limits.max_concurrent_inserts must not be negative")
+	check(c.Limits.Rate >= 0, "limits.rate must not be negative")
+	check(c.Limits.Rate == 0 || c.Limits.Burst > 0, "limits.burst must be positive when limits.rate is set")
+
+	check(c.Shutdown.Timeout > 0, "shutdown.timeout must be positive")
+
+	switch c.Tracing.Exporter {
+	case tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP:
+	case tracing.ExporterFile:
+		check(c.Tracing.File != "", "tracing.file must be set for the file exporter")
+	default:
+		check(false, "tracing.exporter %q is not supported", c.Tracing.Exporter)
+	}
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1,
+		"tracing.sample_ratio must be within [0, 1], got %g", c.Tracing.SampleRatio)
+
+	check((c.Security.TLSCert == "") == (c.Security.TLSKey == ""),
+		"security.tls_cert and security.tls_key must be set together")
+	check(c.Security.ClientCA == "" || c.Security.TLSCert != "",
+		"security.client_ca requires security.tls_cert and security.tls_key")
+	check(c.Security.APIKeysFile == "" || c.Security.APIKeysReload > 0,
+		"security.api_keys_reload must be positive")
+
+	return errors.Join(errs...)
+}
+
+// HNSW converts the index section into an index.Config. Call on a
+// validated Config.
+func (c Config) HNSW() index.Config {
+	cfg := index.DefaultConfig()
+	cfg.M = c.Index.M
+	cfg.M0 = c.Index.M0
+	if cfg.M0 == 0 {
+		cfg.M0 = 2 * c.Index.M
+	}
+	cfg.EfConstruction = c.Index.EfConstruction
+	cfg.EfSearch = c.Index.EfSearch
+	cfg.LevelMultiplier = 1.0 / math.Log(float64(c.Index.M))
+	cfg.Metric, _ = index.ParseMetric(c.Index.Metric)
+	cfg.Precision, _ = index.ParsePrecision(c.Index.Precision)
+	cfg.Seed = uint64(c.Index.Seed)
+	if c.Index.VectorStorage == "mmap" {
+		cfg.VectorDir = c.DataDir
+	}
+	return cfg
+}
+
+// WAL returns the WAL path and options. Call on a validated Config.
+func (c Config) WAL() (string, storage.WALOptions) {
+	policy, _ := storage.ParseSyncPolicy(c.Durability.WALSync)
+	return filepath.Join(c.DataDir, "nebula.wal"), storage.WALOptions{
+		Sync:         policy,
+		SyncInterval: c.Durability.WALSyncInterval,
+	}
+}
+
+// TracingOptions converts the tracing section. Call on a validated Config.
+func (c Config) TracingOptions() tracing.Options {
+	file := c.Tracing.File
+	if file != "" && !filepath.IsAbs(file) {
+		file = filepath.Join(c.DataDir, file)
+	}
+	return tracing.Options{
+		Exporter:    c.Tracing.Exporter,
+		File:        file,
+		Endpoint:    c.Tracing.Endpoint,
+		Insecure:    c.Tracing.Insecure,
+		SampleRatio: c.Tracing.SampleRatio,
+	}
+}
+
+// SnapshotPath is where the index snapshot lives.
+func (c Config) SnapshotPath() string {
+	return filepath.Join(c.DataDir, "nebula.snap")
+}
+
+// String renders the effective configuration as YAML, for logging on boot.
+func (c Config) String() string {
+	out, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Sprintf("<unprintable config: %v>", err)
+	}
+	return string(out)
+}
+
+// Usage lists every flag with its env var.
+func Usage() string {
+	var b strings.Builder
+	b.WriteString("  -config string\n    \tpath to a YAML config file (env NEBULA_CONFIG)\n")
+	cfg := Default()
+	for _, s := range cfg.settings() {
+		fmt.Fprintf(&b, "  -%s\n    \t%s (env %s)\n", s.key, s.usage, envName(s.key))
+	}
+	return b.String()
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sandeep89846/nebuladb/internal/index"
+	"github.com/sandeep89846/nebuladb/internal/storage"
+)
+
+func TestLoad_Precedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nebula.yaml")
+	file := `
+listen_addr: ":7000"
+data_dir: /var/lib/nebula
+index:
+  m: 8
+  ef_search: 100
+  precision: float16
+  vector_storage: mmap
+durability:
+  wal_sync: interval
+  wal_sync_interval: 250ms
+`
+	if err := os.WriteFile(path, []byte(file), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	env := map[string]string{
+		"NEBULA_CONFIG":          path,
+		"NEBULA_LISTEN_ADDR":     ":8000",
+		"NEBULA_INDEX_EF_SEARCH": "64",
+	}
+	cfg, err := Load([]string{"-index.ef_search=128"}, func(k string) string { return env[k] })
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if cfg.ListenAddr != ":8000" {
+		t.Errorf("env should override file: listen_addr = %q", cfg.ListenAddr)
+	}
+	if cfg.Index.EfSearch != 128 {
+		t.Errorf("flag should override env: ef_search = %d", cfg.Index.EfSearch)
+	}
+	if cfg.Index.M != 8 || cfg.DataDir != "/var/lib/nebula" {
+		t.Errorf("file values not applied: %+v", cfg)
+	}
+	if cfg.Index.EfConstruction != 200 {
+		t.Errorf("default lost: ef_construction = %d", cfg.Index.EfConstruction)
+	}
+	if cfg.Tracing.Exporter != "none" {
+		t.Errorf("tracing should be off by default, exporter = %q", cfg.Tracing.Exporter)
+	}
+
+	hnsw := cfg.HNSW()
+	if hnsw.M != 8 || hnsw.M0 != 16 || hnsw.Precision != index.PrecisionFloat16 || hnsw.VectorDir != "/var/lib/nebula" {
+		t.Errorf("unexpected index config: %+v", hnsw)
+	}
+
+	walPath, opts := cfg.WAL()
+	if walPath != filepath.Join("/var/lib/nebula", "nebula.wal") {
+		t.Errorf("unexpected WAL path %q", walPath)
+	}
+	if opts.Sync != storage.SyncInterval || opts.SyncInterval != 250*time.Millisecond {
+		t.Errorf("unexpected WAL options: %+v", opts)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	noEnv := func(string) string { return "" }
+
+	_, err := Load([]string{"-index.m=1", "-index.metric=l2", "-durability.wal_sync=sometimes", "-index.vector_storage=disk"}, noEnv)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, want := range []string{"index.m", "index.metric", "durability.wal_sync", "index.vector_storage"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error should mention %s: %v", want, err)
+		}
+	}
+
+	if _, err := Load([]string{"-index.m=abc"}, noEnv); err == nil {
+		t.Error("expected parse error for non-numeric flag")
+	}
+
+	path := filepath.Join(t.TempDir(), "bad.yaml")
+	os.WriteFile(path, []byte("no_such_key: 1\n"), 0644)
+	if _, err := Load([]string{"-config", path}, noEnv); err == nil {
+		t.Error("expected error for unknown config key")
+	}
+}
@@ -0,0 +1,253 @@
+// Package gateway serves VectorService as JSON over HTTP. Requests are
+// decoded with protojson into the generated request types and run through
+// the generated method handlers with the same interceptors as the gRPC
+// server, so auth, limits and metrics apply unchanged.
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/sandeep89846/nebuladb/api/proto/nebulapb"
+)
+
+// route maps an HTTP endpoint to a VectorService method.
+type route struct {
+	method  string // HTTP method
+	path    string // ServeMux pattern; {id} fills the request's id field
+	rpc     string // VectorService method name
+	summary string
+}
+
+var routes = []route{
+	{http.MethodPost, "/v1/vectors", "Insert", "Insert a vector"},
+	{http.MethodPost, "/v1/search", "Search", "Search for the nearest vectors"},
+	{http.MethodGet, "/v1/vectors/{id}", "Get", "Get a stored vector"},
+	{http.MethodDelete, "/v1/vectors/{id}", "Delete", "Delete a vector"},
+	{http.MethodGet, "/v1/stats", "Stats", "Index sizes and WAL counters"},
+	{http.MethodPost, "/v1/snapshot", "Snapshot", "Write a snapshot and empty the WAL"},
+}
+
+// forwardHeaders are copied from the HTTP request into gRPC metadata.
+var forwardHeaders = []string{"authorization", "x-api-key", "traceparent", "tracestate"}
+
+// maxBodyBytes bounds request bodies; the gRPC limit applies to the decoded
+// message as well.
+const maxBodyBytes = 64 << 20
+
+// Responses include zero values, so a score of 0 is not left out.
+var marshal = protojson.MarshalOptions{EmitUnpopulated: true}
+
+type Gateway struct {
+	svc         nebulapb.VectorServiceServer
+	interceptor grpc.UnaryServerInterceptor
+	spec        []byte
+
+	// mu guards the count of method calls in flight, which Drain waits on.
+	mu       sync.Mutex
+	idle     sync.Cond
+	active   int
+	draining bool
+}
+
+// New returns a gateway calling svc through interceptors, outermost first,
+// as grpc.ChainUnaryInterceptor would.
+func New(svc nebulapb.VectorServiceServer, interceptors ...grpc.UnaryServerInterceptor) *Gateway {
+	spec, err := json.MarshalIndent(openAPI(), "", "  ")
+	if err != nil {
+		panic(err) // the document is built from plain maps and slices
+	}
+	g := &Gateway{svc: svc, interceptor: chain(interceptors), spec: spec}
+	g.idle.L = &g.mu
+	return g
+}
+
+// Drain makes the gateway refuse new calls with Unavailable and waits for
+// those in flight to return. http.Server.Close does not wait for handlers,
+// so call Drain after it before closing what the service writes to.
+func (g *Gateway) Drain() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.draining = true
+	for g.active > 0 {
+		g.idle.Wait()
+	}
+}
+
+// begin counts a call in flight, or reports false once Drain was called.
+func (g *Gateway) begin() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.draining {
+		return false
+	}
+	g.active++
+	return true
+}
+
+func (g *Gateway) end() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.active--; g.active == 0 {
+		g.idle.Broadcast()
+	}
+}
+
+func (g *Gateway) Handler() http.Handler {
+	mux := http.NewServeMux()
+	for _, rt := range routes {
+		handler := methodHandler(rt.rpc)
+		mux.HandleFunc(rt.method+" "+rt.path, func(w http.ResponseWriter, r *http.Request) {
+			g.serve(w, r, handler)
+		})
+	}
+	// The server holds a single set of indexes; the paths are reserved so
+	// clients get a clear answer rather than a bare 404.
+	collections := func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, status.Error(codes.Unimplemented, "collections are not supported yet: the server holds a single index"))
+	}
+	mux.HandleFunc("/v1/collections", collections)
+	mux.HandleFunc("/v1/collections/", collections)
+	mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(g.spec)
+	})
+	return mux
+}
+
+func methodHandler(name string) grpc.MethodHandler {
+	for _, m := range nebulapb.VectorService_ServiceDesc.Methods {
+		if m.MethodName == name {
+			return m.Handler
+		}
+	}
+	panic("gateway: no VectorService method " + name)
+}
+
+func (g *Gateway) serve(w http.ResponseWriter, r *http.Request, handler grpc.MethodHandler) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		writeError(w, status.Errorf(codes.InvalidArgument, "read body: %v", err))
+		return
+	}
+
+	dec := func(m any) error {
+		msg := m.(proto.Message)
+		if len(body) > 0 {
+			if err := protojson.Unmarshal(body, msg); err != nil {
+				return status.Errorf(codes.InvalidArgument, "invalid JSON body: %v", err)
+			}
+		}
+		if id := r.PathValue("id"); id != "" {
+			fd := msg.ProtoReflect().Descriptor().Fields().ByName("id")
+			msg.ProtoReflect().Set(fd, protoreflect.ValueOfString(id))
+		}
+		return nil
+	}
+
+	if !g.begin() {
+		writeError(w, status.Error(codes.Unavailable, "server is shutting down"))
+		return
+	}
+	resp, err := handler(g.svc, incomingContext(r), dec, g.interceptor)
+	g.end()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	out, err := marshal.Marshal(resp.(proto.Message))
+	if err != nil {
+		writeError(w, status.Errorf(codes.Internal, "encode response: %v", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(out)
+}
+
+// incomingContext makes r look like a gRPC call to the interceptors: the
+// forwarded headers become metadata and the client address the peer.
+func incomingContext(r *http.Request) context.Context {
+	md := metadata.MD{}
+	for _, h := range forwardHeaders {
+		if v := r.Header.Values(h); len(v) > 0 {
+			md[h] = v
+		}
+	}
+	ctx := metadata.NewIncomingContext(r.Context(), md)
+	return peer.NewContext(ctx, &peer.Peer{Addr: remoteAddr(r.RemoteAddr)})
+}
+
+type remoteAddr string
+
+func (a remoteAddr) Network() string { return "tcp" }
+func (a remoteAddr) String() string  { return string(a) }
+
+// chain composes interceptors into one, outermost first.
+func chain(interceptors []grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	if len(interceptors) == 0 {
+		return nil
+	}
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		next := handler
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			ic, inner := interceptors[i], next
+			next = func(ctx context.Context, req any) (any, error) {
+				return ic(ctx, req, info, inner)
+			}
+		}
+		return next(ctx, req)
+	}
+}
+
+// writeError sends err as a google.rpc.Status-shaped JSON body with the
+// HTTP status matching its gRPC code.
+func writeError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus(st.Code()))
+	json.NewEncoder(w).Encode(map[string]any{
+		"code":    int(st.Code()),
+		"message": st.Message(),
+	})
+}
+
+// httpStatus follows the mapping used by grpc-gateway.
+func httpStatus(c codes.Code) int {
+	switch c {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499 // client closed request
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
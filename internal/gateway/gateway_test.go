@@ -0,0 +1,204 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/sandeep89846/nebuladb/internal/auth"
+	"github.com/sandeep89846/nebuladb/internal/index"
+	"github.com/sandeep89846/nebuladb/internal/server"
+	"github.com/sandeep89846/nebuladb/internal/storage"
+)
+
+func newTestGateway(t *testing.T) *httptest.Server {
+	t.Helper()
+	dir := t.TempDir()
+	wal, err := storage.OpenWAL(filepath.Join(dir, "nebula.wal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { wal.Close() })
+
+	keyFile := filepath.Join(dir, "keys.yaml")
+	os.WriteFile(keyFile, []byte("keys: [{name: rw, key: secret, roles: [read, write]}]"), 0600)
+	keys, err := auth.LoadKeyStore(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := server.NewServer(index.NewHNSW(index.DefaultConfig()), index.NewSparseIndex(), index.NewTextIndex(), wal)
+	gw := New(srv, auth.NewAuthenticator(keys, server.MethodRoles).UnaryInterceptor(), srv.UnaryInterceptor())
+	ts := httptest.NewServer(gw.Handler())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func do(t *testing.T, ts *httptest.Server, method, path, body string) (int, map[string]any) {
+	t.Helper()
+	req, _ := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(resp.Body)
+	var out map[string]any
+	if err := json.Unmarshal(raw, &out); err != nil {
+		t.Fatalf("%s %s: bad JSON %q", method, path, raw)
+	}
+	return resp.StatusCode, out
+}
+
+func TestGateway_CRUD(t *testing.T) {
+	ts := newTestGateway(t)
+
+	for _, body := range []string{
+		`{"id": "a", "vector": [1, 0, 0]}`,
+		`{"id": "b", "vector": [0, 1, 0]}`,
+	} {
+		if code, out := do(t, ts, "POST", "/v1/vectors", body); code != 200 || out["success"] != true {
+			t.Fatalf("insert %s: %d %v", body, code, out)
+		}
+	}
+
+	code, out := do(t, ts, "POST", "/v1/search", `{"vector": [1, 0.1, 0], "k": 1}`)
+	matches, _ := out["matches"].([]any)
+	if code != 200 || len(matches) != 1 || matches[0].(map[string]any)["id"] != "a" {
+		t.Fatalf("search: %d %v", code, out)
+	}
+
+	code, out = do(t, ts, "GET", "/v1/vectors/b", "")
+	if v, _ := out["vector"].([]any); code != 200 || len(v) != 3 || v[1] != 1.0 {
+		t.Errorf("get: %d %v", code, out)
+	}
+	if code, out := do(t, ts, "POST", "/v1/vectors", `{"id": "c", "vector": [3, 4, 0]}`); code != 200 || out["success"] != true {
+		t.Fatalf("insert c: %d %v", code, out)
+	}
+	code, out = do(t, ts, "GET", "/v1/vectors/c", "")
+	if v, _ := out["vector"].([]any); code != 200 || len(v) != 3 || v[0] != 3.0 || v[1] != 4.0 {
+		t.Errorf("get should return the inserted magnitude: %d %v", code, out)
+	}
+	if code, _ := do(t, ts, "GET", "/v1/collections", ""); code != http.StatusNotImplemented {
+		t.Errorf("collections: %d, want 501", code)
+	}
+
+	if code, out = do(t, ts, "DELETE", "/v1/vectors/b", ""); code != 200 {
+		t.Errorf("delete: %d %v", code, out)
+	}
+	if code, out = do(t, ts, "GET", "/v1/vectors/b", ""); code != 404 || out["code"] != 5.0 {
+		t.Errorf("get after delete: %d %v", code, out)
+	}
+	if code, _ = do(t, ts, "DELETE", "/v1/vectors/b", ""); code != 404 {
+		t.Errorf("second delete: %d, want 404", code)
+	}
+
+	if code, _ = do(t, ts, "POST", "/v1/search", `{"vector": [1, 0`); code != 400 {
+		t.Errorf("bad JSON: %d, want 400", code)
+	}
+}
+
+func TestGateway_Auth(t *testing.T) {
+	ts := newTestGateway(t)
+	resp, err := http.Post(ts.URL+"/v1/search", "application/json", strings.NewReader(`{"vector": [1], "k": 1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("no key: %d, want 401", resp.StatusCode)
+	}
+}
+
+func TestGateway_OpenAPI(t *testing.T) {
+	ts := newTestGateway(t)
+	resp, err := http.Get(ts.URL + "/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var spec struct {
+		OpenAPI    string                    `json:"openapi"`
+		Paths      map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]map[string]any `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&spec); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, rt := range routes {
+		if _, ok := spec.Paths[rt.path][strings.ToLower(rt.method)]; !ok {
+			t.Errorf("spec is missing %s %s", rt.method, rt.path)
+		}
+	}
+	insert := spec.Components.Schemas["InsertRequest"].Properties
+	if insert["packedVector"]["format"] != "byte" || insert["sparse"]["$ref"] != "#/components/schemas/SparseVector" {
+		t.Errorf("InsertRequest schema: %v", insert)
+	}
+	if _, ok := spec.Components.Schemas["SearchResponse.Match"]; !ok {
+		t.Error("nested SearchResponse.Match schema missing")
+	}
+}
+
+// Drain waits for a call in flight and turns new ones away.
+func TestGateway_Drain(t *testing.T) {
+	wal, err := storage.OpenWAL(filepath.Join(t.TempDir(), "nebula.wal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wal.Close()
+	idx := index.NewHNSW(index.DefaultConfig())
+	srv := server.NewServer(idx, index.NewSparseIndex(), index.NewTextIndex(), wal)
+
+	entered, release := make(chan struct{}), make(chan struct{})
+	block := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		close(entered)
+		<-release
+		return handler(ctx, req)
+	}
+	gw := New(srv, block)
+	ts := httptest.NewServer(gw.Handler())
+	defer ts.Close()
+
+	go http.Post(ts.URL+"/v1/vectors", "application/json", strings.NewReader(`{"id": "a", "vector": [1, 0]}`))
+	<-entered
+	drained := make(chan struct{})
+	go func() {
+		gw.Drain()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		t.Fatal("Drain returned with a call in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	<-drained
+	if !idx.Has("a") {
+		t.Error("the call in flight did not finish")
+	}
+
+	resp, err := http.Post(ts.URL+"/v1/vectors", "application/json", strings.NewReader(`{"id": "b", "vector": [1, 0]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("call after Drain: status %d, want 503", resp.StatusCode)
+	}
+}
@@ -0,0 +1,155 @@
+package gateway
+
+import (
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/sandeep89846/nebuladb/api/proto/nebulapb"
+)
+
+// openAPI describes the routes as an OpenAPI 3 document. Schemas come from
+// the compiled proto descriptors, so the spec follows the proto without a
+// separate generation step. Field names and types are those of protojson.
+func openAPI() map[string]any {
+	svc := nebulapb.File_api_proto_nebulapb_vector_service_proto.Services().ByName("VectorService")
+	schemas := map[string]any{
+		"Status": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"code":    map[string]any{"type": "integer", "format": "int32"},
+				"message": map[string]any{"type": "string"},
+			},
+		},
+	}
+
+	paths := map[string]any{}
+	for _, rt := range routes {
+		m := svc.Methods().ByName(protoreflect.Name(rt.rpc))
+		op := map[string]any{
+			"operationId": rt.rpc,
+			"summary":     rt.summary,
+			"responses": map[string]any{
+				"200": jsonContent("OK", schemaRef(m.Output(), schemas)),
+				"default": jsonContent("Error, with the gRPC status code",
+					map[string]any{"$ref": "#/components/schemas/Status"}),
+			},
+		}
+		if strings.Contains(rt.path, "{id}") {
+			op["parameters"] = []any{map[string]any{
+				"name": "id", "in": "path", "required": true,
+				"schema": map[string]any{"type": "string"},
+			}}
+		}
+		if rt.method == "POST" {
+			op["requestBody"] = map[string]any{
+				"required": true,
+				"content": map[string]any{
+					"application/json": map[string]any{"schema": schemaRef(m.Input(), schemas)},
+				},
+			}
+		}
+
+		item, _ := paths[rt.path].(map[string]any)
+		if item == nil {
+			item = map[string]any{}
+			paths[rt.path] = item
+		}
+		item[strings.ToLower(rt.method)] = op
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "NebulaDB",
+			"version": "v1",
+			"description": "JSON mirror of the " + string(svc.FullName()) +
+				" gRPC service. Send an API key as `x-api-key` or `Authorization: Bearer` when the server requires one.",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas,
+			"securitySchemes": map[string]any{
+				"apiKey": map[string]any{"type": "apiKey", "in": "header", "name": "x-api-key"},
+				"bearer": map[string]any{"type": "http", "scheme": "bearer"},
+			},
+		},
+		// Keys are optional unless the server loads a key file.
+		"security": []any{map[string]any{"apiKey": []any{}}, map[string]any{"bearer": []any{}}, map[string]any{}},
+	}
+}
+
+func jsonContent(desc string, schema any) map[string]any {
+	return map[string]any{
+		"description": desc,
+		"content":     map[string]any{"application/json": map[string]any{"schema": schema}},
+	}
+}
+
+// schemaRef adds md and the messages it uses to schemas and returns a
+// reference to it.
+func schemaRef(md protoreflect.MessageDescriptor, schemas map[string]any) map[string]any {
+	name := schemaName(md)
+	ref := map[string]any{"$ref": "#/components/schemas/" + name}
+	if _, ok := schemas[name]; ok {
+		return ref
+	}
+
+	props := map[string]any{}
+	schema := map[string]any{"type": "object", "properties": props}
+	schemas[name] = schema // before recursing, for self-references
+
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		var s map[string]any
+		switch {
+		case fd.IsMap():
+			s = map[string]any{"type": "object", "additionalProperties": fieldSchema(fd.MapValue(), schemas)}
+		case fd.IsList():
+			s = map[string]any{"type": "array", "items": fieldSchema(fd, schemas)}
+		default:
+			s = fieldSchema(fd, schemas)
+		}
+		props[fd.JSONName()] = s
+	}
+	return ref
+}
+
+// fieldSchema is the schema of one value of fd, ignoring cardinality.
+func fieldSchema(fd protoreflect.FieldDescriptor, schemas map[string]any) map[string]any {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return map[string]any{"type": "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return map[string]any{"type": "integer", "format": "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return map[string]any{"type": "integer", "format": "int64", "minimum": 0}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return map[string]any{"type": "string", "format": "int64"} // protojson quotes 64-bit ints
+	case protoreflect.FloatKind:
+		return map[string]any{"type": "number", "format": "float"}
+	case protoreflect.DoubleKind:
+		return map[string]any{"type": "number", "format": "double"}
+	case protoreflect.StringKind:
+		return map[string]any{"type": "string"}
+	case protoreflect.BytesKind:
+		return map[string]any{"type": "string", "format": "byte"}
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		names := make([]any, values.Len())
+		for i := range names {
+			names[i] = string(values.Get(i).Name())
+		}
+		return map[string]any{"type": "string", "enum": names}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return schemaRef(fd.Message(), schemas)
+	}
+	return map[string]any{}
+}
+
+// schemaName drops the package, keeping nesting: "SearchResponse.Match".
+func schemaName(md protoreflect.MessageDescriptor) string {
+	return strings.TrimPrefix(string(md.FullName()), string(md.ParentFile().Package())+".")
+}
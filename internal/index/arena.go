@@ -0,0 +1,246 @@
+package index
+
+import (
+	"context"
+	"sync"
+	"unsafe"
+
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+// arenaChunkRows is the number of vectors per arena chunk.
+const arenaChunkRows = 1024
+
+// point is a vector in whichever representation the index stores: dense
+// float32 for cosine, packed bits for Hamming/Jaccard. Exactly one field is set.
+type point struct {
+	dense vec.Vector
+	bits  vec.BitVector
+	// bitLen is the length of bits in bits; the words may have room for
+	// more, which are zero.
+	bitLen int
+}
+
+// dim is the number of stored elements: floats for dense, words for bits.
+func (p point) dim() int {
+	if p.bits != nil {
+		return len(p.bits)
+	}
+	return len(p.dense)
+}
+
+// vectorStore holds the vectors of an index, addressed by row.
+// Implementations differ in element type and storage precision; dense
+// stores score against a float32 query and accumulate in float32.
+type vectorStore interface {
+	dimension() int
+	set(row int, p point) error
+	// get decodes row into a new point, or returns the zero point if the
+	// row was never allocated.
+	get(row int) point
+	// distRows writes the distance from p to each of rows into out.
+	distRows(p point, rows []int, out []float32)
+	// prefetch reads a file-backed store into the page cache.
+	prefetch(ctx context.Context) error
+	// close releases a file-backed store; the store is unusable after.
+	close() error
+}
+
+// newVectorStore returns the store for cfg's metric and precision: chunks
+// on the Go heap, or in a memory-mapped file when cfg.VectorDir is set.
+// Binary stores hold dim words per vector, of which bitLen bits are used.
+func newVectorStore(cfg Config, dim, bitLen int) (vectorStore, error) {
+	var f *mmapFile
+	if cfg.VectorDir != "" {
+		var err error
+		if f, err = openMmapFile(cfg.VectorDir); err != nil {
+			return nil, err
+		}
+	}
+
+	switch cfg.Metric {
+	case MetricHamming, MetricJaccard:
+		gather := func(q vec.BitVector, rows []vec.BitVector, out []float32) error {
+			return vec.HammingGather(q, bitLen, rows, out)
+		}
+		if cfg.Metric == MetricJaccard {
+			gather = vec.JaccardGather
+		}
+		return newArena(dim, f, func(dst vec.BitVector, p point) {
+			copy(dst, p.bits)
+		}, func(r vec.BitVector) point {
+			return point{bits: append(vec.BitVector(nil), r...), bitLen: bitLen}
+		}, func(p point, rows []vec.BitVector, out []float32) error {
+			return gather(p.bits, rows, out)
+		}), nil
+	}
+
+	switch cfg.Precision {
+	case PrecisionFloat16:
+		return newArena(dim, f, func(dst vec.Float16Vector, p point) {
+			for i, f := range p.dense {
+				dst[i] = vec.ToFloat16(f)
+			}
+		}, func(r vec.Float16Vector) point {
+			return point{dense: r.Float32()}
+		}, cosineGather(vec.DotGatherFloat16)), nil
+	case PrecisionBFloat16:
+		return newArena(dim, f, func(dst vec.BFloat16Vector, p point) {
+			for i, f := range p.dense {
+				dst[i] = vec.ToBFloat16(f)
+			}
+		}, func(r vec.BFloat16Vector) point {
+			return point{dense: r.Float32()}
+		}, cosineGather(vec.DotGatherBFloat16)), nil
+	default:
+		return newArena(dim, f, func(dst vec.Vector, p point) {
+			copy(dst, p.dense)
+		}, func(r vec.Vector) point {
+			return point{dense: append(vec.Vector(nil), r...)}
+		}, cosineGather(vec.DotGather)), nil
+	}
+}
+
+// cosineGather turns a batch dot kernel over normalized rows into cosine
+// distances.
+func cosineGather[R any](dot func(vec.Vector, []R, []float32) error) func(point, []R, []float32) error {
+	return func(p point, rows []R, out []float32) error {
+		if err := dot(p.dense, rows, out); err != nil {
+			return err
+		}
+		for i := range rows {
+			out[i] = 1.0 - out[i]
+		}
+		return nil
+	}
+}
+
+// arena stores fixed-dimension vectors in contiguous chunks of R, so there
+// is no per-vector allocation. Chunks never move once allocated, which lets
+// distRows run the kernel after releasing the lock.
+//
+// Chunks come from the Go heap, or from file when it is set: each chunk is
+// then a mapped region of the file holding chunkRows fixed-size records, and
+// the kernel pages rows in as it touches them. Reads then hold the file's
+// users lock, so close waits for them before it unmaps the chunks.
+type arena[R ~[]E, E any] struct {
+	mu        sync.RWMutex
+	dim       int
+	chunkRows int
+	chunks    []R
+	file      *mmapFile
+
+	encode func(dst R, p point)
+	decode func(r R) point
+	gather func(p point, rows []R, out []float32) error
+
+	rowPool sync.Pool // *[]R scratch for distRows
+}
+
+func newArena[R ~[]E, E any](dim int, file *mmapFile, encode func(R, point), decode func(R) point,
+	gather func(point, []R, []float32) error) *arena[R, E] {
+	a := &arena[R, E]{
+		dim:       dim,
+		chunkRows: arenaChunkRows,
+		file:      file,
+		encode:    encode,
+		decode:    decode,
+		gather:    gather,
+	}
+	if file != nil {
+		a.chunkRows = max(1, mmapChunkBytes/(dim*elemSize[E]()))
+	}
+	a.rowPool.New = func() any { s := make([]R, 0, 64); return &s }
+	return a
+}
+
+func (a *arena[R, E]) dimension() int { return a.dim }
+
+// set encodes p into the given row, growing the arena as needed. It fails
+// only when a file-backed arena cannot grow its file.
+func (a *arena[R, E]) set(row int, p point) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	c := row / a.chunkRows
+	for len(a.chunks) <= c {
+		if a.file == nil {
+			a.chunks = append(a.chunks, make(R, a.dim*a.chunkRows))
+			continue
+		}
+		chunk, err := mapChunk[R](a.file, a.dim*a.chunkRows)
+		if err != nil {
+			return err
+		}
+		a.chunks = append(a.chunks, chunk)
+	}
+	a.encode(a.rowLocked(row), p)
+	return nil
+}
+
+func (a *arena[R, E]) rowLocked(row int) R {
+	c := row / a.chunkRows
+	if row < 0 || c >= len(a.chunks) {
+		return nil
+	}
+	off := (row % a.chunkRows) * a.dim
+	return a.chunks[c][off : off+a.dim : off+a.dim]
+}
+
+func (a *arena[R, E]) get(row int) point {
+	if a.file != nil {
+		a.file.users.RLock()
+		defer a.file.users.RUnlock()
+	}
+	a.mu.RLock()
+	r := a.rowLocked(row)
+	a.mu.RUnlock()
+	if r == nil {
+		return point{}
+	}
+	return a.decode(r)
+}
+
+func (a *arena[R, E]) distRows(p point, rows []int, out []float32) {
+	if a.file != nil {
+		a.file.users.RLock()
+		defer a.file.users.RUnlock()
+	}
+	buf := a.rowPool.Get().(*[]R)
+	g := (*buf)[:0]
+
+	a.mu.RLock()
+	for _, row := range rows {
+		g = append(g, a.rowLocked(row))
+	}
+	a.mu.RUnlock()
+
+	// Callers check the query dimension up front, so this cannot fail.
+	_ = a.gather(p, g, out)
+
+	clear(g)
+	*buf = g[:0]
+	a.rowPool.Put(buf)
+}
+
+func (a *arena[R, E]) prefetch(ctx context.Context) error {
+	if a.file == nil {
+		return nil
+	}
+	return a.file.prefetch(ctx)
+}
+
+func (a *arena[R, E]) close() error {
+	if a.file == nil {
+		return nil
+	}
+	a.mu.Lock()
+	a.chunks = nil
+	a.mu.Unlock()
+	return a.file.close()
+}
+
+// elemSize is the size in bytes of one E.
+func elemSize[E any]() int {
+	var e E
+	return int(unsafe.Sizeof(e))
+}
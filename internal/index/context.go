@@ -0,0 +1,66 @@
+package index
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+// ContextIndex is a VectorIndex whose operations give up when ctx is done.
+type ContextIndex interface {
+	VectorIndex
+	InsertContext(ctx context.Context, id string, v vec.Vector) error
+	SearchContext(ctx context.Context, query vec.Vector, k int) ([]Match, error)
+}
+
+var _ ContextIndex = (*HNSW)(nil)
+
+// ErrPartial is returned along with the best matches found so far when a
+// best-effort search runs out of time.
+var ErrPartial = errors.New("search deadline reached, results are partial")
+
+type bestEffortKey struct{}
+
+// WithBestEffort marks ctx so that searches reaching its deadline return
+// what they have found, with ErrPartial, instead of failing. Cancellation
+// still fails the search.
+func WithBestEffort(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bestEffortKey{}, true)
+}
+
+type efKey struct{}
+
+// WithEf sets the layer-0 search width for HNSW searches under ctx,
+// overriding Config.EfSearch. It is still raised to k when smaller.
+func WithEf(ctx context.Context, ef int) context.Context {
+	return context.WithValue(ctx, efKey{}, ef)
+}
+
+// efFrom returns the search width set by WithEf, or def.
+func efFrom(ctx context.Context, def int) int {
+	if ef, ok := ctx.Value(efKey{}).(int); ok && ef > 0 {
+		return ef
+	}
+	return def
+}
+
+// partial reports whether err ends a search that should still return its
+// results.
+func partial(ctx context.Context, err error) bool {
+	best, _ := ctx.Value(bestEffortKey{}).(bool)
+	return best && errors.Is(err, context.DeadlineExceeded)
+}
+
+// checkEvery is how many node expansions run between cancellation checks;
+// polling ctx on every one would show up in profiles.
+const checkEvery = 16
+
+func stopped(done <-chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
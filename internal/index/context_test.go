@@ -0,0 +1,78 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// cancelOnSecondCheck passes the up-front ctx.Err() check in insert and
+// is cancelled from then on, so the insert stops after publishing its node.
+type cancelOnSecondCheck struct {
+	context.Context
+	checks atomic.Int32
+}
+
+func (c *cancelOnSecondCheck) Done() <-chan struct{} {
+	done := make(chan struct{})
+	close(done)
+	return done
+}
+
+func (c *cancelOnSecondCheck) Err() error {
+	if c.checks.Add(1) == 1 {
+		return nil
+	}
+	return context.Canceled
+}
+
+func TestHNSW_Context(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.M = 8
+	cfg.EfConstruction = 50
+	cfg.EfSearch = 200
+	idx := NewHNSW(cfg)
+	for i := 0; i < 500; i++ {
+		if err := idx.Insert(fmt.Sprintf("v%d", i), randomVec(16)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	q := randomVec(16)
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := idx.SearchContext(cancelled, q, 10); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled search: err = %v", err)
+	}
+	if _, err := idx.SearchContext(WithBestEffort(cancelled), q, 10); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled best-effort search: err = %v, want context.Canceled", err)
+	}
+
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	if _, err := idx.SearchContext(expired, q, 10); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expired search: err = %v", err)
+	}
+	matches, err := idx.SearchContext(WithBestEffort(expired), q, 10)
+	if !errors.Is(err, ErrPartial) || len(matches) == 0 {
+		t.Errorf("expired best-effort search: %d matches, err = %v", len(matches), err)
+	}
+
+	before := idx.Stats().Nodes
+	if err := idx.InsertContext(&cancelOnSecondCheck{Context: context.Background()}, "new", q); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled insert: err = %v", err)
+	}
+	if got := idx.Stats().Nodes; got != before {
+		t.Errorf("cancelled insert left %d nodes, want %d", got, before)
+	}
+	if err := idx.Insert("new", q); err != nil {
+		t.Fatalf("insert after cancelled insert: %v", err)
+	}
+	matches, err = idx.Search(q, 1)
+	if err != nil || len(matches) == 0 || matches[0].ID != "new" {
+		t.Errorf("Search after insert = %v, %v", matches, err)
+	}
+}
@@ -0,0 +1,644 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/sandeep89846/nebuladb/internal/storage"
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+// DiskANN is a Vamana graph index that keeps full vectors on disk, for
+// collections whose vectors and graph do not fit in memory. Memory holds
+// only a product-quantized code per vector (Config.PQBytes bytes), the ids
+// and the codebooks.
+//
+// Searches walk the graph on the codes: each round reads the records of
+// the BeamWidth closest unexpanded nodes in one batch, scores them exactly
+// from the full vectors in the records, and queues their neighbors by code
+// distance. Results are ranked by the exact scores.
+//
+// An index lives in a directory of two files. The graph file holds one
+// fixed-size record per node, a vector followed by its adjacency list,
+// packed into 4 KiB sectors so no record straddles a sector boundary
+// unless it is larger than one. The meta file holds the configuration, the
+// codebooks, the codes and the ids, and is rewritten by Flush and Close.
+//
+// Indexes are built by BuildDiskANN, or in shards from a stream by
+// BuildDiskANNFrom, and opened by OpenDiskANN. The server does not serve
+// them yet; nebula-bench builds and measures them.
+// Insert adds to an open index in place: the new record is appended to the
+// graph file and its neighbors' records are rewritten. Inserts reach the
+// graph file at once but the meta file only on Flush or Close, so after a
+// crash the graph can hold nodes the meta file does not list and edges to
+// them. The first insert after a Flush therefore leaves a pending marker
+// file before it writes, and OpenDiskANN, finding one, strips those edges
+// from the listed nodes. Only cosine is supported.
+type DiskANN struct {
+	cfg    DiskANNConfig
+	dir    string
+	layout diskLayout
+	pq     *productQuantizer
+	medoid uint32
+
+	// mu is held for writing by Insert, which rewrites records in place,
+	// and for reading by searches.
+	mu    sync.RWMutex
+	graph *os.File
+	size  int64    // graph file size, a whole number of sectors
+	codes []byte   // pq.subspaces() bytes per node
+	ids   []string // node -> external id
+	rows  map[string]uint32
+	dirty bool // ids or codes changed since the meta file was written
+	// pending is set while the pending marker file exists: the graph file
+	// may have changed since the meta file was written.
+	pending bool
+}
+
+var _ ContextIndex = (*DiskANN)(nil)
+
+// DiskANNConfig holds the build and search parameters of a DiskANN index.
+// They are stored with the index.
+type DiskANNConfig struct {
+	R         int     // Max neighbors per node
+	L         int     // Candidate list size while building and inserting
+	Alpha     float32 // Prune slack; above 1 keeps the long edges searches need
+	PQBytes   int     // Bytes per in-memory code; 0 means a quarter of the dimension
+	SearchL   int     // Default candidate list size for searches (tunable with WithEf)
+	BeamWidth int     // Node records read from disk per search round
+	Seed      uint64  // Seeds PQ training and the build order
+}
+
+func DefaultDiskANNConfig() DiskANNConfig {
+	return DiskANNConfig{
+		R:         64,
+		L:         100,
+		Alpha:     1.2,
+		SearchL:   100,
+		BeamWidth: 4,
+	}
+}
+
+func (c DiskANNConfig) validate() error {
+	switch {
+	case c.R < 2 || c.R > 1<<16:
+		return fmt.Errorf("diskann: R must be within [2, 65536], got %d", c.R)
+	case c.L < 1 || c.SearchL < 1 || c.BeamWidth < 1:
+		return fmt.Errorf("diskann: L, SearchL and BeamWidth must be positive")
+	case c.Alpha < 1:
+		return fmt.Errorf("diskann: Alpha must be at least 1, got %g", c.Alpha)
+	case c.PQBytes < 0:
+		return fmt.Errorf("diskann: PQBytes must not be negative")
+	}
+	return nil
+}
+
+const (
+	diskANNGraphFile   = "vamana.graph"
+	diskANNMetaFile    = "vamana.meta"
+	diskANNPendingFile = "vamana.pending"
+	diskANNVersion     = 1
+
+	// pqTrainSample caps the vectors the codebooks are trained on.
+	pqTrainSample = 25000
+)
+
+func errBadDiskANN(format string, args ...any) error {
+	return fmt.Errorf("diskann: "+format, args...)
+}
+
+// normalize returns v scaled to unit length, checking it against dim.
+func normalize(v vec.Vector, dim int) (vec.Vector, error) {
+	if len(v) != dim {
+		return nil, fmt.Errorf("vector dimension %d does not match index dimension %d", len(v), dim)
+	}
+	mag := vec.Magnitude(v)
+	if mag == 0 {
+		return nil, fmt.Errorf("zero-magnitude vector")
+	}
+	out := make(vec.Vector, len(v))
+	for i := range v {
+		out[i] = v[i] / mag
+	}
+	return out, nil
+}
+
+// writeMeta replaces the meta file with the current ids and codes.
+func (d *DiskANN) writeMeta() error {
+	err := storage.WriteSnapshot(filepath.Join(d.dir, diskANNMetaFile), func(w io.Writer) error {
+		s := newSnapWriter(w)
+		s.str("diskann")
+		s.u32(diskANNVersion)
+		s.u32(uint32(d.cfg.R))
+		s.u32(uint32(d.cfg.L))
+		s.f32(d.cfg.Alpha)
+		s.u32(uint32(d.cfg.PQBytes))
+		s.u32(uint32(d.cfg.SearchL))
+		s.u32(uint32(d.cfg.BeamWidth))
+		s.u64(d.cfg.Seed)
+		s.u32(d.medoid)
+		d.pq.save(s)
+		s.u32(uint32(len(d.ids)))
+		s.write(d.codes)
+		for _, id := range d.ids {
+			s.str(id)
+		}
+		return s.flush()
+	})
+	if err == nil {
+		d.dirty = false
+	}
+	return err
+}
+
+// OpenDiskANN opens the index BuildDiskANN wrote to dir.
+func OpenDiskANN(dir string) (*DiskANN, error) {
+	d := &DiskANN{dir: dir}
+	err := storage.ReadSnapshot(filepath.Join(dir, diskANNMetaFile), func(r io.Reader) error {
+		s := newSnapReader(r)
+		if tag := s.str(); s.err == nil && tag != "diskann" {
+			return errBadDiskANN("not a diskann meta file")
+		}
+		if v := s.u32(); s.err == nil && (v == 0 || v > diskANNVersion) {
+			return errBadDiskANN("unsupported version %d", v)
+		}
+		d.cfg.R = s.count(1 << 16)
+		d.cfg.L = s.count(maxSnapshotLen)
+		d.cfg.Alpha = s.f32()
+		d.cfg.PQBytes = s.count(maxSnapshotLen)
+		d.cfg.SearchL = s.count(maxSnapshotLen)
+		d.cfg.BeamWidth = s.count(maxSnapshotLen)
+		d.cfg.Seed = s.u64()
+		d.medoid = s.u32()
+		d.pq = loadPQ(s)
+		n := s.count(maxSnapshotLen)
+		if s.err != nil {
+			return s.err
+		}
+		if err := d.cfg.validate(); err != nil {
+			return err
+		}
+		if n == 0 || int(d.medoid) >= n {
+			return errBadDiskANN("medoid %d of %d nodes", d.medoid, n)
+		}
+		d.codes = make([]byte, n*d.pq.subspaces())
+		if _, err := io.ReadFull(r, d.codes); err != nil {
+			return io.ErrUnexpectedEOF
+		}
+		d.ids = make([]string, n)
+		d.rows = make(map[string]uint32, n)
+		for i := range d.ids {
+			d.ids[i] = s.str()
+			d.rows[d.ids[i]] = uint32(i)
+		}
+		return s.err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	d.layout = newDiskLayout(d.pq.dim, d.cfg.R)
+	f, err := os.OpenFile(filepath.Join(dir, diskANNGraphFile), os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, diskSector)
+	if _, err := io.ReadFull(f, header); err != nil {
+		f.Close()
+		return nil, errBadDiskANN("graph header: %v", err)
+	}
+	if !slices.Equal(header, d.layout.header()) {
+		f.Close()
+		return nil, errBadDiskANN("graph file does not match the meta file")
+	}
+	if d.size, err = f.Seek(0, io.SeekEnd); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, end := d.layout.span(uint32(len(d.ids) - 1)); end > d.size {
+		f.Close()
+		return nil, errBadDiskANN("graph file holds fewer than %d nodes", len(d.ids))
+	}
+	d.graph = f
+	if _, err := os.Stat(filepath.Join(dir, diskANNPendingFile)); err == nil {
+		d.pending = true
+		if err := d.dropUnlisted(); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// dropUnlisted removes the edges to nodes past the ones the meta file
+// lists, which inserts a crash kept from it left behind, cuts the graph
+// file after the listed nodes and clears the pending marker.
+func (d *DiskANN) dropUnlisted() error {
+	n := uint32(len(d.ids))
+	err := d.scan(n, func(id uint32, node diskNode) error {
+		deg := len(node.nbrs)
+		node.nbrs = slices.DeleteFunc(node.nbrs, func(nb uint32) bool { return nb >= n })
+		if len(node.nbrs) == deg {
+			return nil
+		}
+		return d.writeNode(id, node.vector, node.nbrs)
+	})
+	if err != nil {
+		return err
+	}
+	if _, end := d.layout.span(n - 1); end < d.size {
+		if err := d.graph.Truncate(end); err != nil {
+			return err
+		}
+		d.size = end
+	}
+	if err := d.graph.Sync(); err != nil {
+		return err
+	}
+	return d.clearPending()
+}
+
+// markPending creates the pending marker, durably, unless it exists.
+// Inserts call it before their first write to the graph file.
+func (d *DiskANN) markPending() error {
+	if d.pending {
+		return nil
+	}
+	err := storage.WriteSnapshot(filepath.Join(d.dir, diskANNPendingFile), func(io.Writer) error { return nil })
+	if err == nil {
+		d.pending = true
+	}
+	return err
+}
+
+// clearPending removes the pending marker once the meta file matches the
+// graph file. A removal lost in a crash costs only a needless
+// dropUnlisted on the next open.
+func (d *DiskANN) clearPending() error {
+	if !d.pending {
+		return nil
+	}
+	if err := os.Remove(filepath.Join(d.dir, diskANNPendingFile)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	d.pending = false
+	return nil
+}
+
+// Flush syncs the graph file and writes the meta file if inserts changed
+// it, making every insert so far survive a restart.
+func (d *DiskANN) Flush() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.flushLocked()
+}
+
+// flushLocked leaves the pending marker in place when no insert finished
+// since the last flush, as one that failed part way may have written edges
+// to a node the meta file will never list.
+func (d *DiskANN) flushLocked() error {
+	if !d.dirty {
+		return nil
+	}
+	if err := d.graph.Sync(); err != nil {
+		return err
+	}
+	if err := d.writeMeta(); err != nil {
+		return err
+	}
+	return d.clearPending()
+}
+
+// Close flushes the index and closes the graph file. Nothing may use the
+// index after.
+func (d *DiskANN) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	err := d.flushLocked()
+	if cerr := d.graph.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Len returns the number of vectors.
+func (d *DiskANN) Len() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return len(d.ids)
+}
+
+// Config returns the parameters the index was built with.
+func (d *DiskANN) Config() DiskANNConfig { return d.cfg }
+
+// Search implements the VectorIndex interface.
+func (d *DiskANN) Search(query vec.Vector, k int) ([]Match, error) {
+	return d.SearchContext(context.Background(), query, k)
+}
+
+// SearchContext returns the k vectors most similar to query by cosine.
+// The candidate list holds max(k, SearchL) nodes; WithEf overrides
+// SearchL. It gives up between rounds of reads when ctx is done, or
+// returns what it has with ErrPartial under WithBestEffort.
+func (d *DiskANN) SearchContext(ctx context.Context, query vec.Vector, k int) ([]Match, error) {
+	if k < 1 {
+		return nil, fmt.Errorf("k must be at least 1, got %d", k)
+	}
+	ctx, span := tracer.Start(ctx, "DiskANN.Search", trace.WithAttributes(attribute.Int("k", k)))
+	defer span.End()
+
+	q, err := normalize(query, d.pq.dim)
+	if err != nil {
+		return nil, err
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var st diskSearchStats
+	l := max(efFrom(ctx, d.cfg.SearchL), k)
+	found, err := d.search(ctx, q, l, nil, &st)
+	if span.IsRecording() {
+		span.SetAttributes(
+			attribute.Int("l", l),
+			attribute.Int("rounds", st.rounds),
+			attribute.Int("reads", st.reads),
+			attribute.Int("nodes_read", st.nodes),
+		)
+	}
+	var stopErr error
+	if err != nil {
+		if !partial(ctx, err) {
+			return nil, err
+		}
+		stopErr = ErrPartial
+	}
+
+	matches := make([]Match, 0, min(k, len(found)))
+	for _, c := range found[:min(k, len(found))] {
+		matches = append(matches, Match{ID: d.ids[c.id], Score: 1 - c.dist})
+	}
+	return matches, stopErr
+}
+
+// diskSearchStats counts the I/O of one search.
+type diskSearchStats struct {
+	rounds int // batches of node reads
+	reads  int // read calls, after merging adjacent sectors
+	nodes  int // node records read
+}
+
+// search runs a beam search for the unit vector q with a candidate list
+// of l nodes and returns the nodes it read, closest first by exact
+// distance. Records read are added to cache when it is not nil. Callers
+// hold d.mu.
+func (d *DiskANN) search(ctx context.Context, q vec.Vector, l int, cache map[uint32]diskNode, st *diskSearchStats) ([]vamanaCandidate, error) {
+	var (
+		m     = d.pq.subspaces()
+		n     = uint32(len(d.ids))
+		table = d.pq.table(q)
+		list  = newBeamList(l)
+		seen  = map[uint32]bool{d.medoid: true}
+		found []vamanaCandidate
+		beam  []vamanaCandidate
+		ids   []uint32
+		err   error
+	)
+	list.add(vamanaCandidate{d.medoid, pqDistance(table, d.codes[int(d.medoid)*m:][:m])})
+	for {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+		if beam = list.next(d.cfg.BeamWidth, beam[:0]); len(beam) == 0 {
+			break
+		}
+		ids = ids[:0]
+		for _, c := range beam {
+			ids = append(ids, c.id)
+		}
+		var nodes []diskNode
+		if nodes, err = d.readNodes(ids, st); err != nil {
+			break
+		}
+		for i, node := range nodes {
+			dot, _ := vec.DotProduct(q, node.vector)
+			found = append(found, vamanaCandidate{ids[i], 1 - dot})
+			if cache != nil {
+				cache[ids[i]] = node
+			}
+			for _, nb := range node.nbrs {
+				// Nodes past n come from an insert that failed part way.
+				if nb < n && !seen[nb] {
+					seen[nb] = true
+					list.add(vamanaCandidate{nb, pqDistance(table, d.codes[int(nb)*m:][:m])})
+				}
+			}
+		}
+	}
+	slices.SortFunc(found, compareCandidates)
+	return found, err
+}
+
+// Insert implements the VectorIndex interface.
+func (d *DiskANN) Insert(id string, v vec.Vector) error {
+	return d.InsertContext(context.Background(), id, v)
+}
+
+// InsertContext adds v under id, linking it like the build's second pass:
+// a search for v picks its neighbors and each of them gains an edge back,
+// pruned if its list is full. Inserts run one at a time and block
+// searches while they rewrite records.
+func (d *DiskANN) InsertContext(ctx context.Context, id string, v vec.Vector) error {
+	ctx, span := tracer.Start(ctx, "DiskANN.Insert")
+	defer span.End()
+
+	p, err := normalize(v, d.pq.dim)
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.rows[id]; ok {
+		return fmt.Errorf("vector with ID %s already exists", id)
+	}
+	if len(d.ids) >= maxSnapshotLen {
+		return fmt.Errorf("diskann: index is full")
+	}
+
+	cache := make(map[uint32]diskNode)
+	var st diskSearchStats
+	cands, err := d.search(ctx, p, d.cfg.L, cache, &st)
+	if err != nil {
+		return err
+	}
+	self := uint32(len(d.ids))
+	cache[self] = diskNode{vector: p}
+	dist := func(a, b uint32) float32 {
+		dot, _ := vec.DotProduct(cache[a].vector, cache[b].vector)
+		return 1 - dot
+	}
+	nbrs := robustPrune(cands, d.cfg.Alpha, d.cfg.R, dist)
+	if err := d.markPending(); err != nil {
+		return err
+	}
+	if err := d.writeNode(self, p, nbrs); err != nil {
+		return err
+	}
+
+	for _, nb := range nbrs {
+		node := cache[nb]
+		if slices.Contains(node.nbrs, self) {
+			continue
+		}
+		node.nbrs = append(node.nbrs, self)
+		if len(node.nbrs) > d.cfg.R {
+			if err := d.fill(cache, node.nbrs, &st); err != nil {
+				return err
+			}
+			pc := make([]vamanaCandidate, len(node.nbrs))
+			for i, x := range node.nbrs {
+				pc[i] = vamanaCandidate{x, dist(nb, x)}
+			}
+			node.nbrs = robustPrune(pc, d.cfg.Alpha, d.cfg.R, dist)
+		}
+		if err := d.writeNode(nb, node.vector, node.nbrs); err != nil {
+			return err
+		}
+		cache[nb] = node
+	}
+
+	m := d.pq.subspaces()
+	d.codes = append(d.codes, make([]byte, m)...)
+	d.pq.encode(p, d.codes[int(self)*m:])
+	d.ids = append(d.ids, id)
+	d.rows[id] = self
+	d.dirty = true
+	span.SetAttributes(attribute.Int("nodes_read", st.nodes), attribute.Int("neighbors", len(nbrs)))
+	return nil
+}
+
+// fill reads the records of ids missing from cache into it.
+func (d *DiskANN) fill(cache map[uint32]diskNode, ids []uint32, st *diskSearchStats) error {
+	var missing []uint32
+	for _, id := range ids {
+		if _, ok := cache[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	nodes, err := d.readNodes(missing, st)
+	for i, node := range nodes {
+		cache[missing[i]] = node
+	}
+	return err
+}
+
+// writeNode writes the record of node id, growing the file by whole
+// sectors when the record is new. Callers hold d.mu for writing.
+func (d *DiskANN) writeNode(id uint32, v vec.Vector, nbrs []uint32) error {
+	buf := make([]byte, d.layout.recordSize)
+	d.layout.encode(buf, v, nbrs)
+	if _, end := d.layout.span(id); end > d.size {
+		if err := d.graph.Truncate(end); err != nil {
+			return err
+		}
+		d.size = end
+	}
+	_, err := d.graph.WriteAt(buf, d.layout.offset(id))
+	return err
+}
+
+// diskBatch is how many records readEach and scan read at once.
+const diskBatch = 1024
+
+// readEach reads the records of ids, diskBatch at a time, and calls fn
+// with each in order.
+func (d *DiskANN) readEach(ids []uint32, fn func(i int, node diskNode) error) error {
+	var st diskSearchStats
+	for lo := 0; lo < len(ids); lo += diskBatch {
+		nodes, err := d.readNodes(ids[lo:min(lo+diskBatch, len(ids))], &st)
+		if err != nil {
+			return err
+		}
+		for i, node := range nodes {
+			if err := fn(lo+i, node); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// scan calls fn with the records of nodes 0 to n-1 in order.
+func (d *DiskANN) scan(n uint32, fn func(id uint32, node diskNode) error) error {
+	ids := make([]uint32, 0, diskBatch)
+	for first := uint32(0); first < n; first += diskBatch {
+		ids = ids[:0]
+		for id := first; id < min(first+diskBatch, n); id++ {
+			ids = append(ids, id)
+		}
+		err := d.readEach(ids, func(i int, node diskNode) error { return fn(ids[i], node) })
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readNodes reads the records of ids, merging records in the same or
+// adjacent sectors into one read and issuing the reads concurrently, so
+// the device sees a batch of requests rather than one at a time.
+func (d *DiskANN) readNodes(ids []uint32, st *diskSearchStats) ([]diskNode, error) {
+	reads := d.layout.coalesce(ids)
+	st.rounds++
+	st.reads += len(reads)
+	st.nodes += len(ids)
+
+	bufs := make([][]byte, len(reads))
+	errs := make([]error, len(reads))
+	var wg sync.WaitGroup
+	for i, r := range reads {
+		bufs[i] = make([]byte, r.end-r.start)
+		if i == len(reads)-1 {
+			_, errs[i] = d.graph.ReadAt(bufs[i], r.start)
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = d.graph.ReadAt(bufs[i], r.start)
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("diskann: read graph: %w", err)
+	}
+
+	nodes := make([]diskNode, len(ids))
+	for i, id := range ids {
+		off := d.layout.offset(id)
+		j, _ := slices.BinarySearchFunc(reads, off, func(r diskRead, off int64) int {
+			switch {
+			case r.end <= off:
+				return -1
+			case r.start > off:
+				return 1
+			}
+			return 0
+		})
+		r := reads[j]
+		node, err := d.layout.decode(bufs[j][off-r.start:][:d.layout.recordSize])
+		if err != nil {
+			return nil, err
+		}
+		nodes[i] = node
+	}
+	return nodes, nil
+}
@@ -0,0 +1,313 @@
+package index
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+// DiskANNSource yields the vectors BuildDiskANNFrom indexes.
+type DiskANNSource interface {
+	// Next returns the next id and vector, or io.EOF after the last.
+	Next() (string, vec.Vector, error)
+}
+
+// sliceSource is a DiskANNSource over vectors already in memory.
+type sliceSource struct {
+	ids []string
+	vs  []vec.Vector
+	i   int
+}
+
+func (s *sliceSource) Next() (string, vec.Vector, error) {
+	if s.i == len(s.vs) {
+		return "", nil, io.EOF
+	}
+	s.i++
+	return s.ids[s.i-1], s.vs[s.i-1], nil
+}
+
+// BuildDiskANN indexes vs[i] under ids[i] into dir, building the whole
+// graph at once; see BuildDiskANNFrom.
+func BuildDiskANN(dir string, cfg DiskANNConfig, ids []string, vs []vec.Vector, workers int) (*DiskANN, error) {
+	if len(ids) != len(vs) {
+		return nil, fmt.Errorf("diskann: need one id per vector, got %d ids and %d vectors", len(ids), len(vs))
+	}
+	return BuildDiskANNFrom(dir, cfg, &sliceSource{ids: ids, vs: vs}, 0, workers)
+}
+
+// BuildDiskANNFrom indexes the vectors src yields into dir, which is
+// created if needed and must not hold an index yet, and returns the index
+// open.
+//
+// The vectors are streamed once into the graph file. Memory then holds the
+// ids, the codes, a training sample and one shard of the vectors at a
+// time. With shardSize > 0 and more vectors than that, the vectors are
+// clustered into shards of about shardSize, each vector joining the two
+// shards whose centroids are nearest; each shard's graph is built in
+// memory and its edges merged into the graph file, lists that overflow R
+// pruned by code distance. Otherwise one shard holds every vector.
+//
+// Shard graphs are built on workers goroutines (GOMAXPROCS when <= 0);
+// with one worker, equal inputs give equal files. The meta file is written
+// last, so an interrupted build leaves nothing OpenDiskANN accepts.
+func BuildDiskANNFrom(dir string, cfg DiskANNConfig, src DiskANNSource, shardSize, workers int) (*DiskANN, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	if shardSize < 0 {
+		return nil, fmt.Errorf("diskann: shard size must not be negative, got %d", shardSize)
+	}
+	if _, err := os.Stat(filepath.Join(dir, diskANNMetaFile)); err == nil {
+		return nil, fmt.Errorf("diskann: %s already holds an index", dir)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	d := &DiskANN{cfg: cfg, dir: dir, rows: make(map[string]uint32)}
+	err := d.build(src, shardSize, workers)
+	if err == nil {
+		err = d.writeMeta()
+	}
+	if err != nil {
+		if d.graph != nil {
+			d.graph.Close()
+		}
+		os.Remove(filepath.Join(dir, diskANNGraphFile))
+		return nil, err
+	}
+	return d, nil
+}
+
+// build writes the graph file and fills in everything the meta file holds.
+func (d *DiskANN) build(src DiskANNSource, shardSize, workers int) error {
+	train, mean, err := d.writeVectors(src)
+	if err != nil {
+		return err
+	}
+	dim, n := len(mean), uint32(len(d.ids))
+
+	m := d.cfg.PQBytes
+	if m == 0 {
+		m = max(1, dim/4)
+	}
+	d.pq = trainPQ(train, min(m, dim), d.cfg.Seed)
+	m = d.pq.subspaces()
+
+	shards := 1
+	if shardSize > 0 && int(n) > shardSize {
+		// Each vector joins two shards.
+		shards = (2*int(n) + shardSize - 1) / shardSize
+	}
+	var cents []float32
+	if shards > 1 {
+		cents = kmeans(train, 0, dim, shards, rand.New(rand.NewPCG(d.cfg.Seed, 2)))
+	}
+
+	// One pass encodes every vector, finds the medoid, the vector closest
+	// to the mean, where searches start, and assigns the shards.
+	d.codes = make([]byte, int(n)*m)
+	members := make([][]uint32, shards)
+	var bestDot float32
+	err = d.scan(n, func(id uint32, node diskNode) error {
+		d.pq.encode(node.vector, d.codes[int(id)*m:][:m])
+		if dot, _ := vec.DotProduct(mean, node.vector); id == 0 || dot > bestDot {
+			d.medoid, bestDot = id, dot
+		}
+		if shards == 1 {
+			members[0] = append(members[0], id)
+			return nil
+		}
+		for _, s := range nearestTwo(cents, node.vector) {
+			members[s] = append(members[s], id)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, ids := range members {
+		if err := d.buildShard(ids, shards > 1, workers); err != nil {
+			return err
+		}
+	}
+	return d.graph.Sync()
+}
+
+// writeVectors streams src into a new graph file as records without
+// edges. It returns a sample of the unit vectors to train on, drawn
+// uniformly by cfg.Seed, and the sum of them all.
+func (d *DiskANN) writeVectors(src DiskANNSource) (train []vec.Vector, mean vec.Vector, err error) {
+	var (
+		rng   = rand.New(rand.NewPCG(d.cfg.Seed, 1))
+		bw    *bufio.Writer
+		block []byte
+		n     int
+	)
+	for {
+		id, v, err := src.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if n == 0 {
+			if len(v) == 0 {
+				return nil, nil, fmt.Errorf("%s: empty vector", id)
+			}
+			mean = make(vec.Vector, len(v))
+			d.layout = newDiskLayout(len(v), d.cfg.R)
+			if d.graph, err = os.OpenFile(filepath.Join(d.dir, diskANNGraphFile), os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0644); err != nil {
+				return nil, nil, err
+			}
+			bw = bufio.NewWriterSize(d.graph, 1<<20)
+			if _, err := bw.Write(d.layout.header()); err != nil {
+				return nil, nil, err
+			}
+			block = make([]byte, d.layout.sectorBytes())
+		}
+		if n == maxSnapshotLen {
+			return nil, nil, fmt.Errorf("diskann: at most %d vectors", maxSnapshotLen)
+		}
+		if _, ok := d.rows[id]; ok {
+			return nil, nil, fmt.Errorf("vector with ID %s already exists", id)
+		}
+		u, err := normalize(v, len(mean))
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", id, err)
+		}
+		d.rows[id] = uint32(n)
+		d.ids = append(d.ids, id)
+		for i, x := range u {
+			mean[i] += x
+		}
+		if len(train) < pqTrainSample {
+			train = append(train, u)
+		} else if j := rng.IntN(n + 1); j < pqTrainSample {
+			train[j] = u
+		}
+
+		first := n - n%d.layout.perBlock()
+		d.layout.encode(block[d.layout.offset(uint32(n))-d.layout.offset(uint32(first)):], u, nil)
+		if n++; n%d.layout.perBlock() == 0 {
+			if _, err := bw.Write(block); err != nil {
+				return nil, nil, err
+			}
+			clear(block)
+		}
+	}
+	if n == 0 {
+		return nil, nil, fmt.Errorf("diskann: need at least one vector")
+	}
+	if n%d.layout.perBlock() != 0 {
+		if _, err := bw.Write(block); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return nil, nil, err
+	}
+	if d.size, err = d.graph.Seek(0, io.SeekEnd); err != nil {
+		return nil, nil, err
+	}
+	return train, mean, nil
+}
+
+// nearestTwo returns the rows of cents closest and next closest to v.
+func nearestTwo(cents []float32, v vec.Vector) [2]int {
+	best := [2]int{-1, -1}
+	var dists [2]float32
+	for c := range len(cents) / len(v) {
+		d := centroidDist(cents, c, v)
+		switch {
+		case best[0] < 0 || d < dists[0]:
+			best[1], dists[1] = best[0], dists[0]
+			best[0], dists[0] = c, d
+		case best[1] < 0 || d < dists[1]:
+			best[1], dists[1] = c, d
+		}
+	}
+	return best
+}
+
+// buildShard links the nodes ids, sorted, into a graph of their own and
+// writes their edges. With merge set, the edges they got from earlier
+// shards are kept too, and a list over R is pruned again.
+func (d *DiskANN) buildShard(ids []uint32, merge bool, workers int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	vs := make([]vec.Vector, len(ids))
+	old := make([][]uint32, len(ids))
+	err := d.readEach(ids, func(i int, node diskNode) error {
+		vs[i], old[i] = node.vector, node.nbrs
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	g := buildVamana(vs, d.cfg, workers)
+	for i, id := range ids {
+		nbrs := make([]uint32, len(g.adj[i]))
+		for j, local := range g.adj[i] {
+			nbrs[j] = ids[local]
+		}
+		if merge && len(old[i]) > 0 {
+			nbrs = d.mergeEdges(id, nbrs, old[i], ids, vs)
+		}
+		if err := d.writeNode(id, vs[i], nbrs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeEdges adds old to the neighbors nbrs node id has in the current
+// shard, whose nodes are ids with vectors vs, and prunes the union to R.
+// Nodes outside the shard are compared by their decoded codes, so merging
+// reads no vectors beyond the shard's.
+func (d *DiskANN) mergeEdges(id uint32, nbrs, old, ids []uint32, vs []vec.Vector) []uint32 {
+	for _, nb := range old {
+		if !slices.Contains(nbrs, nb) {
+			nbrs = append(nbrs, nb)
+		}
+	}
+	if len(nbrs) <= d.cfg.R {
+		return nbrs
+	}
+
+	m := d.pq.subspaces()
+	decoded := make(map[uint32]vec.Vector)
+	vector := func(x uint32) vec.Vector {
+		if i, ok := slices.BinarySearch(ids, x); ok {
+			return vs[i]
+		}
+		v, ok := decoded[x]
+		if !ok {
+			v = make(vec.Vector, d.pq.dim)
+			d.pq.decode(d.codes[int(x)*m:][:m], v)
+			decoded[x] = v
+		}
+		return v
+	}
+	dist := func(a, b uint32) float32 {
+		dot, _ := vec.DotProduct(vector(a), vector(b))
+		return 1 - dot
+	}
+	cands := make([]vamanaCandidate, len(nbrs))
+	for i, nb := range nbrs {
+		cands[i] = vamanaCandidate{nb, dist(id, nb)}
+	}
+	return robustPrune(cands, d.cfg.Alpha, d.cfg.R, dist)
+}
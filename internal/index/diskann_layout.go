@@ -0,0 +1,141 @@
+package index
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+// diskSector is the unit the graph file is laid out in: the page size of
+// most SSDs and of the page cache, so one record costs one device read.
+const diskSector = 4096
+
+// diskLayout places fixed-size node records in the graph file. Sector 0
+// holds the header. Records of up to a sector are packed perSector to a
+// sector, the tail of each sector left empty; larger records take
+// sectorsPer whole sectors each. Either way a node's address is computed
+// from its number alone.
+//
+// A record is the node's unit vector as float32s, its degree as a uint32,
+// and R uint32 neighbor slots, little endian.
+type diskLayout struct {
+	dim, r     int
+	recordSize int
+	perSector  int // records per sector, 0 when records span sectors
+	sectorsPer int // sectors per record when perSector is 0
+}
+
+// diskNode is a decoded graph record.
+type diskNode struct {
+	vector vec.Vector
+	nbrs   []uint32
+}
+
+// diskRead is one read of whole sectors, [start, end) in the graph file.
+type diskRead struct {
+	start, end int64
+}
+
+func newDiskLayout(dim, r int) diskLayout {
+	l := diskLayout{dim: dim, r: r, recordSize: 4*dim + 4 + 4*r}
+	if l.recordSize <= diskSector {
+		l.perSector = diskSector / l.recordSize
+	} else {
+		l.sectorsPer = (l.recordSize + diskSector - 1) / diskSector
+	}
+	return l
+}
+
+// header is the graph file's first sector.
+func (l diskLayout) header() []byte {
+	var b bytes.Buffer
+	s := newSnapWriter(&b)
+	s.str("vamana-graph")
+	s.u32(diskANNVersion)
+	s.u32(uint32(l.dim))
+	s.u32(uint32(l.r))
+	s.flush()
+	return append(b.Bytes(), make([]byte, diskSector-b.Len())...)
+}
+
+// perBlock is the number of records in one block of sectorBytes.
+func (l diskLayout) perBlock() int { return max(l.perSector, 1) }
+
+// sectorBytes is the size of the smallest run of sectors records fill
+// evenly: a sector, or one record's sectors.
+func (l diskLayout) sectorBytes() int { return max(l.sectorsPer, 1) * diskSector }
+
+// offset is where the record of node id starts.
+func (l diskLayout) offset(id uint32) int64 {
+	if l.perSector > 0 {
+		return int64(1+int(id)/l.perSector)*diskSector + int64(int(id)%l.perSector*l.recordSize)
+	}
+	return int64(1+int(id)*l.sectorsPer) * diskSector
+}
+
+// span returns the sectors holding the record of node id.
+func (l diskLayout) span(id uint32) (start, end int64) {
+	off := l.offset(id)
+	start = off - off%diskSector
+	return start, start + int64(l.sectorBytes())
+}
+
+// coalesce returns the sector reads covering the records of ids, sorted,
+// with overlapping and adjacent spans merged.
+func (l diskLayout) coalesce(ids []uint32) []diskRead {
+	reads := make([]diskRead, 0, len(ids))
+	for _, id := range ids {
+		start, end := l.span(id)
+		reads = append(reads, diskRead{start, end})
+	}
+	slices.SortFunc(reads, func(a, b diskRead) int { return cmp.Compare(a.start, b.start) })
+	out := reads[:0]
+	for _, r := range reads {
+		if n := len(out); n > 0 && r.start <= out[n-1].end {
+			out[n-1].end = max(out[n-1].end, r.end)
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// encode writes every byte of a node's record to the start of buf.
+func (l diskLayout) encode(buf []byte, v vec.Vector, nbrs []uint32) {
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(f))
+	}
+	off := 4 * l.dim
+	binary.LittleEndian.PutUint32(buf[off:], uint32(len(nbrs)))
+	off += 4
+	for i := range l.r {
+		var nb uint32
+		if i < len(nbrs) {
+			nb = nbrs[i]
+		}
+		binary.LittleEndian.PutUint32(buf[off+4*i:], nb)
+	}
+}
+
+func (l diskLayout) decode(buf []byte) (diskNode, error) {
+	n := diskNode{vector: make(vec.Vector, l.dim)}
+	for i := range n.vector {
+		n.vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	off := 4 * l.dim
+	deg := int(binary.LittleEndian.Uint32(buf[off:]))
+	if deg > l.r {
+		return diskNode{}, fmt.Errorf("diskann: record with degree %d above R %d", deg, l.r)
+	}
+	off += 4
+	n.nbrs = make([]uint32, deg)
+	for i := range n.nbrs {
+		n.nbrs[i] = binary.LittleEndian.Uint32(buf[off+4*i:])
+	}
+	return n, nil
+}
@@ -0,0 +1,306 @@
+package index
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+func diskANNRecall(t *testing.T, idx VectorIndex, naive *NaiveIndex, dim int) float64 {
+	t.Helper()
+	hits := 0
+	for q := 0; q < 50; q++ {
+		query := randomVec(dim)
+		truth, _ := naive.Search(query, 10)
+		got, err := idx.Search(query, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := make(map[string]bool)
+		for _, m := range truth {
+			want[m.ID] = true
+		}
+		for _, m := range got {
+			if want[m.ID] {
+				hits++
+			}
+		}
+	}
+	return float64(hits) / 500
+}
+
+func TestDiskANN(t *testing.T) {
+	const dim = 32
+	dir := t.TempDir()
+	cfg := DefaultDiskANNConfig()
+	cfg.R = 32
+	naive := NewNaiveIndex()
+	ids := make([]string, 2000)
+	vs := make([]vec.Vector, len(ids))
+	for i := range ids {
+		ids[i], vs[i] = fmt.Sprintf("v%d", i), randomVec(dim)
+		naive.Insert(ids[i], vs[i])
+	}
+
+	idx, err := BuildDiskANN(dir, cfg, ids, vs, 0)
+	if err != nil {
+		t.Fatalf("BuildDiskANN: %v", err)
+	}
+	if recall := diskANNRecall(t, idx, naive, dim); recall < 0.9 {
+		t.Errorf("recall after build = %.2f", recall)
+	}
+	if _, err := BuildDiskANN(dir, cfg, ids, vs, 0); err == nil {
+		t.Error("expected a second build into the same directory to fail")
+	}
+
+	var n42 vec.Vector
+	for i := 0; i < 500; i++ {
+		id, v := fmt.Sprintf("n%d", i), randomVec(dim)
+		if i == 42 {
+			n42 = v
+		}
+		if err := idx.Insert(id, v); err != nil {
+			t.Fatalf("Insert %s: %v", id, err)
+		}
+		naive.Insert(id, v)
+	}
+	if err := idx.Insert("v0", randomVec(dim)); err == nil {
+		t.Error("expected duplicate id to be rejected")
+	}
+	if err := idx.Insert("short", randomVec(dim-1)); err == nil {
+		t.Error("expected dimension mismatch to be rejected")
+	}
+	if recall := diskANNRecall(t, idx, naive, dim); recall < 0.9 {
+		t.Errorf("recall after inserts = %.2f", recall)
+	}
+	if got, _ := idx.Search(n42, 1); len(got) != 1 || got[0].ID != "n42" {
+		t.Errorf("an inserted vector should find itself, got %v", got)
+	}
+
+	queries := make([]vec.Vector, 10)
+	want := make([][]Match, len(queries))
+	for i := range queries {
+		queries[i] = randomVec(dim)
+		want[i], _ = idx.Search(queries[i], 10)
+	}
+	if err := idx.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	reopened, err := OpenDiskANN(dir)
+	if err != nil {
+		t.Fatalf("OpenDiskANN: %v", err)
+	}
+	defer reopened.Close()
+	if reopened.Len() != 2500 || reopened.Config() != cfg {
+		t.Errorf("reopened Len %d, config %+v", reopened.Len(), reopened.Config())
+	}
+	for i, q := range queries {
+		got, _ := reopened.Search(q, 10)
+		if !reflect.DeepEqual(got, want[i]) {
+			t.Fatalf("results differ after reopen:\nwant %v\ngot  %v", want[i], got)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := reopened.SearchContext(ctx, queries[0], 10); err != context.Canceled {
+		t.Errorf("cancelled search: %v", err)
+	}
+	if got, _ := reopened.SearchContext(WithEf(context.Background(), 200), queries[0], 10); len(got) != 10 {
+		t.Errorf("search with a wider list returned %d matches", len(got))
+	}
+}
+
+// A build in shards, streamed from a source, holds a fraction of the
+// vectors in memory at once and still finds the nearest neighbors.
+func TestDiskANN_Sharded(t *testing.T) {
+	const dim = 32
+	cfg := DefaultDiskANNConfig()
+	cfg.R = 32
+	naive := NewNaiveIndex()
+	ids := make([]string, 2000)
+	vs := make([]vec.Vector, len(ids))
+	for i := range ids {
+		ids[i], vs[i] = fmt.Sprintf("v%d", i), randomVec(dim)
+		naive.Insert(ids[i], vs[i])
+	}
+
+	build := func(dir string) *DiskANN {
+		idx, err := BuildDiskANNFrom(dir, cfg, &sliceSource{ids: ids, vs: vs}, 500, 1)
+		if err != nil {
+			t.Fatalf("BuildDiskANNFrom: %v", err)
+		}
+		return idx
+	}
+	a, b := t.TempDir(), t.TempDir()
+	idx := build(a)
+	defer idx.Close()
+	build(b).Close()
+
+	if recall := diskANNRecall(t, idx, naive, dim); recall < 0.9 {
+		t.Errorf("recall after a sharded build = %.2f", recall)
+	}
+	for _, name := range []string{diskANNGraphFile, diskANNMetaFile} {
+		fa, _ := os.ReadFile(filepath.Join(a, name))
+		fb, _ := os.ReadFile(filepath.Join(b, name))
+		if len(fa) == 0 || !bytes.Equal(fa, fb) {
+			t.Errorf("%s differs between single-worker sharded builds", name)
+		}
+	}
+
+	dir := t.TempDir()
+	dup := &sliceSource{ids: []string{"x", "y", "x"}, vs: []vec.Vector{randomVec(dim), randomVec(dim), randomVec(dim)}}
+	if _, err := BuildDiskANNFrom(dir, cfg, dup, 0, 1); err == nil {
+		t.Error("expected a duplicate id to fail the build")
+	}
+	if _, err := os.Stat(filepath.Join(dir, diskANNGraphFile)); !os.IsNotExist(err) {
+		t.Errorf("failed build left a graph file: %v", err)
+	}
+}
+
+// Inserts not yet flushed to the meta file are lost in a crash, and
+// opening the directory afterwards strips the edges they left in the graph
+// file, so the index takes new inserts cleanly.
+func TestDiskANN_UnflushedInserts(t *testing.T) {
+	dir := t.TempDir()
+	pending := filepath.Join(dir, diskANNPendingFile)
+	ids := make([]string, 300)
+	vs := make([]vec.Vector, len(ids))
+	for i := range ids {
+		ids[i], vs[i] = fmt.Sprintf("v%d", i), randomVec(16)
+	}
+	idx, err := BuildDiskANN(dir, DefaultDiskANNConfig(), ids, vs, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		if err := idx.Insert(fmt.Sprintf("n%d", i), randomVec(16)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := os.Stat(pending); err != nil {
+		t.Fatalf("no pending marker for unflushed inserts: %v", err)
+	}
+	idx.graph.Close() // crash before the meta file is written
+
+	crashed, err := OpenDiskANN(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if crashed.Len() != 300 {
+		t.Errorf("Len = %d, want the 300 flushed vectors", crashed.Len())
+	}
+	if _, err := os.Stat(pending); !os.IsNotExist(err) {
+		t.Errorf("pending marker left after open: %v", err)
+	}
+	all := make([]uint32, 300)
+	for i := range all {
+		all[i] = uint32(i)
+	}
+	nodes, err := crashed.readNodes(all, &diskSearchStats{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, node := range nodes {
+		for _, nb := range node.nbrs {
+			if nb >= 300 {
+				t.Fatalf("node %d keeps an edge to unlisted node %d", i, nb)
+			}
+		}
+	}
+	for i := 0; i < 20; i++ {
+		got, err := crashed.Search(randomVec(16), 10)
+		if err != nil || len(got) != 10 {
+			t.Fatalf("search: %v, %d matches", err, len(got))
+		}
+		for _, m := range got {
+			if m.ID[0] == 'n' {
+				t.Fatalf("unflushed vector %s returned", m.ID)
+			}
+		}
+	}
+
+	m5 := randomVec(16)
+	for i := 0; i < 10; i++ {
+		v := randomVec(16)
+		if i == 5 {
+			v = m5
+		}
+		if err := crashed.Insert(fmt.Sprintf("m%d", i), v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := crashed.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(pending); !os.IsNotExist(err) {
+		t.Errorf("pending marker left after Close: %v", err)
+	}
+	reopened, err := OpenDiskANN(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if reopened.Len() != 310 {
+		t.Errorf("Len after Close = %d, want 310", reopened.Len())
+	}
+	if got, _ := reopened.Search(m5, 1); len(got) != 1 || got[0].ID != "m5" {
+		t.Errorf("an inserted vector should find itself, got %v", got)
+	}
+}
+
+func TestDiskANN_Reproducible(t *testing.T) {
+	ids := make([]string, 500)
+	vs := make([]vec.Vector, len(ids))
+	for i := range ids {
+		ids[i], vs[i] = fmt.Sprintf("v%d", i), randomVec(16)
+	}
+	build := func() string {
+		dir := t.TempDir()
+		idx, err := BuildDiskANN(dir, DefaultDiskANNConfig(), ids, vs, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		idx.Close()
+		return dir
+	}
+	a, b := build(), build()
+	for _, name := range []string{diskANNGraphFile, diskANNMetaFile} {
+		fa, _ := os.ReadFile(filepath.Join(a, name))
+		fb, _ := os.ReadFile(filepath.Join(b, name))
+		if len(fa) == 0 || !bytes.Equal(fa, fb) {
+			t.Errorf("%s differs between single-worker builds", name)
+		}
+	}
+}
+
+func TestDiskLayout(t *testing.T) {
+	l := newDiskLayout(32, 32) // 260-byte records, 15 to a sector
+	if l.perSector != 15 || l.offset(16) != 2*diskSector+260 {
+		t.Fatalf("layout %+v, offset(16) = %d", l, l.offset(16))
+	}
+	got := l.coalesce([]uint32{100, 1, 15, 0})
+	want := []diskRead{{diskSector, 3 * diskSector}, {7 * diskSector, 8 * diskSector}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("coalesce = %v, want %v", got, want)
+	}
+
+	big := newDiskLayout(1024, 64) // a record takes two sectors
+	if big.sectorsPer != 2 || big.offset(3) != 7*diskSector {
+		t.Errorf("layout %+v, offset(3) = %d", big, big.offset(3))
+	}
+
+	buf := make([]byte, l.recordSize)
+	v := randomVec(32)
+	l.encode(buf, v, []uint32{7, 9})
+	n, err := l.decode(buf)
+	if err != nil || !reflect.DeepEqual(n.vector, v) || !reflect.DeepEqual(n.nbrs, []uint32{7, 9}) {
+		t.Errorf("decode = %+v, %v", n, err)
+	}
+}
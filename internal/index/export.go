@@ -0,0 +1,108 @@
+package index
+
+import (
+	"context"
+	"slices"
+
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+// rangeBatch is the number of rows Range copies per read lock.
+const rangeBatch = 256
+
+// Entry is one live vector as returned by Range.
+type Entry struct {
+	ID string
+	// Vector holds the values as inserted, rescaled from the stored unit
+	// vector, so it carries the storage precision's rounding.
+	Vector vec.Vector
+	// Bits is set instead of Vector for Hamming and Jaccard indexes.
+	Bits vec.BitVector
+}
+
+// Range calls fn for every live vector in insertion order. It copies rows
+// in small batches under the read lock and calls fn without it, so inserts
+// and deletes proceed during the walk; vectors added or removed meanwhile
+// may or may not be seen. Range stops at the first error from fn or ctx.
+func (h *HNSW) Range(ctx context.Context, fn func(Entry) error) error {
+	batch := make([]Entry, 0, rangeBatch)
+	for row := 0; ; {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		batch = batch[:0]
+		h.globalLock.RLock()
+		for ; row < len(h.nodes) && len(batch) < rangeBatch; row++ {
+			n := h.nodes[row]
+			if n == nil {
+				continue
+			}
+			id, ok := h.internalToID[n.id]
+			if !ok {
+				continue // deleted
+			}
+			p := h.vectors.get(row)
+			if p.dense != nil {
+				norm := h.normOf(row)
+				for i := range p.dense {
+					p.dense[i] *= norm
+				}
+			}
+			batch = append(batch, Entry{ID: id, Vector: p.dense, Bits: p.bits})
+		}
+		done := row >= len(h.nodes)
+		h.globalLock.RUnlock()
+
+		for _, e := range batch {
+			if err := fn(e); err != nil {
+				return err
+			}
+		}
+		if done {
+			return nil
+		}
+	}
+}
+
+// Vectors returns a copy of every live sparse vector, rebuilt from the
+// posting lists in one pass under the read lock.
+func (s *SparseIndex) Vectors() map[string]vec.SparseVector {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	dims := make([]uint32, 0, len(s.postings))
+	for d := range s.postings {
+		dims = append(dims, d)
+	}
+	slices.Sort(dims) // keeps each rebuilt vector's indices ascending
+
+	byRow := make(map[int32]*vec.SparseVector, len(s.rows))
+	for _, row := range s.rows {
+		byRow[int32(row)] = &vec.SparseVector{}
+	}
+	for _, d := range dims {
+		for _, p := range s.postings[d] {
+			if v, ok := byRow[p.row]; ok {
+				v.Indices = append(v.Indices, d)
+				v.Values = append(v.Values, p.value)
+			}
+		}
+	}
+	out := make(map[string]vec.SparseVector, len(byRow))
+	for row, v := range byRow {
+		out[s.ids[row]] = *v
+	}
+	return out
+}
+
+// Documents returns the original text of every live document. Documents
+// restored from a snapshot older than version 3 have empty text.
+func (t *TextIndex) Documents() map[string]string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	out := make(map[string]string, len(t.rows))
+	for id, row := range t.rows {
+		out[id] = t.texts[row]
+	}
+	return out
+}
@@ -0,0 +1,162 @@
+package index
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+func rangeAll(t *testing.T, h *HNSW) map[string]vec.Vector {
+	t.Helper()
+	got := make(map[string]vec.Vector)
+	err := h.Range(context.Background(), func(e Entry) error {
+		got[e.ID] = e.Vector
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func closeTo(a, b vec.Vector, tol float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Abs(float64(a[i]-b[i])) > tol*math.Max(1, math.Abs(float64(b[i]))) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestHNSW_Range(t *testing.T) {
+	idx := NewHNSW(DefaultConfig())
+	want := make(map[string]vec.Vector)
+	for i := 0; i < 600; i++ { // several batches
+		v := randomVec(8)
+		for j := range v {
+			v[j] *= float32(i + 1)
+		}
+		id := fmt.Sprintf("v%d", i)
+		idx.Insert(id, v)
+		want[id] = v
+	}
+	idx.Delete("v3")
+	delete(want, "v3")
+
+	check := func(name string, got map[string]vec.Vector) {
+		if len(got) != len(want) {
+			t.Fatalf("%s: %d entries, want %d", name, len(got), len(want))
+		}
+		for id, v := range want {
+			if !closeTo(got[id], v, 1e-5) {
+				t.Fatalf("%s: %s = %v, want %v", name, id, got[id], v)
+			}
+		}
+	}
+	check("Range", rangeAll(t, idx))
+	byVector := make(map[string]vec.Vector, len(want))
+	for id := range want {
+		byVector[id], _ = idx.Vector(id)
+	}
+	check("Vector", byVector)
+
+	var buf bytes.Buffer
+	if err := idx.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadHNSW(&buf, DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("Range after reload", rangeAll(t, loaded))
+
+	stop := fmt.Errorf("stop")
+	n := 0
+	err = idx.Range(context.Background(), func(Entry) error {
+		if n++; n == 10 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || n != 10 {
+		t.Errorf("Range did not stop at the callback's error: %v after %d", err, n)
+	}
+}
+
+func TestHNSW_RangeConcurrentInserts(t *testing.T) {
+	idx := NewHNSW(DefaultConfig())
+	for i := 0; i < 500; i++ {
+		idx.Insert(fmt.Sprintf("old%d", i), randomVec(8))
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 500; i++ {
+			idx.Insert(fmt.Sprintf("new%d", i), randomVec(8))
+		}
+	}()
+	got := rangeAll(t, idx)
+	wg.Wait()
+
+	// everything present before the walk is seen exactly once
+	for i := 0; i < 500; i++ {
+		if _, ok := got[fmt.Sprintf("old%d", i)]; !ok {
+			t.Fatalf("old%d missing from a concurrent Range", i)
+		}
+	}
+}
+
+func TestSparseAndText_Export(t *testing.T) {
+	sp := NewSparseIndex()
+	sp.InsertSparse("A", sparse(t, []uint32{100, 1}, []float32{2, 1}))
+	sp.InsertSparse("B", sparse(t, []uint32{100}, []float32{2}))
+	sp.InsertSparse("B", sparse(t, []uint32{7}, []float32{3})) // overwrite
+	sp.InsertSparse("C", sparse(t, []uint32{7}, []float32{1}))
+	sp.Delete("C")
+
+	txt := NewTextIndex()
+	txt.Insert("A", "The quick brown fox!")
+	txt.Insert("B", "lazy dog")
+	txt.Insert("B", "lazy brown dog")
+	txt.Delete("A")
+
+	wantSp := map[string]vec.SparseVector{
+		"A": sparse(t, []uint32{1, 100}, []float32{1, 2}),
+		"B": sparse(t, []uint32{7}, []float32{3}),
+	}
+	wantTxt := map[string]string{"B": "lazy brown dog"}
+
+	var buf bytes.Buffer
+	sp.Save(&buf)
+	txt.Save(&buf)
+	sp2, err := LoadSparseIndex(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txt2, err := LoadTextIndex(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, got := range map[string]map[string]vec.SparseVector{"live": sp.Vectors(), "reloaded": sp2.Vectors()} {
+		if !reflect.DeepEqual(got, wantSp) {
+			t.Errorf("%s sparse Vectors = %v", name, got)
+		}
+	}
+	for name, got := range map[string]map[string]string{"live": txt.Documents(), "reloaded": txt2.Documents()} {
+		if !reflect.DeepEqual(got, wantTxt) {
+			t.Errorf("%s text Documents = %v", name, got)
+		}
+	}
+}
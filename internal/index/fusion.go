@@ -0,0 +1,75 @@
+package index
+
+import (
+	"container/heap"
+	"math"
+)
+
+// Fusion selects how ranked lists from different indexes are merged.
+type Fusion int
+
+const (
+	// FusionWeighted min-max normalizes each list's scores to [0, 1] and
+	// sums them with per-list weights.
+	FusionWeighted Fusion = iota
+	// FusionRRF is reciprocal rank fusion: sum of weight / (rrfK + rank).
+	// It ignores raw scores, so it needs no normalization.
+	FusionRRF
+)
+
+// DefaultRRFK is the rank offset recommended by the original RRF paper.
+const DefaultRRFK = 60
+
+// Fuse merges ranked match lists (best first) into a single top-k list.
+// weights[i] applies to lists[i]; missing weights default to 1. rrfK is only
+// used by FusionRRF and defaults to DefaultRRFK when <= 0.
+func Fuse(method Fusion, lists [][]Match, weights []float32, rrfK, k int) []Match {
+	if rrfK <= 0 {
+		rrfK = DefaultRRFK
+	}
+
+	fused := make(map[string]float32)
+	for li, list := range lists {
+		w := float32(1)
+		if li < len(weights) {
+			w = weights[li]
+		}
+
+		switch method {
+		case FusionRRF:
+			for rank, m := range list {
+				fused[m.ID] += w / float32(rrfK+rank+1)
+			}
+		default:
+			lo, hi := scoreRange(list)
+			for _, m := range list {
+				norm := float32(1)
+				if hi > lo {
+					norm = (m.Score - lo) / (hi - lo)
+				}
+				fused[m.ID] += w * norm
+			}
+		}
+	}
+
+	pq := &MatchQueue{}
+	heap.Init(pq)
+	for id, score := range fused {
+		pq.PushWithLimit(Match{ID: id, Score: score}, k)
+	}
+
+	results := make([]Match, pq.Len())
+	for i := len(results) - 1; i >= 0; i-- {
+		results[i] = heap.Pop(pq).(Match)
+	}
+	return results
+}
+
+func scoreRange(list []Match) (lo, hi float32) {
+	lo, hi = math.MaxFloat32, -math.MaxFloat32
+	for _, m := range list {
+		lo = min(lo, m.Score)
+		hi = max(hi, m.Score)
+	}
+	return lo, hi
+}
@@ -1,15 +1,26 @@
 package index
 
-import "container/heap"
+import (
+	"cmp"
+	"container/heap"
+	"strings"
+)
 
 // MatchQueue is a priorty queue of Matches, ordered by Score.
 // we use Min heap to keep track of the Top K largest scores.
+// Equal scores are ordered by ID, the smaller ID ranking higher, so results
+// do not depend on the order matches were pushed in.
 
 type MatchQueue []Match
 
-func (pq MatchQueue) Len() int           { return len(pq) }
-func (pq MatchQueue) Less(i, j int) bool { return pq[i].Score < pq[j].Score }
-func (pq MatchQueue) Swap(i, j int)      { pq[i], pq[j] = pq[j], pq[i] }
+func (pq MatchQueue) Len() int { return len(pq) }
+func (pq MatchQueue) Less(i, j int) bool {
+	if pq[i].Score != pq[j].Score {
+		return pq[i].Score < pq[j].Score
+	}
+	return pq[i].ID > pq[j].ID
+}
+func (pq MatchQueue) Swap(i, j int) { pq[i], pq[j] = pq[j], pq[i] }
 
 func (pq *MatchQueue) Push(x any) {
 	*pq = append(*pq, x.(Match)) // use type-assertion.
@@ -30,3 +41,11 @@ func (pq *MatchQueue) PushWithLimit(item Match, k int) {
 		heap.Pop(pq)
 	}
 }
+
+// compareMatches orders matches best first, the order MatchQueue yields.
+func compareMatches(a, b Match) int {
+	if c := cmp.Compare(b.Score, a.Score); c != 0 {
+		return c
+	}
+	return strings.Compare(a.ID, b.ID)
+}
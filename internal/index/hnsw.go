@@ -1,19 +1,57 @@
 package index
 
 import (
+	"fmt"
 	"math"
-	"math/rand"
+	"math/rand/v2"
 	"sync"
+	"sync/atomic"
 
 	"github.com/sandeep89846/nebuladb/pkg/vec"
 )
 
+// Precision selects how vectors are stored in memory. Distances are always
+// computed against a float32 query and accumulated in float32.
+type Precision int
+
+const (
+	PrecisionFloat32  Precision = iota // 4 bytes per dimension (default)
+	PrecisionFloat16                   // IEEE half, 2 bytes per dimension
+	PrecisionBFloat16                  // bfloat16, 2 bytes per dimension
+)
+
+func (p Precision) String() string {
+	switch p {
+	case PrecisionFloat32:
+		return "float32"
+	case PrecisionFloat16:
+		return "float16"
+	case PrecisionBFloat16:
+		return "bfloat16"
+	}
+	return fmt.Sprintf("Precision(%d)", int(p))
+}
+
+// ParsePrecision is the inverse of Precision.String.
+func ParsePrecision(s string) (Precision, error) {
+	for _, p := range []Precision{PrecisionFloat32, PrecisionFloat16, PrecisionBFloat16} {
+		if p.String() == s {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown precision %q", s)
+}
+
 type Config struct {
-	M               int     // Max connections per layer
-	M0              int     // Max connections at Layer 0 (usually 2*M)
-	EfConstruction  int     // Search range during insertion
-	EfSearch        int     // Default ef for search (tunable)
-	LevelMultiplier float64 // Probabilistic factor
+	M               int       // Max connections per layer
+	M0              int       // Max connections at Layer 0 (usually 2*M)
+	EfConstruction  int       // Search range during insertion
+	EfSearch        int       // Default ef for search (tunable)
+	LevelMultiplier float64   // Probabilistic factor
+	Precision       Precision // Storage precision of dense vectors
+	Metric          Metric    // Distance function, also picks dense vs binary vectors
+	Seed            uint64    // Seeds node levels; equal seeds and inserts give equal graphs
+	VectorDir       string    // If set, vectors live in a memory-mapped file here, not the heap
 }
 
 func DefaultConfig() Config {
@@ -30,11 +68,14 @@ func DefaultConfig() Config {
 type Node struct {
 	id    uint64
 	level int
-	vec   vec.Vector
 
 	// adj list representation.
 	neighbors [][]uint64
 
+	// deleted marks a tombstone: the node still routes searches but is
+	// never returned. See HNSW.Delete.
+	deleted atomic.Bool
+
 	mu sync.RWMutex
 }
 
@@ -49,8 +90,28 @@ type HNSW struct {
 	entryPointID uint64
 	maxLevel     int // Current highest layer
 
-	// globalLock protects id maps, nodes slice, entryPoint, maxLevel
+	// vectors holds the normalized vectors, row = internalID-1.
+	// Created on first insert, which fixes the dimension of the index.
+	vectors vectorStore
+	// norms holds the magnitude each dense vector had before it was
+	// normalized, row = internalID-1, so Range can return original values.
+	norms []float32
+	// bitLen is the length in bits of a binary index's vectors, fixed with
+	// the dimension by the first insert.
+	bitLen int
+
+	// layerNodes[l] counts the nodes present at layer l, tombstones
+	// included, and tombstones the deleted ones, so Stats need not walk
+	// the graph.
+	layerNodes []int
+	tombstones int
+
+	// globalLock protects id maps, nodes slice, vectors, bitLen,
+	// entryPoint, maxLevel and the node counts
 	globalLock sync.RWMutex
+
+	counters counters
+	observer atomic.Pointer[func(SearchStats)]
 }
 
 func NewHNSW(cfg Config) *HNSW {
@@ -64,18 +125,23 @@ func NewHNSW(cfg Config) *HNSW {
 	}
 }
 
-// randomLevel determines the height of a new node using LevelMultiplier.
-func (h *HNSW) randomLevel() int {
+// randomLevel determines the height of the node with the given internal id
+// using LevelMultiplier. The draw comes from a generator keyed by the
+// index's Seed and the id rather than from shared state, so a node gets the
+// same level however inserts interleave and whether or not the index was
+// reloaded from a snapshot in between.
+func (h *HNSW) randomLevel(internalID uint64) int {
+	rng := rand.New(rand.NewPCG(h.config.Seed, internalID))
 	mult := h.config.LevelMultiplier
 	if mult <= 0 {
 		lvl := 0
-		for rand.Float64() < 0.5 {
+		for rng.Float64() < 0.5 {
 			lvl++
 		}
 		return lvl
 	}
 
-	u := rand.Float64()
+	u := rng.Float64()
 	if u <= 0 {
 		return 0
 	}
@@ -86,61 +152,105 @@ func (h *HNSW) randomLevel() int {
 	return lvl
 }
 
-// fastDot: a simple, fast float32 dot product optimized for typical vector lengths.
-// Assumes both vectors have the same length. If lengths mismatch, returns ok=false.
-// Unrolls loop by 4 for a small speedup (Replacement to earlier Dot func).
-func fastDot(a, b vec.Vector) (float32, bool) {
-	na := len(a)
-	nb := len(b)
-	if na != nb {
-		return 0, false
+// nodeByID returns the *Node for a given internalID, or nil if not present.
+// It acquires the read lock briefly.
+func (h *HNSW) nodeByID(internalID uint64) *Node {
+	h.globalLock.RLock()
+	defer h.globalLock.RUnlock()
+	if internalID == 0 {
+		return nil
 	}
-	var sum float32 = 0.0
-	i := 0
-	for ; i+3 < na; i += 4 {
-		sum += a[i]*b[i] + a[i+1]*b[i+1] + a[i+2]*b[i+2] + a[i+3]*b[i+3]
+	idx := int(internalID - 1)
+	if idx < 0 || idx >= len(h.nodes) {
+		return nil
 	}
-	for ; i < na; i++ {
-		sum += a[i] * b[i]
+	return h.nodes[idx]
+}
+
+// pointOf returns the stored vector for internalID, decoded to its query
+// representation, or the zero point if missing.
+func (h *HNSW) pointOf(internalID uint64) point {
+	h.globalLock.RLock()
+	store := h.vectors
+	h.globalLock.RUnlock()
+	if internalID == 0 || store == nil {
+		return point{}
 	}
-	return sum, true
+	return store.get(int(internalID - 1))
 }
 
-// dist calculates "Cosine Distance" (1 - Similarity).
-// Assumes vectors are normalized (magnitude == 1). Returns a large
-// distance when dimensions mismatch (defensive).
-func (h *HNSW) dist(v1, v2 vec.Vector) float32 {
-	if v1 == nil || v2 == nil {
-		return float32(math.MaxFloat32)
+// vectorOf returns the stored (normalized) dense vector for internalID,
+// decoded to float32, or nil.
+func (h *HNSW) vectorOf(internalID uint64) vec.Vector {
+	return h.pointOf(internalID).dense
+}
+
+// setNorm records the pre-normalization magnitude of row. Callers hold
+// globalLock for writing.
+func (h *HNSW) setNorm(row int, norm float32) {
+	for len(h.norms) <= row {
+		h.norms = append(h.norms, 1)
 	}
-	if dot, ok := fastDot(v1, v2); ok {
-		return 1.0 - dot
+	h.norms[row] = norm
+}
+
+// normOf returns the magnitude recorded for row, or 1 for rows restored
+// from a snapshot that predates norms. Callers hold globalLock.
+func (h *HNSW) normOf(row int) float32 {
+	if row < len(h.norms) {
+		return h.norms[row]
 	}
-	return float32(math.MaxFloat32)
+	return 1
 }
 
-// nodeByID returns the *Node for a given internalID, or nil if not present.
-// It acquires the read lock briefly.
-func (h *HNSW) nodeByID(internalID uint64) *Node {
+// dimension returns the vector dimension of the index, or 0 while empty.
+// For binary indexes this is the number of 64-bit words.
+func (h *HNSW) dimension() int {
 	h.globalLock.RLock()
 	defer h.globalLock.RUnlock()
-	if internalID == 0 {
-		return nil
+	if h.vectors == nil {
+		return 0
 	}
-	idx := int(internalID - 1)
-	if idx < 0 || idx >= len(h.nodes) {
+	return h.vectors.dimension()
+}
+
+// BitLen returns the length in bits of the vectors in a binary index, or 0
+// while it is empty.
+func (h *HNSW) BitLen() int {
+	h.globalLock.RLock()
+	defer h.globalLock.RUnlock()
+	return h.bitLen
+}
+
+// checkDim rejects p if it does not match the dimension, and for bit
+// vectors the length, fixed by the first insert.
+func (h *HNSW) checkDim(p point) error {
+	h.globalLock.RLock()
+	defer h.globalLock.RUnlock()
+	if h.fits(p) {
 		return nil
 	}
-	return h.nodes[idx]
+	if p.bits != nil {
+		return fmt.Errorf("bit vector length %d does not match index length %d", p.bitLen, h.bitLen)
+	}
+	return fmt.Errorf("vector dimension %d does not match index dimension %d", p.dim(), h.vectors.dimension())
 }
 
-// snapshotNodes returns a slice of *Node for the provided internalIDs.
-// This acquires a single RLock and collects pointers into a new slice.
-func (h *HNSW) snapshotNodes(ids []uint64) []*Node {
+// fits reports whether p can be stored in the index. Callers hold
+// globalLock.
+func (h *HNSW) fits(p point) bool {
+	if h.vectors == nil {
+		return true
+	}
+	return h.vectors.dimension() == p.dim() && p.bitLen == h.bitLen
+}
+
+// snapshotNodes appends the *Node for each of the provided internalIDs to
+// out. Missing IDs are skipped. This acquires a single RLock for the batch.
+func (h *HNSW) snapshotNodes(ids []uint64, out []*Node) []*Node {
 	h.globalLock.RLock()
 	defer h.globalLock.RUnlock()
 
-	out := make([]*Node, 0, len(ids))
 	for _, id := range ids {
 		if id == 0 {
 			continue
@@ -155,3 +265,39 @@ func (h *HNSW) snapshotNodes(ids []uint64) []*Node {
 	}
 	return out
 }
+
+// distBatch fills out with the distance from query to each node, using one
+// batch kernel call. rows is scratch space for the arena rows.
+func (h *HNSW) distBatch(query point, nodes []*Node, rows []int, out []float32) []int {
+	rows = rows[:0]
+	for _, n := range nodes {
+		rows = append(rows, int(n.id-1))
+	}
+
+	h.globalLock.RLock()
+	store := h.vectors
+	h.globalLock.RUnlock()
+	if store == nil {
+		// closed under a search in flight
+		for i := range rows {
+			out[i] = float32(math.MaxFloat32)
+		}
+		return rows
+	}
+
+	store.distRows(query, rows, out)
+	return rows
+}
+
+// distTo returns the distance from query to a single stored node.
+func (h *HNSW) distTo(query point, internalID uint64) float32 {
+	h.globalLock.RLock()
+	store := h.vectors
+	h.globalLock.RUnlock()
+	if internalID == 0 || store == nil {
+		return float32(math.MaxFloat32)
+	}
+	var out [1]float32
+	store.distRows(query, []int{int(internalID - 1)}, out[:])
+	return out[0]
+}
@@ -0,0 +1,135 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+// InsertBatch inserts vs[i] under ids[i] on workers goroutines, GOMAXPROCS
+// when workers <= 0, and returns one error per vector, nil where it went
+// in. See insertBatch for what is deterministic.
+func (h *HNSW) InsertBatch(ctx context.Context, ids []string, vs []vec.Vector, workers int) []error {
+	errs := make([]error, len(ids))
+	points := make([]point, len(ids))
+	norms := make([]float32, len(ids))
+	for i, v := range vs {
+		switch {
+		case h.config.Metric.Binary():
+			errs[i] = fmt.Errorf("index stores binary vectors, use InsertBinary")
+		case len(v) == 0:
+			errs[i] = fmt.Errorf("empty vector")
+		default:
+			if norms[i] = vec.Magnitude(v); norms[i] == 0 {
+				errs[i] = fmt.Errorf("zero-magnitude vector")
+				continue
+			}
+			points[i].dense = make(vec.Vector, len(v))
+			for j := range v {
+				points[i].dense[j] = v[j] / norms[i]
+			}
+		}
+	}
+	h.insertBatch(ctx, ids, points, norms, workers, errs)
+	return errs
+}
+
+// InsertBinaryBatch is InsertBatch for Hamming and Jaccard indexes. Every
+// vector in vs is n bits long; see InsertBinary.
+func (h *HNSW) InsertBinaryBatch(ctx context.Context, ids []string, vs []vec.BitVector, n, workers int) []error {
+	errs := make([]error, len(ids))
+	points := make([]point, len(ids))
+	for i, v := range vs {
+		if errs[i] = h.checkBinary(v, n); errs[i] == nil {
+			points[i] = point{bits: v, bitLen: n}
+		}
+	}
+	h.insertBatch(ctx, ids, points, make([]float32, len(ids)), workers, errs)
+	return errs
+}
+
+// insertBatch inserts the points whose errs entry is still nil. Ids and
+// levels are assigned sequentially in batch order first, and an id
+// repeated within the batch fails after its first use. The batch's highest
+// node, the first at that level, is then linked on its own, so no later
+// node in the batch can outrank it: the entry point afterwards is that
+// node or the one before the batch, whichever is higher, however the
+// workers are scheduled. The rest are linked concurrently.
+func (h *HNSW) insertBatch(ctx context.Context, ids []string, points []point, norms []float32, workers int, errs []error) {
+	type pending struct {
+		i          int
+		internalID uint64
+		level      int
+	}
+	var (
+		todo = make([]pending, 0, len(ids))
+		seen = make(map[string]bool, len(ids))
+		top  = -1
+		dim  = h.dimension()
+	)
+	for i, id := range ids {
+		if errs[i] != nil {
+			continue
+		}
+		if seen[id] {
+			errs[i] = fmt.Errorf("vector with ID %s already exists", id)
+			continue
+		}
+		if errs[i] = h.checkInsert(id, points[i]); errs[i] != nil {
+			continue
+		}
+		// An empty index takes its dimension from the batch's first vector,
+		// not from whichever is linked first.
+		if dim == 0 {
+			dim = points[i].dim()
+		} else if points[i].dim() != dim {
+			errs[i] = fmt.Errorf("vector dimension %d does not match index dimension %d", points[i].dim(), dim)
+			continue
+		}
+		seen[id] = true
+		internalID, level := h.allocate()
+		todo = append(todo, pending{i, internalID, level})
+		if top < 0 || level > todo[top].level {
+			top = len(todo) - 1
+		}
+	}
+	if top < 0 {
+		return
+	}
+
+	run := func(p pending) {
+		if err := ctx.Err(); err != nil {
+			errs[p.i] = err
+			return
+		}
+		errs[p.i] = h.insertNode(ctx, ids[p.i], points[p.i], norms[p.i], p.internalID, p.level)
+	}
+	run(todo[top])
+	todo = append(todo[:top], todo[top+1:]...)
+
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	var (
+		next atomic.Int64
+		wg   sync.WaitGroup
+	)
+	for range min(workers, len(todo)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				n := int(next.Add(1)) - 1
+				if n >= len(todo) {
+					return
+				}
+				run(todo[n])
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,88 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+func TestHNSW_InsertBatch(t *testing.T) {
+	idx := NewHNSW(DefaultConfig())
+	naive := NewNaiveIndex()
+	ids := make([]string, 2000)
+	vs := make([]vec.Vector, len(ids))
+	for i := range ids {
+		ids[i] = fmt.Sprintf("v%d", i)
+		vs[i] = randomVec(32)
+		naive.Insert(ids[i], vs[i])
+	}
+	for i, err := range idx.InsertBatch(context.Background(), ids, vs, 4) {
+		if err != nil {
+			t.Fatalf("%s: %v", ids[i], err)
+		}
+	}
+	if idx.Len() != len(ids) {
+		t.Fatalf("Len = %d, want %d", idx.Len(), len(ids))
+	}
+
+	// the entry point is the first node drawn at the top level
+	top := 0
+	for i, n := range idx.nodes {
+		if n.level > idx.nodes[top].level {
+			top = i
+		}
+	}
+	if idx.entryPointID != idx.nodes[top].id || idx.maxLevel != idx.nodes[top].level {
+		t.Errorf("entry point %d at level %d, want %d at level %d",
+			idx.entryPointID, idx.maxLevel, idx.nodes[top].id, idx.nodes[top].level)
+	}
+
+	hits := 0
+	for q := 0; q < 50; q++ {
+		query := randomVec(32)
+		truth, _ := naive.Search(query, 10)
+		got, _ := idx.Search(query, 10)
+		want := make(map[string]bool)
+		for _, m := range truth {
+			want[m.ID] = true
+		}
+		for _, m := range got {
+			if want[m.ID] {
+				hits++
+			}
+		}
+	}
+	if recall := float64(hits) / 500; recall < 0.9 {
+		t.Errorf("recall after InsertBatch = %.2f", recall)
+	}
+}
+
+func TestHNSW_InsertBatchErrors(t *testing.T) {
+	idx := NewHNSW(DefaultConfig())
+	idx.Insert("old", vec.Vector{1, 0, 0})
+
+	ids := []string{"a", "old", "a", "zero", "short", "b"}
+	vs := []vec.Vector{{1, 1, 0}, {0, 1, 0}, {0, 0, 1}, {0, 0, 0}, {1, 1}, {0, 1, 1}}
+	errs := idx.InsertBatch(context.Background(), ids, vs, 0)
+	for i, wantErr := range []bool{false, true, true, true, true, false} {
+		if (errs[i] != nil) != wantErr {
+			t.Errorf("%s: error %v, want error %t", ids[i], errs[i], wantErr)
+		}
+	}
+	if v, _ := idx.Vector("a"); v[2] != 0 {
+		t.Errorf("the first of a repeated id should win, got %v", v)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, err := range idx.InsertBatch(ctx, []string{"c"}, []vec.Vector{{1, 2, 3}}, 0) {
+		if err != context.Canceled {
+			t.Errorf("cancelled batch: %v", err)
+		}
+	}
+	if errs := idx.InsertBinaryBatch(context.Background(), []string{"bits"}, []vec.BitVector{{1}}, 64, 0); errs[0] == nil {
+		t.Error("expected binary vectors to be rejected by a dense index")
+	}
+}
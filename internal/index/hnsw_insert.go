@@ -1,58 +1,181 @@
 package index
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/sandeep89846/nebuladb/pkg/vec"
 )
 
 // Insert adds a vector to the index.
 func (h *HNSW) Insert(id string, v vec.Vector) error {
+	return h.InsertContext(context.Background(), id, v)
+}
 
-	h.globalLock.RLock()
-	_, exists := h.idToInternal[id]
-	h.globalLock.RUnlock()
-	if exists {
-		return fmt.Errorf("vector with ID %s already exists", id)
+// InsertContext is Insert that gives up when ctx is done. A cancelled
+// insert leaves the index unchanged.
+func (h *HNSW) InsertContext(ctx context.Context, id string, v vec.Vector) error {
+	mag, err := h.checkDense(v)
+	if err != nil {
+		return err
+	}
+	normalized := make(vec.Vector, len(v))
+	for i := range v {
+		normalized[i] = v[i] / mag
 	}
 
-	// Validate & normalize vector
+	return h.insert(ctx, id, point{dense: normalized}, mag)
+}
+
+// checkDense validates a dense vector for insertion and returns its
+// magnitude.
+func (h *HNSW) checkDense(v vec.Vector) (float32, error) {
+	if h.config.Metric.Binary() {
+		return 0, fmt.Errorf("index stores binary vectors, use InsertBinary")
+	}
 	if len(v) == 0 {
-		return fmt.Errorf("empty vector")
+		return 0, fmt.Errorf("empty vector")
 	}
 	mag := vec.Magnitude(v)
 	if mag == 0 {
-		return fmt.Errorf("zero-magnitude vector")
+		return 0, fmt.Errorf("zero-magnitude vector")
 	}
-	normalized := make(vec.Vector, len(v))
-	for i := range v {
-		normalized[i] = v[i] / mag
+	return mag, nil
+}
+
+// CheckInsert returns the error Insert would fail with for id and v, without
+// inserting. An insert of the same id that races with it can still win.
+func (h *HNSW) CheckInsert(id string, v vec.Vector) error {
+	if _, err := h.checkDense(v); err != nil {
+		return err
+	}
+	return h.checkInsert(id, point{dense: v})
+}
+
+// InsertBinary adds a packed bit vector, n bits long, to a Hamming or
+// Jaccard index. The first insert fixes n for the index; Hamming distances
+// are normalized by it.
+func (h *HNSW) InsertBinary(id string, v vec.BitVector, n int) error {
+	return h.InsertBinaryContext(context.Background(), id, v, n)
+}
+
+// InsertBinaryContext is InsertBinary that gives up when ctx is done.
+func (h *HNSW) InsertBinaryContext(ctx context.Context, id string, v vec.BitVector, n int) error {
+	if err := h.checkBinary(v, n); err != nil {
+		return err
+	}
+	return h.insert(ctx, id, point{bits: v, bitLen: n}, 0)
+}
+
+func (h *HNSW) checkBinary(v vec.BitVector, n int) error {
+	if !h.config.Metric.Binary() {
+		return fmt.Errorf("index stores dense vectors, use Insert")
+	}
+	return checkBits(v, n)
+}
+
+// checkBits rejects v unless it is n bits long: n fills its last word, and
+// no bit from n on is set.
+func checkBits(v vec.BitVector, n int) error {
+	if len(v) == 0 {
+		return fmt.Errorf("empty vector")
+	}
+	if n <= 0 || (n+63)/64 != len(v) {
+		return fmt.Errorf("%d-word bit vector cannot be %d bits long", len(v), n)
+	}
+	if n%64 != 0 && v[len(v)-1]>>(n%64) != 0 {
+		return fmt.Errorf("bit vector has bits set past its length %d", n)
+	}
+	return nil
+}
+
+// CheckInsertBinary is CheckInsert for InsertBinary.
+func (h *HNSW) CheckInsertBinary(id string, v vec.BitVector, n int) error {
+	if err := h.checkBinary(v, n); err != nil {
+		return err
+	}
+	return h.checkInsert(id, point{bits: v, bitLen: n})
+}
+
+// insert adds p under id. norm is the magnitude of a dense vector before
+// normalization; binary vectors pass 0.
+func (h *HNSW) insert(ctx context.Context, id string, p point, norm float32) error {
+	if err := h.checkInsert(id, p); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	internalID, level := h.allocate()
+	return h.insertNode(ctx, id, p, norm, internalID, level)
+}
+
+// checkInsert rejects ids already stored and vectors of the wrong
+// dimension. insertNode checks again under the write lock.
+func (h *HNSW) checkInsert(id string, p point) error {
+	h.globalLock.RLock()
+	_, exists := h.idToInternal[id]
+	h.globalLock.RUnlock()
+	if exists {
+		return fmt.Errorf("vector with ID %s already exists", id)
 	}
+	return h.checkDim(p)
+}
 
+// allocate reserves the internal id and draws the level of a new node.
+func (h *HNSW) allocate() (uint64, int) {
 	internalID := atomic.AddUint64(&h.nextID, 1)
-	level := h.randomLevel()
+	return internalID, h.randomLevel(internalID)
+}
+
+// insertNode publishes p as node internalID and links it into the graph.
+func (h *HNSW) insertNode(ctx context.Context, id string, p point, norm float32, internalID uint64, level int) error {
+	_, span := tracer.Start(ctx, "HNSW.Insert")
+	defer span.End()
 
 	node := &Node{
 		id:        internalID,
-		vec:       normalized,
 		level:     level,
 		neighbors: make([][]uint64, level+1),
 	}
 
+	lockStart := time.Now()
 	h.globalLock.Lock()
+	lockWait := time.Since(lockStart)
 	// Re-check to avoid race where another goroutine inserted same ID
 	if _, exists := h.idToInternal[id]; exists {
 		h.globalLock.Unlock()
 		return fmt.Errorf("vector with ID %s already exists", id)
 	}
+	if h.vectors == nil {
+		store, err := newVectorStore(h.config, p.dim(), p.bitLen)
+		if err != nil {
+			h.globalLock.Unlock()
+			return err
+		}
+		h.vectors = store
+		h.bitLen = p.bitLen
+	} else if !h.fits(p) {
+		h.globalLock.Unlock()
+		return h.checkDim(p)
+	}
+	// Store the vector first: a file-backed store can fail to grow, and
+	// the node must not be published without it.
+	idx := int(internalID - 1)
+	if err := h.vectors.set(idx, p); err != nil {
+		h.globalLock.Unlock()
+		return err
+	}
 
 	h.idToInternal[id] = internalID
 	h.internalToID[internalID] = id
 
 	// Place node into slice at index internalID-1 (grow if necessary)
-	idx := int(internalID - 1)
 	if idx == len(h.nodes) {
 		h.nodes = append(h.nodes, node)
 	} else if idx < len(h.nodes) {
@@ -64,6 +187,10 @@ func (h *HNSW) Insert(id string, v vec.Vector) error {
 		newNodes[idx] = node
 		h.nodes = newNodes
 	}
+	h.countNode(level, 1)
+	if p.dense != nil {
+		h.setNorm(idx, norm)
+	}
 
 	entryPointID := h.entryPointID
 	maxLevel := h.maxLevel
@@ -87,10 +214,35 @@ func (h *HNSW) Insert(id string, v vec.Vector) error {
 		currNode = node
 	}
 
-	currDist := h.dist(normalized, currNode.vec)
+	currDist := h.distTo(p, currObjID)
+	st := SearchStats{Distances: 1}
+	defer func() {
+		h.counters.insertDistances.Add(uint64(st.Distances))
+		if span.IsRecording() {
+			span.SetAttributes(
+				attribute.Int("level", level),
+				attribute.Int("ef", h.config.EfConstruction),
+				attribute.Int("visited", st.Visited),
+				attribute.Int("distances", st.Distances),
+				attribute.Int64("lock_wait_us", lockWait.Microseconds()),
+			)
+		}
+	}()
+
+	sp := scratchPool.get()
+	sp.reset()
+
+	// The node is published but nothing links to it yet, so until the
+	// linking below it can be withdrawn if ctx is cancelled.
+	done := ctx.Done()
 
 	// Traverse layers down to the node's top level
 	for l := maxLevel; l > level; l-- {
+		if done != nil && stopped(done) {
+			scratchPool.put(sp)
+			h.withdraw(id, internalID)
+			return ctx.Err()
+		}
 		changed := true
 		for changed {
 			changed = false
@@ -101,14 +253,14 @@ func (h *HNSW) Insert(id string, v vec.Vector) error {
 				currNode.mu.RUnlock()
 				break
 			}
-			neighbors := make([]uint64, len(currNode.neighbors[l]))
-			copy(neighbors, currNode.neighbors[l])
+			sp.ids = append(sp.ids[:0], currNode.neighbors[l]...)
 			currNode.mu.RUnlock()
 
-			neighborNodes := h.snapshotNodes(neighbors)
+			sp.gather(h, p)
+			st.Distances += len(sp.nodes)
 
-			for _, neighborNode := range neighborNodes {
-				d := h.dist(normalized, neighborNode.vec)
+			for i, neighborNode := range sp.nodes {
+				d := sp.dists[i]
 				if d < currDist {
 					currDist = d
 					currObjID = neighborNode.id
@@ -119,24 +271,26 @@ func (h *HNSW) Insert(id string, v vec.Vector) error {
 		}
 	}
 
+	sp.reset()
+	scratchPool.put(sp)
+
 	topLevel := int(math.Min(float64(maxLevel), float64(level)))
 
+	// Pick the neighbors on every layer first and link afterwards. Linking
+	// layer l does not change what the search on layer l-1 sees, so this
+	// matches linking as we go.
+	links := make([][]uint64, topLevel+1)
 	for l := topLevel; l >= 0; l-- {
 		// Search for efConstruction neighbors
-		searchRes := h.searchLayer(normalized, []uint64{currObjID}, h.config.EfConstruction, l)
+		searchRes, err := h.searchLayer(ctx, p, []uint64{currObjID}, h.config.EfConstruction, l, false, &st)
+		if err != nil {
+			resultPool.put(searchRes)
+			h.withdraw(id, internalID)
+			return err
+		}
 
 		// Select M neighbors to connect to
-		neighborsToAdd := h.selectNeighbors(searchRes, h.config.M)
-
-		// Link: NewNode -> Neighbors
-		node.mu.Lock()
-		node.neighbors[l] = neighborsToAdd
-		node.mu.Unlock()
-
-		// Link: Neighbors -> NewNode (Bidirectional)
-		for _, neighborID := range neighborsToAdd {
-			h.addBidirectionalConnection(neighborID, internalID, l)
-		}
+		links[l] = h.selectNeighbors(searchRes, h.config.M)
 
 		// Update currObjID to the closest node found in this layer
 		if searchRes.Len() > 0 {
@@ -150,6 +304,19 @@ func (h *HNSW) Insert(id string, v vec.Vector) error {
 				}
 			}
 		}
+		resultPool.put(searchRes)
+	}
+
+	for l := topLevel; l >= 0; l-- {
+		// Link: NewNode -> Neighbors
+		node.mu.Lock()
+		node.neighbors[l] = links[l]
+		node.mu.Unlock()
+
+		// Link: Neighbors -> NewNode (Bidirectional)
+		for _, neighborID := range links[l] {
+			h.addBidirectionalConnection(neighborID, internalID, l)
+		}
 	}
 
 	h.globalLock.Lock()
@@ -162,6 +329,17 @@ func (h *HNSW) Insert(id string, v vec.Vector) error {
 	return nil
 }
 
+// withdraw undoes the publication of a node that was never linked into the
+// graph. Its slot in h.nodes is left nil.
+func (h *HNSW) withdraw(id string, internalID uint64) {
+	h.globalLock.Lock()
+	delete(h.idToInternal, id)
+	delete(h.internalToID, internalID)
+	h.countNode(h.nodes[internalID-1].level, -1)
+	h.nodes[internalID-1] = nil
+	h.globalLock.Unlock()
+}
+
 // Helper: Pick M closest from a bounded max-heap results
 func (h *HNSW) selectNeighbors(results *maxBoundedPQ, m int) []uint64 {
 
@@ -216,16 +394,18 @@ func (h *HNSW) addBidirectionalConnection(hostID, guestID uint64, layer int) {
 
 	if len(hostNode.neighbors[layer]) > limit {
 
-		neighbors := make([]uint64, len(hostNode.neighbors[layer]))
-		copy(neighbors, hostNode.neighbors[layer])
+		sp := scratchPool.get()
+		sp.reset()
+		defer scratchPool.put(sp)
 
-		neighborNodes := h.snapshotNodes(neighbors)
+		sp.ids = append(sp.ids, hostNode.neighbors[layer]...)
+		sp.gather(h, h.pointOf(hostID))
+		h.counters.insertDistances.Add(uint64(len(sp.nodes)))
 
 		worstIdx := -1
 		var worstDist float32 = -1.0
 
-		for i, nNode := range neighborNodes {
-			d := h.dist(hostNode.vec, nNode.vec)
+		for i, d := range sp.dists {
 			if d > worstDist {
 				worstDist = d
 				worstIdx = i
@@ -233,7 +413,7 @@ func (h *HNSW) addBidirectionalConnection(hostID, guestID uint64, layer int) {
 		}
 
 		if worstIdx != -1 {
-			toRemove := neighbors[worstIdx]
+			toRemove := sp.nodes[worstIdx].id
 
 			l := len(hostNode.neighbors[layer])
 			for i, id := range hostNode.neighbors[layer] {
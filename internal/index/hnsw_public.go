@@ -1,17 +1,39 @@
 package index
 
 import (
+	"context"
 	"fmt"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/sandeep89846/nebuladb/pkg/vec"
 )
 
+var tracer = otel.Tracer("github.com/sandeep89846/nebuladb/internal/index")
+
 // Search implements the VectorIndex interface
 func (h *HNSW) Search(query vec.Vector, k int) ([]Match, error) {
+	return h.SearchContext(context.Background(), query, k)
+}
+
+// SearchContext is Search that stops when ctx is done; see WithBestEffort
+// for returning partial results at the deadline instead.
+func (h *HNSW) SearchContext(ctx context.Context, query vec.Vector, k int) ([]Match, error) {
+	if h.config.Metric.Binary() {
+		return nil, fmt.Errorf("index stores binary vectors, use SearchBinary")
+	}
+
 	// Validate & normalize query
 	if len(query) == 0 {
 		return nil, fmt.Errorf("empty query vector")
 	}
+	if dim := h.dimension(); dim != 0 && dim != len(query) {
+		return nil, fmt.Errorf("query dimension %d does not match index dimension %d", len(query), dim)
+	}
 	mag := vec.Magnitude(query)
 	if mag == 0 {
 		return nil, fmt.Errorf("zero-magnitude query vector")
@@ -21,7 +43,43 @@ func (h *HNSW) Search(query vec.Vector, k int) ([]Match, error) {
 		nq[i] = query[i] / mag
 	}
 
+	return h.search(ctx, point{dense: nq}, k)
+}
+
+// SearchBinary finds the k nearest bit vectors to query in a Hamming or
+// Jaccard index. Scores are 1 - distance, so 1 is an exact match.
+func (h *HNSW) SearchBinary(query vec.BitVector, k int) ([]Match, error) {
+	return h.SearchBinaryContext(context.Background(), query, k)
+}
+
+// SearchBinaryContext is SearchBinary that stops when ctx is done.
+func (h *HNSW) SearchBinaryContext(ctx context.Context, query vec.BitVector, k int) ([]Match, error) {
+	if !h.config.Metric.Binary() {
+		return nil, fmt.Errorf("index stores dense vectors, use Search")
+	}
+	if len(query) == 0 {
+		return nil, fmt.Errorf("empty query vector")
+	}
+	// Only the words are compared: the query is taken to be as long as the
+	// index's vectors, so its bits past that length must be zero.
+	if dim := h.dimension(); dim != 0 && dim != len(query) {
+		return nil, fmt.Errorf("bit vector length %d does not match index length %d", query.Len(), h.BitLen())
+	}
+	return h.search(ctx, point{bits: query}, k)
+}
+
+func (h *HNSW) search(ctx context.Context, nq point, k int) ([]Match, error) {
+	if k < 1 {
+		return nil, fmt.Errorf("k must be at least 1, got %d", k)
+	}
+	ctx, span := tracer.Start(ctx, "HNSW.Search", trace.WithAttributes(attribute.Int("k", k)))
+	defer span.End()
+
+	// lockWait sums the waits for globalLock below, as insertNode reports
+	// its own; the per-node reads during the traversal are not counted.
+	lockStart := time.Now()
 	h.globalLock.RLock()
+	lockWait := time.Since(lockStart)
 	entryPointID := h.entryPointID
 	maxLevel := h.maxLevel
 	h.globalLock.RUnlock()
@@ -31,55 +89,181 @@ func (h *HNSW) Search(query vec.Vector, k int) ([]Match, error) {
 	}
 
 	currObjID := entryPointID
+	var st SearchStats
+	var stopErr error
+	defer func() {
+		h.recordSearch(st)
+		if span.IsRecording() {
+			span.SetAttributes(
+				attribute.Bool("partial", stopErr != nil),
+				attribute.Int("ef", st.Ef),
+				attribute.Int("visited", st.Visited),
+				attribute.Int("distances", st.Distances),
+				attribute.Int("layer_descents", st.Descents),
+				attribute.Int64("lock_wait_us", lockWait.Microseconds()),
+			)
+		}
+	}()
 
+	_, descend := tracer.Start(ctx, "HNSW.descend", trace.WithAttributes(attribute.Int("max_level", maxLevel)))
 	for l := maxLevel; l > 0; l-- {
-		res := h.searchLayer(nq, []uint64{currObjID}, 1, l)
+		st.Descents++
+		res, err := h.searchLayer(ctx, nq, []uint64{currObjID}, 1, l, false, &st)
 		if res.Len() > 0 {
 
 			currObjID = res.Pop().id
 		}
 		// MEMORY FIX: Return the queue to the pool!
-		resultPool.Put(res)
+		resultPool.put(res)
+		if err != nil && !partial(ctx, err) {
+			descend.End()
+			return nil, err
+		}
 	}
+	descend.End()
 
 	// Layer 0: The Full Search
-	efSearch := h.config.EfSearch
+	efSearch := efFrom(ctx, h.config.EfSearch)
 	if efSearch < k {
 		efSearch = k
 	}
 
-	res := h.searchLayer(nq, []uint64{currObjID}, efSearch, 0)
+	st.Ef = efSearch
+	_, layer0 := tracer.Start(ctx, "HNSW.searchLayer", trace.WithAttributes(attribute.Int("layer", 0), attribute.Int("ef", efSearch)))
+	res, err := h.searchLayer(ctx, nq, []uint64{currObjID}, efSearch, 0, true, &st)
+	layer0.End()
+	if err != nil {
+		if !partial(ctx, err) {
+			resultPool.put(res)
+			return nil, err
+		}
+		stopErr = ErrPartial
+	}
 
 	// res.PopAll() returns Furthest->Closest
 	allCandidates := res.PopAll()
 
 	// MEMORY FIX: Return the queue to the pool!
-	resultPool.Put(res)
+	resultPool.put(res)
+
+	// Every live candidate is kept until the cut to k, so that a tie at the
+	// k-th score is broken by ID, as MatchQueue does, rather than by
+	// traversal order.
+	finalMatches := make([]Match, 0, len(allCandidates))
+	lockStart = time.Now()
+	h.globalLock.RLock()
+	lockWait += time.Since(lockStart)
+	for _, c := range allCandidates {
+		externalID, ok := h.internalToID[c.id]
+		if !ok {
+			continue // deleted since the traversal saw it
+		}
+		// Dist = 1 - Sim  =>  Sim = 1 - Dist (for every metric)
+		finalMatches = append(finalMatches, Match{ID: externalID, Score: 1.0 - c.dist})
+	}
+	h.globalLock.RUnlock()
+	slices.SortFunc(finalMatches, compareMatches)
+	if len(finalMatches) > k {
+		finalMatches = finalMatches[:k]
+	}
 
-	finalMatches := make([]Match, 0, k)
+	return finalMatches, stopErr
+}
 
-	// Iterate backwards to get Closest -> Furthest
-	count := 0
-	for i := len(allCandidates) - 1; i >= 0; i-- {
-		c := allCandidates[i]
+// Delete removes id from the index and reports whether it was present. The
+// node stays in the graph as a tombstone so searches keep routing through
+// it; the id may be inserted again as a new node.
+func (h *HNSW) Delete(id string) bool {
+	h.globalLock.Lock()
+	defer h.globalLock.Unlock()
 
-		h.globalLock.RLock()
-		externalID := h.internalToID[c.id]
-		h.globalLock.RUnlock()
+	internalID, ok := h.idToInternal[id]
+	if !ok {
+		return false
+	}
+	delete(h.idToInternal, id)
+	delete(h.internalToID, internalID)
+	h.nodes[internalID-1].deleted.Store(true)
+	h.tombstones++
+	return true
+}
 
-		// Dist = 1 - Sim  =>  Sim = 1 - Dist
-		score := 1.0 - c.dist
+// Vector returns the vector stored for id in a dense index, rescaled to
+// the magnitude it was inserted with, as Range returns it.
+func (h *HNSW) Vector(id string) (vec.Vector, bool) {
+	h.globalLock.RLock()
+	internalID, ok := h.idToInternal[id]
+	var norm float32
+	if ok {
+		norm = h.normOf(int(internalID - 1))
+	}
+	h.globalLock.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	v := h.pointOf(internalID).dense
+	for i := range v {
+		v[i] *= norm
+	}
+	return v, v != nil
+}
 
-		finalMatches = append(finalMatches, Match{
-			ID:    externalID,
-			Score: score,
-		})
+// BitVector returns the stored vector for id in a Hamming or Jaccard index.
+func (h *HNSW) BitVector(id string) (vec.BitVector, bool) {
+	p, ok := h.lookup(id)
+	return p.bits, ok && p.bits != nil
+}
 
-		count++
-		if count >= k {
-			break
-		}
+func (h *HNSW) lookup(id string) (point, bool) {
+	h.globalLock.RLock()
+	internalID, ok := h.idToInternal[id]
+	h.globalLock.RUnlock()
+	if !ok {
+		return point{}, false
+	}
+	return h.pointOf(internalID), true
+}
+
+// Has reports whether id is stored.
+func (h *HNSW) Has(id string) bool {
+	h.globalLock.RLock()
+	defer h.globalLock.RUnlock()
+	_, ok := h.idToInternal[id]
+	return ok
+}
+
+// Len returns the number of live vectors.
+func (h *HNSW) Len() int {
+	h.globalLock.RLock()
+	defer h.globalLock.RUnlock()
+	return len(h.idToInternal)
+}
+
+// Prefetch reads the vectors of an index with a VectorDir into the page
+// cache, so the first searches after a start do not each wait on disk. It
+// is a no-op for vectors on the heap, and worthwhile only when the vectors
+// fit in memory. Searches and inserts may run meanwhile.
+func (h *HNSW) Prefetch(ctx context.Context) error {
+	h.globalLock.RLock()
+	store := h.vectors
+	h.globalLock.RUnlock()
+	if store == nil {
+		return nil
 	}
+	return store.prefetch(ctx)
+}
 
-	return finalMatches, nil
+// Close unmaps and closes the vector file of an index with a VectorDir,
+// once the searches and prefetches reading it are done. Nothing may start
+// using the index once Close is called. Heap indexes need no Close, but it
+// is harmless.
+func (h *HNSW) Close() error {
+	h.globalLock.Lock()
+	store := h.vectors
+	h.vectors = nil
+	h.globalLock.Unlock()
+	if store == nil {
+		return nil
+	}
+	return store.close()
 }
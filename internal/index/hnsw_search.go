@@ -1,10 +1,6 @@
 package index
 
-import (
-	"sync"
-
-	"github.com/sandeep89846/nebuladb/pkg/vec"
-)
+import "context"
 
 // candidate represents a node traversed during search.
 type candidate struct {
@@ -218,61 +214,104 @@ func (p *maxBoundedPQ) siftDownMax(i int) {
 // Pools
 // ---------------------------
 
-var candidatePool = sync.Pool{
-	New: func() any {
-		p := &minPQ{items: make([]candidate, 0, 64)}
-		return p
-	},
+var candidatePool = newCountedPool("candidate", func() *minPQ {
+	return &minPQ{items: make([]candidate, 0, 64)}
+})
+
+var resultPool = newCountedPool("result", func() *maxBoundedPQ {
+	// default capacity 64; reset with desired ef on use
+	return newMaxBoundedPQ(64)
+})
+
+var visitedPool = newCountedPool("visited", func() *map[uint64]bool {
+	m := make(map[uint64]bool)
+	return &m
+})
+
+// batchScratch holds the buffers used to gather a neighbor list and score
+// it with a single batch kernel call.
+type batchScratch struct {
+	ids   []uint64
+	nodes []*Node
+	rows  []int
+	dists []float32
 }
 
-var resultPool = sync.Pool{
-	New: func() any {
-		// default capacity 64; reset with desired ef on use
-		return newMaxBoundedPQ(64)
-	},
+func (b *batchScratch) reset() {
+	b.ids = b.ids[:0]
+	clear(b.nodes)
+	b.nodes = b.nodes[:0]
 }
 
-var visitedPool = sync.Pool{
-	New: func() any { m := make(map[uint64]bool); return &m },
+// gather snapshots the nodes for b.ids and scores them against query,
+// leaving the results in b.nodes / b.dists.
+func (b *batchScratch) gather(h *HNSW, query point) {
+	b.nodes = h.snapshotNodes(b.ids, b.nodes[:0])
+	if cap(b.dists) < len(b.nodes) {
+		b.dists = make([]float32, len(b.nodes))
+	}
+	b.dists = b.dists[:len(b.nodes)]
+	b.rows = h.distBatch(query, b.nodes, b.rows, b.dists)
 }
 
+var scratchPool = newCountedPool("scratch", func() *batchScratch {
+	return &batchScratch{
+		ids:   make([]uint64, 0, 64),
+		nodes: make([]*Node, 0, 64),
+		rows:  make([]int, 0, 64),
+		dists: make([]float32, 0, 64),
+	}
+})
+
 // ---------------------------
 // searchLayer (rewirte using typed heaps)
 // ---------------------------
 
 // searchLayer performs a greedy graph traversal at a specific layer.
-// Returns a bounded max-heap of the best 'ef' nodes found.
-func (h *HNSW) searchLayer(query vec.Vector, entryPointIDs []uint64, ef int, layer int) *maxBoundedPQ {
+// Returns a bounded max-heap of the best 'ef' nodes found; the caller puts
+// it back into resultPool. The work done is added to st. If ctx is done
+// the traversal stops and the nodes found so far are returned with ctx.Err().
+// With liveOnly, deleted nodes are traversed but left out of the result.
+func (h *HNSW) searchLayer(ctx context.Context, query point, entryPointIDs []uint64, ef int, layer int, liveOnly bool, st *SearchStats) (*maxBoundedPQ, error) {
 	// Acquire candidate queue from pool and reset it.
-	cp := candidatePool.Get().(*minPQ)
+	cp := candidatePool.get()
 	cp.Reset()
 
 	// Acquire visited map from pool and reset it.
-	vp := visitedPool.Get().(*map[uint64]bool)
+	vp := visitedPool.get()
 	visited := *vp
 	for k := range visited {
 		delete(visited, k)
 	}
 
 	// Acquire result PQ from pool and reset with capacity ef
-	rp := resultPool.Get().(*maxBoundedPQ)
+	rp := resultPool.get()
 	rp.Reset(ef)
 
-	for _, epID := range entryPointIDs {
-		node := h.nodeByID(epID)
-		if node == nil {
-			continue
-		}
+	sp := scratchPool.get()
+	sp.reset()
 
-		dist := h.dist(query, node.vec)
-		visited[epID] = true
+	sp.ids = append(sp.ids, entryPointIDs...)
+	sp.gather(h, query)
+	st.Distances += len(sp.nodes)
+	for i, node := range sp.nodes {
+		visited[node.id] = true
 
-		c := candidate{id: epID, dist: dist}
+		c := candidate{id: node.id, dist: sp.dists[i]}
 		cp.Push(c)
-		rp.Push(c)
+		if !liveOnly || !node.deleted.Load() {
+			rp.Push(c)
+		}
 	}
 
-	for cp.Len() > 0 {
+	done := ctx.Done()
+	var err error
+	for expanded := 0; cp.Len() > 0; expanded++ {
+		if done != nil && expanded%checkEvery == 0 && stopped(done) {
+			err = ctx.Err()
+			break
+		}
+
 		// Explore the closest candidate first
 		curr := cp.Pop()
 
@@ -289,32 +328,39 @@ func (h *HNSW) searchLayer(query vec.Vector, entryPointIDs []uint64, ef int, lay
 			continue
 		}
 
+		// Collect the unvisited neighbors, then score them in one batch.
+		sp.reset()
 		currNode.mu.RLock()
 		if layer >= len(currNode.neighbors) {
 			currNode.mu.RUnlock()
 			continue
 		}
-		neighbors := make([]uint64, len(currNode.neighbors[layer]))
-		copy(neighbors, currNode.neighbors[layer])
+		for _, neighborID := range currNode.neighbors[layer] {
+			if !visited[neighborID] {
+				visited[neighborID] = true
+				sp.ids = append(sp.ids, neighborID)
+			}
+		}
 		currNode.mu.RUnlock()
 
-		neighborNodes := h.snapshotNodes(neighbors)
+		if len(sp.ids) == 0 {
+			continue
+		}
+		sp.gather(h, query)
+		st.Distances += len(sp.nodes)
 
-		for _, neighborNode := range neighborNodes {
+		for i, neighborNode := range sp.nodes {
 			neighborID := neighborNode.id
-			if visited[neighborID] {
-				continue
-			}
-			visited[neighborID] = true
-
-			d := h.dist(query, neighborNode.vec)
+			d := sp.dists[i]
 
-			if rp.Len() < ef {
+			keep := rp.Len() < ef
+			if !keep {
+				root, ok := rp.Peek()
+				keep = ok && d < root.dist
+			}
+			if keep {
 				cp.Push(candidate{id: neighborID, dist: d})
-				rp.Push(candidate{id: neighborID, dist: d})
-			} else {
-				if root, ok := rp.Peek(); ok && d < root.dist {
-					cp.Push(candidate{id: neighborID, dist: d})
+				if !liveOnly || !neighborNode.deleted.Load() {
 					rp.Push(candidate{id: neighborID, dist: d})
 				}
 			}
@@ -322,12 +368,16 @@ func (h *HNSW) searchLayer(query vec.Vector, entryPointIDs []uint64, ef int, lay
 	}
 
 	cp.Reset()
-	candidatePool.Put(cp)
+	candidatePool.put(cp)
+
+	sp.reset()
+	scratchPool.put(sp)
 
+	st.Visited += len(visited)
 	for k := range visited {
 		delete(visited, k)
 	}
-	visitedPool.Put(&visited)
+	visitedPool.put(&visited)
 
-	return rp
+	return rp, err
 }
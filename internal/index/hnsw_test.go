@@ -1,8 +1,11 @@
 package index
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"math/rand"
+	"reflect"
 	"sync"
 	"testing"
 
@@ -47,11 +50,10 @@ func TestHNSW_Sanity(t *testing.T) {
 	internalID := idx.idToInternal[targetID]
 	idx.globalLock.RUnlock()
 
-	targetVecNode := idx.nodeByID(internalID)
-	if targetVecNode == nil {
+	targetVec := idx.vectorOf(internalID)
+	if targetVec == nil {
 		t.Fatalf("internal node not found for %s", targetID)
 	}
-	targetVec := targetVecNode.vec
 
 	results, err := idx.Search(targetVec, 5)
 	if err != nil {
@@ -216,3 +218,301 @@ func BenchmarkHNSW_Search(b *testing.B) {
 		idx.Search(query, 10)
 	}
 }
+
+func TestHNSW_HalfPrecision(t *testing.T) {
+	for _, p := range []Precision{PrecisionFloat16, PrecisionBFloat16} {
+		t.Run(p.String(), func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Precision = p
+			idx := NewHNSW(cfg)
+
+			dim := 64
+			data := make([]vec.Vector, 200)
+			for i := range data {
+				data[i] = randomVec(dim)
+				if err := idx.Insert(fmt.Sprintf("vec_%d", i), data[i]); err != nil {
+					t.Fatalf("Insert failed: %v", err)
+				}
+			}
+
+			results, err := idx.Search(data[42], 1)
+			if err != nil {
+				t.Fatalf("Search failed: %v", err)
+			}
+			if len(results) == 0 || results[0].ID != "vec_42" {
+				t.Fatalf("expected vec_42 as top result, got %v", results)
+			}
+			if results[0].Score < 0.99 {
+				t.Errorf("Top result score should be ~1.0, got %f", results[0].Score)
+			}
+		})
+	}
+}
+
+func randomBits(words int) vec.BitVector {
+	v := make(vec.BitVector, words)
+	for i := range v {
+		v[i] = rand.Uint64()
+	}
+	return v
+}
+
+func TestHNSW_Binary(t *testing.T) {
+	for _, m := range []Metric{MetricHamming, MetricJaccard} {
+		t.Run(m.String(), func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Metric = m
+			idx := NewHNSW(cfg)
+			naive := NewNaiveIndexWithMetric(m)
+
+			data := make([]vec.BitVector, 500)
+			for i := range data {
+				data[i] = randomBits(4)
+				id := fmt.Sprintf("bits_%d", i)
+				if err := idx.InsertBinary(id, data[i], 256); err != nil {
+					t.Fatalf("InsertBinary failed: %v", err)
+				}
+				naive.InsertBinary(id, data[i], 256)
+			}
+
+			results, err := idx.SearchBinary(data[7], 10)
+			if err != nil {
+				t.Fatalf("SearchBinary failed: %v", err)
+			}
+			if results[0].ID != "bits_7" || results[0].Score != 1 {
+				t.Fatalf("expected exact match bits_7 first, got %v", results[0])
+			}
+
+			truth, _ := naive.SearchBinary(data[7], 10)
+			truthMap := make(map[string]bool)
+			for _, r := range truth {
+				truthMap[r.ID] = true
+			}
+			matches := 0
+			for _, r := range results {
+				if truthMap[r.ID] {
+					matches++
+				}
+			}
+			if matches < 8 {
+				t.Errorf("Recall too low: %d/10", matches)
+			}
+
+			if err := idx.Insert("dense", randomVec(8)); err == nil {
+				t.Error("expected dense insert into binary index to fail")
+			}
+			if err := idx.InsertBinary("short", randomBits(2), 128); err == nil {
+				t.Error("expected length mismatch error")
+			}
+		})
+	}
+}
+
+// Hamming scores are normalized by the inserted bit length, not the word
+// capacity, and a snapshot keeps that length.
+func TestHNSW_BitLen(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Metric = MetricHamming
+	idx := NewHNSW(cfg)
+
+	a := vec.BitVectorFromBytes([]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12})
+	b := append(vec.BitVector(nil), a...)
+	b.Set(95)
+	if err := idx.InsertBinary("a", a, 96); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.InsertBinary("b", b, 96); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.InsertBinary("c", a, 128); err == nil {
+		t.Error("expected a 128-bit vector to be rejected by a 96-bit index")
+	}
+	over := append(vec.BitVector(nil), a...)
+	over.Set(100)
+	if err := idx.InsertBinary("d", over, 96); err == nil {
+		t.Error("expected a bit set past the length to be rejected")
+	}
+
+	check := func(idx *HNSW) {
+		t.Helper()
+		if n := idx.BitLen(); n != 96 {
+			t.Errorf("BitLen() = %d, want 96", n)
+		}
+		results, err := idx.SearchBinary(a, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 2 || results[1].ID != "b" || results[1].Score != 1-1.0/96 {
+			t.Errorf("SearchBinary() = %v, want b at %v", results, 1-1.0/96)
+		}
+	}
+	check(idx)
+
+	var buf bytes.Buffer
+	if err := idx.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadHNSW(&buf, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(loaded)
+}
+
+func TestHNSW_Delete(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.M = 8
+	cfg.EfSearch = 100
+	idx := NewHNSW(cfg)
+	dim := 16
+	vecs := make([]vec.Vector, 300)
+	for i := range vecs {
+		vecs[i] = randomVec(dim)
+		idx.Insert(fmt.Sprintf("v%d", i), vecs[i])
+	}
+
+	// Delete every other vector; none may come back, and the rest must
+	// still be found through the tombstones.
+	for i := 0; i < len(vecs); i += 2 {
+		if !idx.Delete(fmt.Sprintf("v%d", i)) {
+			t.Fatalf("Delete(v%d) = false", i)
+		}
+	}
+	if idx.Delete("v0") {
+		t.Error("second Delete reported the id present")
+	}
+	if st := idx.Stats(); st.Nodes != 150 || st.Deleted != 150 || idx.Len() != 150 {
+		t.Errorf("after deletes: Stats %d live %d deleted, Len %d", st.Nodes, st.Deleted, idx.Len())
+	}
+
+	for i := 1; i < len(vecs); i += 2 {
+		res, _ := idx.Search(vecs[i], 10)
+		if len(res) != 10 {
+			t.Fatalf("search returned %d results, want 10", len(res))
+		}
+		for _, m := range res {
+			var n int
+			fmt.Sscanf(m.ID, "v%d", &n)
+			if n%2 == 0 {
+				t.Fatalf("deleted vector %s returned", m.ID)
+			}
+		}
+		if res[0].ID != fmt.Sprintf("v%d", i) {
+			t.Errorf("live vector v%d not found first, got %s", i, res[0].ID)
+		}
+	}
+
+	if _, ok := idx.Vector("v0"); ok {
+		t.Error("Vector returned a deleted id")
+	}
+	if err := idx.Insert("v0", vecs[0]); err != nil {
+		t.Fatalf("re-insert after delete: %v", err)
+	}
+	if res, _ := idx.Search(vecs[0], 1); len(res) == 0 || res[0].ID != "v0" {
+		t.Errorf("re-inserted vector not found: %v", res)
+	}
+
+	// Tombstones survive a snapshot.
+	var buf bytes.Buffer
+	if err := idx.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadHNSW(&buf, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st := loaded.Stats(); st.Nodes != 151 || st.Deleted != 150 {
+		t.Errorf("after reload: %d live, %d deleted", st.Nodes, st.Deleted)
+	}
+	if loaded.Has("v2") || !loaded.Has("v0") {
+		t.Error("reloaded index has the wrong ids")
+	}
+}
+
+func TestHNSW_SeedReproducible(t *testing.T) {
+	vs := make([]vec.Vector, 1000)
+	for i := range vs {
+		vs[i] = randomVec(16)
+	}
+	build := func(seed uint64) []byte {
+		cfg := DefaultConfig()
+		cfg.Seed = seed
+		idx := NewHNSW(cfg)
+		for i, v := range vs {
+			if err := idx.Insert(fmt.Sprintf("v%d", i), v); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var buf bytes.Buffer
+		if err := idx.Save(&buf); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	if !bytes.Equal(build(7), build(7)) {
+		t.Error("two single-threaded builds with the same seed differ")
+	}
+	if bytes.Equal(build(7), build(8)) {
+		t.Error("builds with different seeds are identical")
+	}
+}
+
+// Stats keeps its counts as the index changes; they must match a walk of
+// the graph.
+func TestHNSW_Stats(t *testing.T) {
+	walk := func(h *HNSW) (live, deleted int, layers []int) {
+		if h.maxLevel >= 0 {
+			layers = make([]int, h.maxLevel+1)
+		}
+		for _, n := range h.nodes {
+			if n == nil {
+				continue
+			}
+			if n.deleted.Load() {
+				deleted++
+			} else {
+				live++
+			}
+			for l := 0; l <= n.level; l++ {
+				layers[l]++
+			}
+		}
+		return live, deleted, layers
+	}
+	check := func(name string, h *HNSW) {
+		t.Helper()
+		st := h.Stats()
+		live, deleted, layers := walk(h)
+		if st.Nodes != live || st.Deleted != deleted || !reflect.DeepEqual(st.LayerNodes, layers) {
+			t.Errorf("%s: Stats %d live, %d deleted, layers %v; graph has %d, %d, %v",
+				name, st.Nodes, st.Deleted, st.LayerNodes, live, deleted, layers)
+		}
+	}
+
+	cfg := DefaultConfig()
+	cfg.M = 4 // more layers
+	idx := NewHNSW(cfg)
+	check("empty", idx)
+	for i := 0; i < 400; i++ {
+		idx.Insert(fmt.Sprintf("v%d", i), randomVec(8))
+	}
+	for i := 0; i < 400; i += 3 {
+		idx.Delete(fmt.Sprintf("v%d", i))
+	}
+	idx.InsertContext(&cancelOnSecondCheck{Context: context.Background()}, "withdrawn", randomVec(8))
+	check("after deletes", idx)
+
+	var buf bytes.Buffer
+	if err := idx.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadHNSW(&buf, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("loaded", loaded)
+	if a, b := idx.Stats(), loaded.Stats(); !reflect.DeepEqual(a.LayerNodes, b.LayerNodes) || a.Deleted != b.Deleted {
+		t.Errorf("Stats differ after reload: %+v vs %+v", a, b)
+	}
+}
@@ -1,6 +1,10 @@
 package index
 
-import "github.com/sandeep89846/nebuladb/pkg/vec"
+import (
+	"fmt"
+
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
 
 type Match struct {
 	ID    string
@@ -13,3 +17,41 @@ type VectorIndex interface {
 	Insert(id string, v vec.Vector) error
 	Search(query vec.Vector, k int) ([]Match, error)
 }
+
+// Metric is the distance function an index ranks by. It also decides the
+// vector type: cosine indexes hold dense float vectors, Hamming and Jaccard
+// indexes hold packed vec.BitVectors.
+type Metric int
+
+const (
+	MetricCosine  Metric = iota // dense vectors, 1 - cosine similarity (default)
+	MetricHamming               // binary vectors, differing bits / bit length
+	MetricJaccard               // binary vectors, 1 - |a AND b| / |a OR b|
+)
+
+// Binary reports whether the metric works on bit vectors.
+func (m Metric) Binary() bool {
+	return m == MetricHamming || m == MetricJaccard
+}
+
+func (m Metric) String() string {
+	switch m {
+	case MetricCosine:
+		return "cosine"
+	case MetricHamming:
+		return "hamming"
+	case MetricJaccard:
+		return "jaccard"
+	}
+	return fmt.Sprintf("Metric(%d)", int(m))
+}
+
+// ParseMetric is the inverse of Metric.String.
+func ParseMetric(s string) (Metric, error) {
+	for _, m := range []Metric{MetricCosine, MetricHamming, MetricJaccard} {
+		if m.String() == s {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown metric %q", s)
+}
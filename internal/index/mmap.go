@@ -0,0 +1,37 @@
+package index
+
+import (
+	"os"
+	"sync"
+	"unsafe"
+)
+
+// mmapChunkBytes is the target size of one chunk of a file-backed arena.
+// Few large mappings keep the process far below the kernel's limit on
+// mappings, while the file still grows in steps small next to the data.
+// A variable so tests can cross chunk boundaries cheaply.
+var mmapChunkBytes = 64 << 20
+
+// mmapFile is the scratch file behind a file-backed arena. It is unlinked
+// as soon as it is created, so nothing is left behind however the process
+// exits: the snapshot and the WAL stay the durable copy of the vectors, and
+// the file is refilled from them at startup.
+type mmapFile struct {
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+	maps [][]byte
+
+	// users is held for reading while mapped memory is read, and for
+	// writing by close, so nothing is unmapped under a search or prefetch.
+	users sync.RWMutex
+}
+
+// mapChunk grows f by room for n elements and returns the new region as R.
+func mapChunk[R ~[]E, E any](f *mmapFile, n int) (R, error) {
+	b, err := f.grow(n * elemSize[E]())
+	if err != nil {
+		return nil, err
+	}
+	return R(unsafe.Slice((*E)(unsafe.Pointer(unsafe.SliceData(b))), n)), nil
+}
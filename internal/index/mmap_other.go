@@ -0,0 +1,17 @@
+//go:build !unix
+
+package index
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+)
+
+var errNoMmap = fmt.Errorf("memory-mapped vector storage is not supported on %s", runtime.GOOS)
+
+func openMmapFile(dir string) (*mmapFile, error) { return nil, errNoMmap }
+
+func (m *mmapFile) grow(n int) ([]byte, error)         { return nil, errNoMmap }
+func (m *mmapFile) prefetch(ctx context.Context) error { return errNoMmap }
+func (m *mmapFile) close() error                       { return errNoMmap }
@@ -0,0 +1,118 @@
+//go:build unix
+
+package index
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestHNSW_MmapVectors(t *testing.T) {
+	defer func(n int) { mmapChunkBytes = n }(mmapChunkBytes)
+	mmapChunkBytes = 10000 // a few hundred rows per chunk
+
+	for _, p := range []Precision{PrecisionFloat32, PrecisionFloat16} {
+		t.Run(p.String(), func(t *testing.T) {
+			dir := t.TempDir()
+			cfg := DefaultConfig()
+			cfg.Precision = p
+			heap := NewHNSW(cfg)
+			cfg.VectorDir = dir
+			mapped := NewHNSW(cfg)
+			defer mapped.Close()
+
+			for i := 0; i < 1000; i++ {
+				v := randomVec(32)
+				heap.Insert(fmt.Sprintf("v%d", i), v)
+				if err := mapped.Insert(fmt.Sprintf("v%d", i), v); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+				t.Errorf("vector file left in the directory: %v", entries)
+			}
+			if err := mapped.Prefetch(context.Background()); err != nil {
+				t.Fatalf("Prefetch: %v", err)
+			}
+
+			// same seed, same inserts: the storage must not change the graph
+			var hb, mb bytes.Buffer
+			heap.Save(&hb)
+			mapped.Save(&mb)
+			if !bytes.Equal(hb.Bytes(), mb.Bytes()) {
+				t.Fatal("mapped and heap indexes differ")
+			}
+
+			loaded, err := LoadHNSW(bytes.NewReader(mb.Bytes()), cfg)
+			if err != nil {
+				t.Fatalf("LoadHNSW: %v", err)
+			}
+			defer loaded.Close()
+			for i := 0; i < 20; i++ {
+				q := randomVec(32)
+				want, _ := heap.Search(q, 10)
+				got, _ := loaded.Search(q, 10)
+				if !reflect.DeepEqual(want, got) {
+					t.Fatalf("results differ:\nwant %v\ngot  %v", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestHNSW_MmapBadDir(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.VectorDir = "/nonexistent/dir"
+	idx := NewHNSW(cfg)
+	if err := idx.Insert("a", randomVec(4)); err == nil {
+		t.Fatal("expected an error for a missing vector directory")
+	}
+	if idx.Has("a") || idx.Len() != 0 {
+		t.Error("failed insert left the id behind")
+	}
+}
+
+// Close waits for the searches and prefetches reading the vector file, so
+// none of them touches unmapped memory. Run with -race.
+func TestHNSW_MmapCloseWhileBusy(t *testing.T) {
+	defer func(n int) { mmapChunkBytes = n }(mmapChunkBytes)
+	mmapChunkBytes = 10000
+
+	cfg := DefaultConfig()
+	idxs := make([]*HNSW, 2)
+	for i := range idxs {
+		cfg.VectorDir = t.TempDir()
+		idxs[i] = NewHNSW(cfg)
+		for j := 0; j < 500; j++ {
+			if err := idxs[i].Insert(fmt.Sprintf("v%d", j), randomVec(32)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, idx := range idxs {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			idx.Prefetch(context.Background())
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				idx.Search(randomVec(32), 10)
+			}
+		}()
+	}
+	for _, idx := range idxs {
+		if err := idx.Close(); err != nil {
+			t.Errorf("Close: %v", err)
+		}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,87 @@
+//go:build unix
+
+package index
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"syscall"
+)
+
+func openMmapFile(dir string) (*mmapFile, error) {
+	f, err := os.CreateTemp(dir, "vectors-*.bin")
+	if err != nil {
+		return nil, fmt.Errorf("vector file: %w", err)
+	}
+	if err := os.Remove(f.Name()); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("vector file: %w", err)
+	}
+	return &mmapFile{f: f}, nil
+}
+
+// grow extends the file by n bytes, rounded up to whole pages, and maps
+// the new region.
+func (m *mmapFile) grow(n int) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	page := os.Getpagesize()
+	n = (n + page - 1) / page * page
+	if err := m.f.Truncate(m.size + int64(n)); err != nil {
+		return nil, fmt.Errorf("vector file: %w", err)
+	}
+	b, err := syscall.Mmap(int(m.f.Fd()), m.size, n, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("vector file: mmap: %w", err)
+	}
+	m.size += int64(n)
+	m.maps = append(m.maps, b)
+	return b, nil
+}
+
+// prefetch reads one byte of every page, in file order, so the kernel
+// pages the file in with readahead instead of one fault per search hop.
+func (m *mmapFile) prefetch(ctx context.Context) error {
+	m.users.RLock()
+	defer m.users.RUnlock()
+	m.mu.Lock()
+	maps := m.maps
+	m.mu.Unlock()
+	page := os.Getpagesize()
+	var sum byte
+	for _, b := range maps {
+		for off := 0; off < len(b); off += page {
+			if off%(1024*page) == 0 {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+			}
+			sum += b[off]
+		}
+	}
+	// Keep the reads from being optimized away.
+	runtime.KeepAlive(sum)
+	return nil
+}
+
+// close waits for the readers of the mapped memory, then unmaps it and
+// closes the file.
+func (m *mmapFile) close() error {
+	m.users.Lock()
+	defer m.users.Unlock()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var first error
+	for _, b := range m.maps {
+		if err := syscall.Munmap(b); err != nil && first == nil {
+			first = err
+		}
+	}
+	m.maps = nil
+	if err := m.f.Close(); err != nil && first == nil {
+		first = err
+	}
+	return first
+}
@@ -2,19 +2,41 @@ package index
 
 import (
 	"container/heap"
+	"fmt"
 	"sync"
 
 	"github.com/sandeep89846/nebuladb/pkg/vec"
 )
 
+// NaiveIndex is an exact brute-force index. Vectors live in one contiguous
+// matrix so a search is a single batch kernel sweep.
 type NaiveIndex struct {
-	store map[string]vec.Vector
-	mu    sync.RWMutex
+	metric Metric
+	ids    []string       // row -> external id
+	rows   map[string]int // external id -> row
+	data   vec.Matrix
+	norms  []float32 // per-row magnitude, cached at insert
+
+	// binary indexes only: rows of `words` uint64s, back to back, each
+	// bitLen bits long
+	bits   vec.BitVector
+	words  int
+	bitLen int
+
+	mu sync.RWMutex
 }
 
+// NewNaiveIndex returns a cosine brute-force index over dense vectors.
 func NewNaiveIndex() *NaiveIndex {
+	return NewNaiveIndexWithMetric(MetricCosine)
+}
+
+// NewNaiveIndexWithMetric returns a brute-force index ranking by m.
+// Hamming and Jaccard indexes take bit vectors via InsertBinary.
+func NewNaiveIndexWithMetric(m Metric) *NaiveIndex {
 	return &NaiveIndex{
-		store: make(map[string]vec.Vector),
+		metric: m,
+		rows:   make(map[string]int),
 	}
 }
 
@@ -22,30 +44,189 @@ func (n *NaiveIndex) Insert(id string, v vec.Vector) error {
 	n.mu.Lock()
 	defer n.mu.Unlock()
 
-	n.store[id] = v
-	return nil
+	if n.metric.Binary() {
+		return fmt.Errorf("index stores binary vectors, use InsertBinary")
+	}
+
+	if len(v) == 0 {
+		return fmt.Errorf("empty vector")
+	}
+	if n.data.Dim == 0 {
+		n.data.Dim = len(v)
+	}
+	if len(v) != n.data.Dim {
+		return fmt.Errorf("vector dimension %d does not match index dimension %d", len(v), n.data.Dim)
+	}
+
+	// overwrite in place
+	if row, ok := n.rows[id]; ok {
+		copy(n.data.Row(row), v)
+		n.norms[row] = vec.Magnitude(v)
+		return nil
+	}
+
+	n.rows[id] = len(n.ids)
+	n.ids = append(n.ids, id)
+	n.norms = append(n.norms, vec.Magnitude(v))
+	return n.data.Append(v)
 }
 
 func (n *NaiveIndex) Search(query vec.Vector, k int) ([]Match, error) {
 	n.mu.RLock() // only allow reads during the process.
 	defer n.mu.RUnlock()
 
+	if n.metric.Binary() {
+		return nil, fmt.Errorf("index stores binary vectors, use SearchBinary")
+	}
+	if len(n.ids) == 0 {
+		return []Match{}, nil
+	}
+
+	qmag := vec.Magnitude(query)
+	if qmag == 0 {
+		return []Match{}, nil
+	}
+
+	// O(n) scan
+	scores := make([]float32, len(n.ids))
+	if err := vec.DotBatch(query, n.data, scores); err != nil {
+		return nil, err
+	}
+
+	return n.topK(scores, qmag, k), nil
+}
+
+// SearchBatch runs several queries at once with a single many-vs-many
+// distance matrix. Results are returned in query order.
+func (n *NaiveIndex) SearchBatch(queries []vec.Vector, k int) ([][]Match, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	if n.metric.Binary() {
+		return nil, fmt.Errorf("index stores binary vectors, use SearchBinary")
+	}
+
+	out := make([][]Match, len(queries))
+	if len(n.ids) == 0 || len(queries) == 0 {
+		for i := range out {
+			out[i] = []Match{}
+		}
+		return out, nil
+	}
+
+	q := vec.Matrix{Dim: n.data.Dim, Data: make([]float32, 0, len(queries)*n.data.Dim)}
+	for _, v := range queries {
+		if err := q.Append(v); err != nil {
+			return nil, err
+		}
+	}
+
+	rows := len(n.ids)
+	scores := make([]float32, len(queries)*rows)
+	if err := vec.DotMatrix(q, n.data, scores); err != nil {
+		return nil, err
+	}
+
+	for i, v := range queries {
+		qmag := vec.Magnitude(v)
+		if qmag == 0 {
+			out[i] = []Match{}
+			continue
+		}
+		out[i] = n.topK(scores[i*rows:(i+1)*rows], qmag, k)
+	}
+	return out, nil
+}
+
+// topK turns raw dot products into cosine scores and keeps the best k.
+// Rows with zero magnitude are skipped.
+func (n *NaiveIndex) topK(dots []float32, qmag float32, k int) []Match {
 	pq := &MatchQueue{}
 	heap.Init(pq)
 
-	// O(n) scan
-	for id, v := range n.store {
-		score, err := vec.CosineSimilarity(query, v)
-		if err != nil {
+	for row, d := range dots {
+		if n.norms[row] == 0 {
 			continue
 		}
-		pq.PushWithLimit(Match{ID: id, Score: score}, k)
+		score := d / (qmag * n.norms[row])
+		pq.PushWithLimit(Match{ID: n.ids[row], Score: score}, k)
 	}
 
 	results := make([]Match, pq.Len())
 	for i := len(results) - 1; i >= 0; i-- {
 		results[i] = heap.Pop(pq).(Match)
 	}
+	return results
+}
+
+// InsertBinary adds (or overwrites) a bit vector, nbits long, in a Hamming
+// or Jaccard index.
+func (n *NaiveIndex) InsertBinary(id string, v vec.BitVector, nbits int) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if !n.metric.Binary() {
+		return fmt.Errorf("index stores dense vectors, use Insert")
+	}
+	if err := checkBits(v, nbits); err != nil {
+		return err
+	}
+	if n.words == 0 {
+		n.words, n.bitLen = len(v), nbits
+	}
+	if nbits != n.bitLen {
+		return fmt.Errorf("bit vector length %d does not match index length %d", nbits, n.bitLen)
+	}
+
+	if row, ok := n.rows[id]; ok {
+		copy(n.bits[row*n.words:], v)
+		return nil
+	}
+
+	n.rows[id] = len(n.ids)
+	n.ids = append(n.ids, id)
+	n.bits = append(n.bits, v...)
+	return nil
+}
+
+// SearchBinary returns the k closest bit vectors to query. Scores are
+// 1 - distance, so an exact match scores 1.
+func (n *NaiveIndex) SearchBinary(query vec.BitVector, k int) ([]Match, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	if !n.metric.Binary() {
+		return nil, fmt.Errorf("index stores dense vectors, use Search")
+	}
+	if len(n.ids) == 0 {
+		return []Match{}, nil
+	}
+
+	rows := make([]vec.BitVector, len(n.ids))
+	for i := range rows {
+		rows[i] = n.bits[i*n.words : (i+1)*n.words]
+	}
+	dists := make([]float32, len(rows))
+
+	var err error
+	if n.metric == MetricJaccard {
+		err = vec.JaccardGather(query, rows, dists)
+	} else {
+		err = vec.HammingGather(query, n.bitLen, rows, dists)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pq := &MatchQueue{}
+	heap.Init(pq)
+	for row, d := range dists {
+		pq.PushWithLimit(Match{ID: n.ids[row], Score: 1 - d}, k)
+	}
 
+	results := make([]Match, pq.Len())
+	for i := len(results) - 1; i >= 0; i-- {
+		results[i] = heap.Pop(pq).(Match)
+	}
 	return results, nil
 }
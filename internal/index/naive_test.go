@@ -24,3 +24,34 @@ func TestNaiveIndex(t *testing.T) {
 		t.Fatalf("expected D,C as neighbors but got %s,%s", results[0].ID, results[1].ID)
 	}
 }
+
+func TestNaiveIndex_SearchBatch(t *testing.T) {
+	idx := NewNaiveIndex()
+
+	idx.Insert("A", vec.Vector{1, 0, 1})
+	idx.Insert("B", vec.Vector{0, 1, 0})
+	idx.Insert("C", vec.Vector{0, 3, 4})
+	idx.Insert("D", vec.Vector{0, 1, 1})
+
+	queries := []vec.Vector{{0, 1, 1}, {1, 0, 0}}
+	results, err := idx.SearchBatch(queries, 2)
+	if err != nil {
+		t.Fatalf("SearchBatch failed: %v", err)
+	}
+
+	for i, q := range queries {
+		want, _ := idx.Search(q, 2)
+		if len(results[i]) != len(want) {
+			t.Fatalf("query %d: expected %d results but got %d", i, len(want), len(results[i]))
+		}
+		for j := range want {
+			if results[i][j].ID != want[j].ID {
+				t.Errorf("query %d rank %d: expected %s but got %s", i, j, want[j].ID, results[i][j].ID)
+			}
+		}
+	}
+
+	if err := idx.Insert("E", vec.Vector{1, 1}); err == nil {
+		t.Error("expected dimension mismatch error")
+	}
+}
@@ -0,0 +1,201 @@
+package index
+
+import (
+	"math/rand/v2"
+	"runtime"
+	"sync"
+
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+// pqCentroids is the number of centroids per subspace, so each subspace
+// of a code is one byte.
+const pqCentroids = 256
+
+// pqTrainIters is the number of k-means rounds per subspace.
+const pqTrainIters = 10
+
+// productQuantizer compresses a vector to one byte per subspace: the
+// dimensions are split into contiguous subspaces and each slice is replaced
+// by the nearest of the subspace's centroids. Vectors are normalized, so
+// the inner product of a query with a code's centroids approximates cosine
+// similarity.
+type productQuantizer struct {
+	dim   int
+	bound []int       // subspace j covers dimensions bound[j]:bound[j+1]
+	cents [][]float32 // per subspace, pqCentroids rows of its width back to back
+}
+
+// pqBounds splits dim dimensions into m subspaces whose widths differ by at
+// most one.
+func pqBounds(dim, m int) []int {
+	bound := make([]int, m+1)
+	for j := range m {
+		bound[j+1] = bound[j] + dim/m
+		if j < dim%m {
+			bound[j+1]++
+		}
+	}
+	return bound
+}
+
+// trainPQ runs k-means in each of m subspaces of train, in parallel. The
+// result depends only on train, m and seed.
+func trainPQ(train []vec.Vector, m int, seed uint64) *productQuantizer {
+	dim := len(train[0])
+	q := &productQuantizer{dim: dim, bound: pqBounds(dim, m), cents: make([][]float32, m)}
+
+	var (
+		next = make(chan int, m)
+		wg   sync.WaitGroup
+	)
+	for j := range m {
+		next <- j
+	}
+	close(next)
+	for range min(m, runtime.GOMAXPROCS(0)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range next {
+				q.cents[j] = kmeans(train, q.bound[j], q.bound[j+1], pqCentroids, rand.New(rand.NewPCG(seed, uint64(j))))
+			}
+		}()
+	}
+	wg.Wait()
+	return q
+}
+
+// kmeans clusters dimensions lo:hi of train into k centroids, seeded from
+// distinct training rows. With fewer rows than centroids the rows repeat,
+// which only wastes centroids.
+func kmeans(train []vec.Vector, lo, hi, k int, rng *rand.Rand) []float32 {
+	w := hi - lo
+	cents := make([]float32, k*w)
+	perm := rng.Perm(len(train))
+	for c := range k {
+		copy(cents[c*w:(c+1)*w], train[perm[c%len(perm)]][lo:hi])
+	}
+
+	sums := make([]float32, k*w)
+	counts := make([]int, k)
+	for range pqTrainIters {
+		clear(sums)
+		clear(counts)
+		for _, v := range train {
+			c := nearestCentroid(cents, v[lo:hi])
+			counts[c]++
+			for d, x := range v[lo:hi] {
+				sums[c*w+d] += x
+			}
+		}
+		for c, n := range counts {
+			if n == 0 {
+				continue // keep an empty cluster where it was
+			}
+			for d := range w {
+				cents[c*w+d] = sums[c*w+d] / float32(n)
+			}
+		}
+	}
+	return cents
+}
+
+// nearestCentroid returns the row of cents closest to x in Euclidean
+// distance.
+func nearestCentroid(cents, x []float32) int {
+	best, bestDist := 0, float32(0)
+	for c := range len(cents) / len(x) {
+		if d := centroidDist(cents, c, x); c == 0 || d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+// centroidDist is the squared Euclidean distance from x to row c of cents.
+func centroidDist(cents []float32, c int, x []float32) float32 {
+	w := len(x)
+	var d float32
+	for i, y := range cents[c*w : (c+1)*w] {
+		diff := x[i] - y
+		d += diff * diff
+	}
+	return d
+}
+
+func (q *productQuantizer) subspaces() int { return len(q.cents) }
+
+// encode writes the code of v into code, one byte per subspace.
+func (q *productQuantizer) encode(v vec.Vector, code []byte) {
+	for j := range q.cents {
+		code[j] = byte(nearestCentroid(q.cents[j], v[q.bound[j]:q.bound[j+1]]))
+	}
+}
+
+// decode writes the vector code stands for, the centroids it picks side
+// by side, into v.
+func (q *productQuantizer) decode(code []byte, v vec.Vector) {
+	for j, c := range code {
+		w := q.bound[j+1] - q.bound[j]
+		copy(v[q.bound[j]:q.bound[j+1]], q.cents[j][int(c)*w:][:w])
+	}
+}
+
+// table precomputes the inner product of each slice of query with every
+// centroid of its subspace, so scoring a code is one lookup per byte.
+func (q *productQuantizer) table(query vec.Vector) []float32 {
+	t := make([]float32, len(q.cents)*pqCentroids)
+	for j, cents := range q.cents {
+		sub := query[q.bound[j]:q.bound[j+1]]
+		w := len(sub)
+		for c := range pqCentroids {
+			var dot float32
+			for i, y := range cents[c*w : (c+1)*w] {
+				dot += sub[i] * y
+			}
+			t[j*pqCentroids+c] = dot
+		}
+	}
+	return t
+}
+
+// pqDistance is the approximate cosine distance between the query a table
+// was built for and the vector behind code.
+func pqDistance(table []float32, code []byte) float32 {
+	var dot float32
+	for j, c := range code {
+		dot += table[j*pqCentroids+int(c)]
+	}
+	return 1 - dot
+}
+
+func (q *productQuantizer) save(s *snapWriter) {
+	s.u32(uint32(q.dim))
+	s.u32(uint32(len(q.cents)))
+	for _, cents := range q.cents {
+		for _, f := range cents {
+			s.f32(f)
+		}
+	}
+}
+
+func loadPQ(s *snapReader) *productQuantizer {
+	dim := s.count(maxSnapshotLen)
+	m := s.count(maxSnapshotLen)
+	if s.err != nil {
+		return nil
+	}
+	if m == 0 || m > dim {
+		s.err = errBadDiskANN("%d PQ subspaces for dimension %d", m, dim)
+		return nil
+	}
+	q := &productQuantizer{dim: dim, bound: pqBounds(dim, m), cents: make([][]float32, m)}
+	for j := range q.cents {
+		q.cents[j] = make([]float32, pqCentroids*(q.bound[j+1]-q.bound[j]))
+		for i := range q.cents[j] {
+			q.cents[j][i] = s.f32()
+		}
+	}
+	return q
+}
@@ -0,0 +1,497 @@
+package index
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+)
+
+// Snapshots serialize an index's in-memory state so a restart can skip
+// rebuilding it from the WAL. All integers are little endian. Writers hold
+// the index read lock for the whole dump, so a snapshot is consistent but
+// blocks inserts while it runs.
+
+// Version history:
+//
+//	1: initial format
+//	2: HNSW slots may hold tombstones (flag 2, no id); sparse and text
+//	   rows carry a live flag so deletes survive a reload
+//	3: dense HNSW slots end with the vector's original magnitude; text
+//	   rows carry their original text
+//	4: the HNSW header carries the index's level seed
+const snapshotVersion = 4
+
+// HNSW slot flags.
+const (
+	slotEmpty     = 0
+	slotLive      = 1
+	slotTombstone = 2
+)
+
+// snapWriter is a little-endian encoder with a sticky error.
+type snapWriter struct {
+	w   *bufio.Writer
+	buf [8]byte
+	err error
+}
+
+func newSnapWriter(w io.Writer) *snapWriter {
+	return &snapWriter{w: bufio.NewWriter(w)}
+}
+
+func (s *snapWriter) write(b []byte) {
+	if s.err == nil {
+		_, s.err = s.w.Write(b)
+	}
+}
+
+func (s *snapWriter) u8(v uint8) { s.buf[0] = v; s.write(s.buf[:1]) }
+
+func (s *snapWriter) u32(v uint32) {
+	binary.LittleEndian.PutUint32(s.buf[:4], v)
+	s.write(s.buf[:4])
+}
+
+func (s *snapWriter) u64(v uint64) {
+	binary.LittleEndian.PutUint64(s.buf[:8], v)
+	s.write(s.buf[:8])
+}
+
+func (s *snapWriter) f32(v float32) { s.u32(math.Float32bits(v)) }
+
+func (s *snapWriter) str(v string) {
+	s.u32(uint32(len(v)))
+	if s.err == nil {
+		_, s.err = s.w.WriteString(v)
+	}
+}
+
+func (s *snapWriter) flush() error {
+	if s.err == nil {
+		s.err = s.w.Flush()
+	}
+	return s.err
+}
+
+// snapReader is the decoding side of snapWriter. It does not buffer, so
+// sections can be read back to back from one stream; callers pass a
+// buffered reader.
+type snapReader struct {
+	r   io.Reader
+	buf [8]byte
+	err error
+}
+
+func newSnapReader(r io.Reader) *snapReader {
+	return &snapReader{r: r}
+}
+
+func (s *snapReader) read(n int) []byte {
+	if s.err != nil {
+		return s.buf[:n]
+	}
+	_, s.err = io.ReadFull(s.r, s.buf[:n])
+	if s.err == io.EOF {
+		s.err = io.ErrUnexpectedEOF
+	}
+	return s.buf[:n]
+}
+
+func (s *snapReader) u8() uint8   { return s.read(1)[0] }
+func (s *snapReader) u32() uint32 { return binary.LittleEndian.Uint32(s.read(4)) }
+func (s *snapReader) u64() uint64 { return binary.LittleEndian.Uint64(s.read(8)) }
+func (s *snapReader) f32() float32 {
+	return math.Float32frombits(s.u32())
+}
+
+// count reads a length prefix and rejects values above limit, so a corrupt
+// file cannot trigger a huge allocation.
+func (s *snapReader) count(limit int) int {
+	n := s.u32()
+	if s.err == nil && int64(n) > int64(limit) {
+		s.err = fmt.Errorf("snapshot: length %d exceeds limit %d", n, limit)
+	}
+	if s.err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+func (s *snapReader) str() string {
+	n := s.count(math.MaxInt32)
+	if s.err != nil {
+		return ""
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(s.r, b); err != nil {
+		s.err = io.ErrUnexpectedEOF
+		return ""
+	}
+	return string(b)
+}
+
+// header writes or checks the section tag and format version.
+func (s *snapWriter) header(tag string) {
+	s.str(tag)
+	s.u32(snapshotVersion)
+}
+
+// header returns the section version; older versions stay readable.
+func (s *snapReader) header(tag string) uint32 {
+	if got := s.str(); s.err == nil && got != tag {
+		s.err = fmt.Errorf("snapshot: expected %s section, found %q", tag, got)
+	}
+	v := s.u32()
+	if s.err == nil && (v == 0 || v > snapshotVersion) {
+		s.err = fmt.Errorf("snapshot: unsupported %s version %d", tag, v)
+	}
+	return v
+}
+
+// maxSnapshotLen caps any single length read from a snapshot.
+const maxSnapshotLen = 1 << 30
+
+// Save writes the graph, the stored vectors and the id mappings to w.
+func (h *HNSW) Save(w io.Writer) error {
+	h.globalLock.RLock()
+	defer h.globalLock.RUnlock()
+
+	s := newSnapWriter(w)
+	s.header("hnsw")
+	s.u8(uint8(h.config.Metric))
+	s.u8(uint8(h.config.Precision))
+	s.u64(h.config.Seed)
+	dim := 0
+	if h.vectors != nil {
+		dim = h.vectors.dimension()
+	}
+	s.u32(uint32(dim))
+	if h.config.Metric.Binary() {
+		s.u32(uint32(h.bitLen))
+	}
+	s.u64(h.nextID)
+	s.u64(h.entryPointID)
+	s.u32(uint32(int32(h.maxLevel)))
+
+	s.u32(uint32(len(h.nodes)))
+	for i, n := range h.nodes {
+		switch {
+		case n == nil:
+			s.u8(slotEmpty)
+			continue
+		case n.deleted.Load():
+			s.u8(slotTombstone)
+		default:
+			s.u8(slotLive)
+			s.str(h.internalToID[n.id])
+		}
+
+		n.mu.RLock()
+		s.u32(uint32(n.level))
+		for _, layer := range n.neighbors {
+			s.u32(uint32(len(layer)))
+			for _, nb := range layer {
+				s.u64(nb)
+			}
+		}
+		n.mu.RUnlock()
+
+		p := h.vectors.get(i)
+		if p.bits != nil {
+			for _, word := range p.bits {
+				s.u64(word)
+			}
+		} else {
+			for _, f := range p.dense {
+				s.f32(f)
+			}
+			s.f32(h.normOf(i))
+		}
+	}
+	return s.flush()
+}
+
+// LoadHNSW reads an index written by Save. cfg supplies the build and search
+// parameters for the loaded index; its Metric and Precision must match the
+// snapshot, since the stored vectors depend on them. The snapshot's Seed
+// replaces cfg's, so later inserts draw the levels they would have drawn
+// without the reload.
+func LoadHNSW(r io.Reader, cfg Config) (*HNSW, error) {
+	s := newSnapReader(r)
+	version := s.header("hnsw")
+	metric := Metric(s.u8())
+	precision := Precision(s.u8())
+	if version >= 4 {
+		cfg.Seed = s.u64()
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	if metric != cfg.Metric || precision != cfg.Precision {
+		return nil, fmt.Errorf("snapshot holds a %s/%s index, configured for %s/%s",
+			metric, precision, cfg.Metric, cfg.Precision)
+	}
+
+	h := NewHNSW(cfg)
+	dim := s.count(maxSnapshotLen)
+	if metric.Binary() {
+		h.bitLen = s.count(64 * dim)
+		if s.err == nil && dim > 0 && h.bitLen <= 64*(dim-1) {
+			s.err = fmt.Errorf("snapshot: %d-bit vectors cannot fill %d words", h.bitLen, dim)
+		}
+	}
+	h.nextID = s.u64()
+	h.entryPointID = s.u64()
+	h.maxLevel = int(int32(s.u32()))
+
+	slots := s.count(maxSnapshotLen)
+	if s.err != nil {
+		return nil, s.err
+	}
+	if dim > 0 {
+		if h.vectors, s.err = newVectorStore(cfg, dim, h.bitLen); s.err != nil {
+			return nil, s.err
+		}
+	}
+	h.nodes = make([]*Node, slots)
+
+	for i := 0; i < slots && s.err == nil; i++ {
+		flag := s.u8()
+		if flag == slotEmpty {
+			continue
+		}
+		if flag > slotTombstone {
+			s.err = fmt.Errorf("snapshot: bad slot flag %d", flag)
+			break
+		}
+		var id string
+		if flag == slotLive {
+			id = s.str()
+		}
+		level := s.count(maxSnapshotLen)
+		if s.err != nil || dim == 0 {
+			break
+		}
+
+		n := &Node{id: uint64(i + 1), level: level, neighbors: make([][]uint64, level+1)}
+		for l := range n.neighbors {
+			layer := make([]uint64, s.count(maxSnapshotLen))
+			for j := range layer {
+				layer[j] = s.u64()
+			}
+			n.neighbors[l] = layer
+		}
+
+		var p point
+		if metric.Binary() {
+			p.bits = make([]uint64, dim)
+			for j := range p.bits {
+				p.bits[j] = s.u64()
+			}
+		} else {
+			p.dense = make([]float32, dim)
+			for j := range p.dense {
+				p.dense[j] = s.f32()
+			}
+			if version >= 3 {
+				h.setNorm(i, s.f32())
+			}
+		}
+		if s.err != nil {
+			break
+		}
+
+		if s.err = h.vectors.set(i, p); s.err != nil {
+			break
+		}
+		h.nodes[i] = n
+		h.countNode(level, 1)
+		if flag == slotTombstone {
+			n.deleted.Store(true)
+			h.tombstones++
+			continue
+		}
+		h.idToInternal[id] = n.id
+		h.internalToID[n.id] = id
+	}
+	if s.err == nil && slots > 0 && dim == 0 {
+		s.err = fmt.Errorf("snapshot: %d nodes but no dimension", slots)
+	}
+	if s.err != nil {
+		h.Close()
+		return nil, s.err
+	}
+	return h, nil
+}
+
+// Save writes the inverted lists and id table to w.
+func (s *SparseIndex) Save(w io.Writer) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Only live rows are written, renumbered as compact would.
+	remap, n := liveRemap(s.ids, s.rows)
+	sw := newSnapWriter(w)
+	sw.header("sparse")
+	sw.u32(uint32(n))
+	for row, id := range s.ids {
+		if remap[row] >= 0 {
+			sw.str(id)
+			sw.u8(1)
+		}
+	}
+
+	// Dimension order is fixed so equal indexes give equal snapshots.
+	dims := make([]uint32, 0, len(s.postings))
+	lists := make(map[uint32][]posting, len(s.postings))
+	for d, list := range s.postings {
+		var live []posting
+		for _, p := range list {
+			if r := remap[p.row]; r >= 0 {
+				live = append(live, posting{row: r, value: p.value})
+			}
+		}
+		if len(live) > 0 {
+			dims = append(dims, d)
+			lists[d] = live
+		}
+	}
+	slices.Sort(dims)
+
+	sw.u32(uint32(len(dims)))
+	for _, d := range dims {
+		list := lists[d]
+		sw.u32(d)
+		sw.u32(uint32(len(list)))
+		for _, p := range list {
+			sw.u32(uint32(p.row))
+			sw.f32(p.value)
+		}
+	}
+	return sw.flush()
+}
+
+// LoadSparseIndex reads an index written by SparseIndex.Save.
+func LoadSparseIndex(r io.Reader) (*SparseIndex, error) {
+	sr := newSnapReader(r)
+	version := sr.header("sparse")
+
+	s := NewSparseIndex()
+	s.ids = make([]string, sr.count(maxSnapshotLen))
+	for row := range s.ids {
+		s.ids[row] = sr.str()
+		if version < 2 || sr.u8() == 1 {
+			s.rows[s.ids[row]] = row // later rows win, as on insert
+		}
+	}
+
+	dims := sr.count(maxSnapshotLen)
+	for i := 0; i < dims && sr.err == nil; i++ {
+		d := sr.u32()
+		list := make([]posting, sr.count(maxSnapshotLen))
+		for j := range list {
+			list[j] = posting{row: int32(sr.u32()), value: sr.f32()}
+			if sr.err == nil && int(list[j].row) >= len(s.ids) {
+				sr.err = fmt.Errorf("snapshot: sparse posting row %d out of range", list[j].row)
+			}
+		}
+		s.postings[d] = list
+	}
+	if sr.err != nil {
+		return nil, sr.err
+	}
+	return s, nil
+}
+
+// Save writes the postings, document lengths and id table to w.
+func (t *TextIndex) Save(w io.Writer) error {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	// Only live rows are written, renumbered as compact would.
+	remap, n := liveRemap(t.ids, t.rows)
+	s := newSnapWriter(w)
+	s.header("text")
+	s.u32(uint32(n))
+	for row, id := range t.ids {
+		if remap[row] >= 0 {
+			s.str(id)
+			s.u32(uint32(t.docLen[row]))
+			s.u8(1)
+			s.str(t.texts[row])
+		}
+	}
+
+	terms := make([]string, 0, len(t.postings))
+	lists := make(map[string][]textPosting, len(t.postings))
+	for term, list := range t.postings {
+		var live []textPosting
+		for _, p := range list {
+			if r := remap[p.row]; r >= 0 {
+				live = append(live, textPosting{row: r, tf: p.tf})
+			}
+		}
+		if len(live) > 0 {
+			terms = append(terms, term)
+			lists[term] = live
+		}
+	}
+	slices.Sort(terms)
+
+	s.u32(uint32(len(terms)))
+	for _, term := range terms {
+		list := lists[term]
+		s.str(term)
+		s.u32(uint32(len(list)))
+		for _, p := range list {
+			s.u32(uint32(p.row))
+			s.u32(uint32(p.tf))
+		}
+	}
+	return s.flush()
+}
+
+// LoadTextIndex reads an index written by TextIndex.Save.
+func LoadTextIndex(r io.Reader) (*TextIndex, error) {
+	s := newSnapReader(r)
+	version := s.header("text")
+
+	t := NewTextIndex()
+	n := s.count(maxSnapshotLen)
+	t.ids = make([]string, n)
+	t.texts = make([]string, n)
+	t.docLen = make([]int32, n)
+	for row := 0; row < n && s.err == nil; row++ {
+		t.ids[row] = s.str()
+		t.docLen[row] = int32(s.u32())
+		if version < 2 || s.u8() == 1 {
+			t.rows[t.ids[row]] = row
+		}
+		if version >= 3 {
+			t.texts[row] = s.str()
+		}
+	}
+	for _, row := range t.rows {
+		t.liveLen += int64(t.docLen[row])
+	}
+
+	terms := s.count(maxSnapshotLen)
+	for i := 0; i < terms && s.err == nil; i++ {
+		term := s.str()
+		list := make([]textPosting, s.count(maxSnapshotLen))
+		for j := range list {
+			list[j] = textPosting{row: int32(s.u32()), tf: int32(s.u32())}
+			if s.err == nil && int(list[j].row) >= n {
+				s.err = fmt.Errorf("snapshot: text posting row %d out of range", list[j].row)
+			}
+		}
+		t.postings[term] = list
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	return t, nil
+}
@@ -0,0 +1,181 @@
+package index
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+func TestHNSW_SaveLoad(t *testing.T) {
+	for _, p := range []Precision{PrecisionFloat32, PrecisionFloat16} {
+		t.Run(p.String(), func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Precision = p
+			idx := NewHNSW(cfg)
+			for i := 0; i < 500; i++ {
+				idx.Insert(fmt.Sprintf("v%d", i), randomVec(32))
+			}
+
+			var buf bytes.Buffer
+			if err := idx.Save(&buf); err != nil {
+				t.Fatalf("Save failed: %v", err)
+			}
+			loaded, err := LoadHNSW(bytes.NewReader(buf.Bytes()), cfg)
+			if err != nil {
+				t.Fatalf("LoadHNSW failed: %v", err)
+			}
+
+			for i := 0; i < 20; i++ {
+				q := randomVec(32)
+				want, _ := idx.Search(q, 10)
+				got, _ := loaded.Search(q, 10)
+				if !reflect.DeepEqual(want, got) {
+					t.Fatalf("results differ after reload:\nwant %v\ngot  %v", want, got)
+				}
+			}
+
+			// the loaded index keeps accepting inserts
+			if err := loaded.Insert("v0", randomVec(32)); err == nil {
+				t.Error("expected duplicate id to be rejected after reload")
+			}
+			if err := loaded.Insert("new", randomVec(32)); err != nil {
+				t.Errorf("insert after reload failed: %v", err)
+			}
+
+			other := cfg
+			other.Metric = MetricHamming
+			if _, err := LoadHNSW(bytes.NewReader(buf.Bytes()), other); err == nil {
+				t.Error("expected metric mismatch error")
+			}
+			if _, err := LoadHNSW(bytes.NewReader(buf.Bytes()[:buf.Len()/2]), cfg); err == nil {
+				t.Error("expected error for truncated snapshot")
+			}
+		})
+	}
+}
+
+// A reload keeps the snapshot's seed, so inserting after it gives the same
+// graph as inserting without it.
+func TestHNSW_SaveLoadKeepsSeed(t *testing.T) {
+	vs := make([]vec.Vector, 600)
+	for i := range vs {
+		vs[i] = randomVec(16)
+	}
+	cfg := DefaultConfig()
+	cfg.Seed = 99
+	save := func(idx *HNSW) []byte {
+		var buf bytes.Buffer
+		if err := idx.Save(&buf); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	straight, split := NewHNSW(cfg), NewHNSW(cfg)
+	for i, v := range vs {
+		straight.Insert(fmt.Sprintf("v%d", i), v)
+		if i < len(vs)/2 {
+			split.Insert(fmt.Sprintf("v%d", i), v)
+		}
+	}
+	other := cfg
+	other.Seed = 1
+	loaded, err := LoadHNSW(bytes.NewReader(save(split)), other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.config.Seed != cfg.Seed {
+		t.Fatalf("loaded seed = %d, want %d", loaded.config.Seed, cfg.Seed)
+	}
+	for i := len(vs) / 2; i < len(vs); i++ {
+		loaded.Insert(fmt.Sprintf("v%d", i), vs[i])
+	}
+	if !bytes.Equal(save(straight), save(loaded)) {
+		t.Error("inserting across a reload gave a different graph")
+	}
+}
+
+func TestSparseAndText_SaveLoad(t *testing.T) {
+	sp := NewSparseIndex()
+	sp.InsertSparse("A", sparse(t, []uint32{1, 100}, []float32{1, 1}))
+	sp.InsertSparse("B", sparse(t, []uint32{100}, []float32{2}))
+	sp.InsertSparse("B", sparse(t, []uint32{7}, []float32{1})) // overwrite
+
+	txt := NewTextIndex()
+	txt.Insert("A", "the quick brown fox")
+	txt.Insert("B", "lazy brown dog")
+	txt.Insert("C", "quick quick fox")
+
+	// both sections back to back, as in a snapshot file
+	var buf bytes.Buffer
+	if err := sp.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := txt.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	r := bytes.NewReader(buf.Bytes())
+	sp2, err := LoadSparseIndex(r)
+	if err != nil {
+		t.Fatalf("LoadSparseIndex failed: %v", err)
+	}
+	txt2, err := LoadTextIndex(r)
+	if err != nil {
+		t.Fatalf("LoadTextIndex failed: %v", err)
+	}
+
+	q := sparse(t, []uint32{7, 100}, []float32{1, 1})
+	want, _ := sp.SearchSparse(q, 5)
+	got, _ := sp2.SearchSparse(q, 5)
+	if !reflect.DeepEqual(want, got) || sp2.Len() != 2 {
+		t.Errorf("sparse results differ after reload: want %v, got %v", want, got)
+	}
+
+	wantT, _ := txt.Search("quick fox", 5)
+	gotT, _ := txt2.Search("quick fox", 5)
+	if !reflect.DeepEqual(wantT, gotT) {
+		t.Errorf("text results differ after reload: want %v, got %v", wantT, gotT)
+	}
+}
+
+func TestSparseAndText_DeleteSaveLoad(t *testing.T) {
+	sp := NewSparseIndex()
+	sp.InsertSparse("A", sparse(t, []uint32{1}, []float32{1}))
+	sp.InsertSparse("B", sparse(t, []uint32{1}, []float32{2}))
+	txt := NewTextIndex()
+	txt.Insert("A", "brown fox")
+	txt.Insert("B", "brown dog")
+
+	if !sp.Delete("A") || !txt.Delete("A") || sp.Delete("A") {
+		t.Fatal("Delete reported the wrong presence")
+	}
+
+	var buf bytes.Buffer
+	sp.Save(&buf)
+	txt.Save(&buf)
+	sp2, err := LoadSparseIndex(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txt2, err := LoadTextIndex(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []*SparseIndex{sp, sp2} {
+		res, _ := s.SearchSparse(sparse(t, []uint32{1}, []float32{1}), 5)
+		if len(res) != 1 || res[0].ID != "B" || s.Has("A") {
+			t.Errorf("sparse search after delete = %v", res)
+		}
+	}
+	for _, x := range []*TextIndex{txt, txt2} {
+		res, _ := x.Search("brown", 5)
+		if len(res) != 1 || res[0].ID != "B" || x.Has("A") {
+			t.Errorf("text search after delete = %v", res)
+		}
+	}
+}
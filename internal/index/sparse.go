@@ -0,0 +1,212 @@
+package index
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+// posting is one entry of an inverted list: the row holding a non-zero
+// weight for the list's dimension.
+type posting struct {
+	row   int32
+	value float32
+}
+
+// compactMinDead is the number of dead rows, overwritten or deleted, below
+// which an inverted index is never compacted. Past it, the index compacts
+// once dead rows outnumber live ones.
+const compactMinDead = 1024
+
+// needsCompact reports whether an index with the given row counts is due
+// for compaction. Each compaction drops at least as many rows as remain,
+// so its cost is amortized over the updates that made them dead.
+func needsCompact(rows, live int) bool {
+	dead := rows - live
+	return dead >= compactMinDead && dead > live
+}
+
+// liveRemap maps every row of ids to its number once dead rows are dropped,
+// or -1 for a dead row, and returns the number of live rows. A row is live
+// when rows maps its id back to it.
+func liveRemap(ids []string, rows map[string]int) ([]int32, int) {
+	remap := make([]int32, len(ids))
+	n := int32(0)
+	for row, id := range ids {
+		if live, ok := rows[id]; ok && live == row {
+			remap[row] = n
+			n++
+		} else {
+			remap[row] = -1
+		}
+	}
+	return remap, int(n)
+}
+
+// SparseIndex is an inverted index over sparse vectors, ranked by dot
+// product. It is exact: every posting list touched by the query is scanned.
+// The postings of overwritten and deleted rows are skipped at query time
+// and dropped when the index compacts.
+type SparseIndex struct {
+	postings map[uint32][]posting
+	ids      []string       // row -> external id
+	rows     map[string]int // external id -> live row
+	mu       sync.RWMutex
+}
+
+func NewSparseIndex() *SparseIndex {
+	return &SparseIndex{
+		postings: make(map[uint32][]posting),
+		rows:     make(map[string]int),
+	}
+}
+
+// Insert implements VectorIndex by treating the non-zero entries of v as a
+// sparse vector.
+func (s *SparseIndex) Insert(id string, v vec.Vector) error {
+	return s.InsertSparse(id, vec.SparseFromDense(v))
+}
+
+// Search implements VectorIndex; see Insert.
+func (s *SparseIndex) Search(query vec.Vector, k int) ([]Match, error) {
+	return s.SearchSparse(vec.SparseFromDense(query), k)
+}
+
+// InsertSparse adds v under id. Re-inserting an id replaces the old vector.
+func (s *SparseIndex) InsertSparse(id string, v vec.SparseVector) error {
+	if v.Len() == 0 {
+		return fmt.Errorf("empty sparse vector")
+	}
+	if len(v.Indices) != len(v.Values) {
+		return vec.ErrSparseLength
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// On overwrite the old postings stay behind until the next compaction;
+	// rows no longer referenced from s.rows are skipped at query time.
+	row := int32(len(s.ids))
+	s.ids = append(s.ids, id)
+	s.rows[id] = int(row)
+
+	for i, dim := range v.Indices {
+		s.postings[dim] = append(s.postings[dim], posting{row: row, value: v.Values[i]})
+	}
+	s.maybeCompact()
+	return nil
+}
+
+// maybeCompact compacts the index when needsCompact says so. Callers hold
+// s.mu.
+func (s *SparseIndex) maybeCompact() {
+	if needsCompact(len(s.ids), len(s.rows)) {
+		s.compact()
+	}
+}
+
+// compact drops dead rows and their postings and renumbers the live rows
+// in order. Callers hold s.mu.
+func (s *SparseIndex) compact() {
+	remap, n := liveRemap(s.ids, s.rows)
+	ids := make([]string, 0, n)
+	for row, id := range s.ids {
+		if remap[row] >= 0 {
+			ids = append(ids, id)
+			s.rows[id] = int(remap[row])
+		}
+	}
+	s.ids = ids
+	for dim, list := range s.postings {
+		out := list[:0]
+		for _, p := range list {
+			if r := remap[p.row]; r >= 0 {
+				out = append(out, posting{row: r, value: p.value})
+			}
+		}
+		if len(out) == 0 {
+			delete(s.postings, dim)
+		} else {
+			s.postings[dim] = out
+		}
+	}
+}
+
+// SearchSparse returns the k rows with the highest dot product against
+// query. Rows sharing no dimension with the query are never returned.
+func (s *SparseIndex) SearchSparse(query vec.SparseVector, k int) ([]Match, error) {
+	return s.SearchSparseContext(context.Background(), query, k)
+}
+
+// SearchSparseContext is SearchSparse that stops when ctx is done, checked
+// between posting lists.
+func (s *SparseIndex) SearchSparseContext(ctx context.Context, query vec.SparseVector, k int) ([]Match, error) {
+	if query.Len() == 0 {
+		return nil, fmt.Errorf("empty sparse query")
+	}
+	if k < 1 {
+		return nil, fmt.Errorf("k must be at least 1, got %d", k)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Term-at-a-time accumulation.
+	scores := make(map[int32]float32)
+	for i, dim := range query.Indices {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		w := query.Values[i]
+		for _, p := range s.postings[dim] {
+			scores[p.row] += w * p.value
+		}
+	}
+
+	pq := &MatchQueue{}
+	heap.Init(pq)
+	for row, score := range scores {
+		id := s.ids[row]
+		if live, ok := s.rows[id]; !ok || live != int(row) {
+			continue // overwritten or deleted
+		}
+		pq.PushWithLimit(Match{ID: id, Score: score}, k)
+	}
+
+	results := make([]Match, pq.Len())
+	for i := len(results) - 1; i >= 0; i-- {
+		results[i] = heap.Pop(pq).(Match)
+	}
+	return results, nil
+}
+
+// Delete removes id and reports whether it was present. Its postings are
+// skipped at query time like overwritten ones.
+func (s *SparseIndex) Delete(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.rows[id]
+	if ok {
+		delete(s.rows, id)
+		s.maybeCompact()
+	}
+	return ok
+}
+
+// Has reports whether id is stored.
+func (s *SparseIndex) Has(id string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.rows[id]
+	return ok
+}
+
+// Len returns the number of live vectors.
+func (s *SparseIndex) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.rows)
+}
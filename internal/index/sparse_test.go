@@ -0,0 +1,117 @@
+package index
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+func sparse(t *testing.T, idx []uint32, vals []float32) vec.SparseVector {
+	t.Helper()
+	s, err := vec.NewSparseVector(idx, vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestSparseIndex(t *testing.T) {
+	idx := NewSparseIndex()
+
+	idx.InsertSparse("A", sparse(t, []uint32{1, 100}, []float32{1, 1}))
+	idx.InsertSparse("B", sparse(t, []uint32{100, 5000}, []float32{2, 1}))
+	idx.InsertSparse("C", sparse(t, []uint32{7}, []float32{9}))
+
+	results, err := idx.SearchSparse(sparse(t, []uint32{100}, []float32{1}), 5)
+	if err != nil {
+		t.Fatalf("SearchSparse failed: %v", err)
+	}
+	if len(results) != 2 || results[0].ID != "B" || results[1].ID != "A" {
+		t.Fatalf("expected B,A but got %v", results)
+	}
+
+	// overwrite B so it no longer matches dimension 100
+	idx.InsertSparse("B", sparse(t, []uint32{7}, []float32{1}))
+	results, _ = idx.SearchSparse(sparse(t, []uint32{100}, []float32{1}), 5)
+	if len(results) != 1 || results[0].ID != "A" {
+		t.Fatalf("expected only A after overwrite but got %v", results)
+	}
+	if idx.Len() != 3 {
+		t.Errorf("expected 3 live vectors, got %d", idx.Len())
+	}
+
+	// VectorIndex view: dense vectors are treated as sparse
+	var vi VectorIndex = idx
+	results, _ = vi.Search(vec.Vector{0, 0, 0, 0, 0, 0, 0, 1}, 1)
+	if len(results) != 1 || results[0].ID != "C" {
+		t.Fatalf("expected C but got %v", results)
+	}
+}
+
+func TestFuse(t *testing.T) {
+	dense := []Match{{"A", 0.9}, {"B", 0.8}, {"E", 0.1}}
+	sparse := []Match{{"C", 40}, {"B", 30}, {"D", 10}}
+
+	// B ranks well in both lists, so both fusions should put it first.
+	rrf := Fuse(FusionRRF, [][]Match{dense, sparse}, nil, 0, 2)
+	if len(rrf) != 2 || rrf[0].ID != "B" {
+		t.Errorf("RRF: expected B first, got %v", rrf)
+	}
+
+	weighted := Fuse(FusionWeighted, [][]Match{dense, sparse}, []float32{1, 1}, 0, 4)
+	if len(weighted) != 4 || weighted[0].ID != "B" {
+		t.Errorf("weighted: expected B first, got %v", weighted)
+	}
+
+	// A and C tie under RRF: the smaller ID ranks first, every time.
+	tied := Fuse(FusionRRF, [][]Match{{{"C", 1}}, {{"A", 1}}}, nil, 0, 2)
+	if len(tied) != 2 || tied[0].ID != "A" || tied[1].ID != "C" {
+		t.Errorf("RRF tie: expected A,C, got %v", tied)
+	}
+
+	// All the weight on the sparse side reproduces its order.
+	sparseOnly := Fuse(FusionWeighted, [][]Match{dense, sparse}, []float32{0, 1}, 0, 1)
+	if sparseOnly[0].ID != "C" {
+		t.Errorf("weighted: expected C first, got %v", sparseOnly)
+	}
+}
+
+// Overwrites and deletes leave dead postings that compaction reclaims, and
+// a snapshot holds only live rows whatever the history.
+func TestSparseIndex_Compaction(t *testing.T) {
+	churned, fresh := NewSparseIndex(), NewSparseIndex()
+	for round := 0; round < 5; round++ {
+		for i := 0; i < 500; i++ {
+			churned.InsertSparse(fmt.Sprintf("v%d", i), sparse(t, []uint32{uint32(i % 7), uint32(100 + round)}, []float32{1, float32(i)}))
+		}
+	}
+	for i := 0; i < 500; i += 2 {
+		churned.Delete(fmt.Sprintf("v%d", i))
+	}
+	for i := 1; i < 500; i += 2 {
+		fresh.InsertSparse(fmt.Sprintf("v%d", i), sparse(t, []uint32{uint32(i % 7), 104}, []float32{1, float32(i)}))
+	}
+
+	if rows := len(churned.ids); rows > 2*churned.Len()+compactMinDead {
+		t.Errorf("%d rows kept for %d live vectors", rows, churned.Len())
+	}
+	if _, ok := churned.postings[100]; ok {
+		t.Error("a dimension only dead rows used still has postings")
+	}
+	q := sparse(t, []uint32{3, 104}, []float32{1, 1})
+	want, _ := fresh.SearchSparse(q, 10)
+	got, _ := churned.SearchSparse(q, 10)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("search after churn = %v, want %v", got, want)
+	}
+
+	var a, b bytes.Buffer
+	churned.Save(&a)
+	fresh.Save(&b)
+	if !bytes.Equal(a.Bytes(), b.Bytes()) {
+		t.Errorf("snapshot after churn is %d bytes, %d for the live rows alone", a.Len(), b.Len())
+	}
+}
@@ -0,0 +1,143 @@
+package index
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// SearchStats describes the work done by one HNSW search.
+type SearchStats struct {
+	Ef        int // search width used at layer 0
+	Descents  int // upper layers descended before layer 0
+	Visited   int // nodes visited, all layers
+	Distances int // distance computations, all layers
+}
+
+// Stats is a point-in-time view of an HNSW index. The counters are
+// cumulative since the index was created or loaded.
+type Stats struct {
+	Nodes      int // live vectors
+	Deleted    int // tombstones still in the graph
+	Dimension  int
+	MaxLevel   int
+	LayerNodes []int // nodes present at each layer, 0..MaxLevel, tombstones included
+
+	Searches        uint64
+	SearchVisited   uint64
+	SearchDistances uint64
+	InsertDistances uint64
+}
+
+// counters are updated once per search or insert, never per distance, so
+// they are cheap enough to leave on.
+type counters struct {
+	searches        atomic.Uint64
+	searchVisited   atomic.Uint64
+	searchDistances atomic.Uint64
+	insertDistances atomic.Uint64
+}
+
+// Stats reads counts kept up to date by inserts and deletes, so it costs
+// O(levels) whatever the size of the index.
+func (h *HNSW) Stats() Stats {
+	h.globalLock.RLock()
+	st := Stats{
+		Nodes:    len(h.idToInternal),
+		Deleted:  h.tombstones,
+		MaxLevel: h.maxLevel,
+	}
+	if h.vectors != nil {
+		st.Dimension = h.vectors.dimension()
+	}
+	if h.maxLevel >= 0 {
+		st.LayerNodes = make([]int, h.maxLevel+1)
+		copy(st.LayerNodes, h.layerNodes)
+	}
+	h.globalLock.RUnlock()
+
+	st.Searches = h.counters.searches.Load()
+	st.SearchVisited = h.counters.searchVisited.Load()
+	st.SearchDistances = h.counters.searchDistances.Load()
+	st.InsertDistances = h.counters.insertDistances.Load()
+	return st
+}
+
+// countNode adds delta to the count of every layer a node of the given
+// level is present at. Callers hold globalLock.
+func (h *HNSW) countNode(level, delta int) {
+	for len(h.layerNodes) <= level {
+		h.layerNodes = append(h.layerNodes, 0)
+	}
+	for l := 0; l <= level; l++ {
+		h.layerNodes[l] += delta
+	}
+}
+
+// SetSearchObserver registers fn to be called after every search with the
+// work it did. fn runs on the search goroutine and must be fast. Pass nil
+// to remove it.
+func (h *HNSW) SetSearchObserver(fn func(SearchStats)) {
+	if fn == nil {
+		h.observer.Store(nil)
+		return
+	}
+	h.observer.Store(&fn)
+}
+
+// recordSearch folds one search into the counters and the observer.
+func (h *HNSW) recordSearch(st SearchStats) {
+	h.counters.searches.Add(1)
+	h.counters.searchVisited.Add(uint64(st.Visited))
+	h.counters.searchDistances.Add(uint64(st.Distances))
+	if fn := h.observer.Load(); fn != nil {
+		(*fn)(st)
+	}
+}
+
+// PoolStats counts traffic through one of the search scratch pools.
+// Gets - Puts is the number of objects currently checked out; News is how
+// many the pool had to allocate.
+type PoolStats struct {
+	Name             string
+	Gets, Puts, News uint64
+}
+
+// Pools reports the scratch pools shared by all HNSW indexes.
+func Pools() []PoolStats {
+	return []PoolStats{
+		candidatePool.stats(),
+		resultPool.stats(),
+		visitedPool.stats(),
+		scratchPool.stats(),
+	}
+}
+
+// countedPool is a typed sync.Pool that counts gets, puts and allocations.
+type countedPool[T any] struct {
+	name             string
+	pool             sync.Pool
+	gets, puts, news atomic.Uint64
+}
+
+func newCountedPool[T any](name string, fn func() T) *countedPool[T] {
+	p := &countedPool[T]{name: name}
+	p.pool.New = func() any {
+		p.news.Add(1)
+		return fn()
+	}
+	return p
+}
+
+func (p *countedPool[T]) get() T {
+	p.gets.Add(1)
+	return p.pool.Get().(T)
+}
+
+func (p *countedPool[T]) put(x T) {
+	p.puts.Add(1)
+	p.pool.Put(x)
+}
+
+func (p *countedPool[T]) stats() PoolStats {
+	return PoolStats{Name: p.name, Gets: p.gets.Load(), Puts: p.puts.Load(), News: p.news.Load()}
+}
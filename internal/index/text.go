@@ -0,0 +1,230 @@
+package index
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"math"
+	"sync"
+)
+
+// BM25 parameters, the usual Lucene defaults.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+type textPosting struct {
+	row int32
+	tf  int32
+}
+
+// TextIndex is a BM25 keyword index over one text field per record. Like
+// SparseIndex it leaves the postings of overwritten and deleted rows behind
+// until it compacts.
+type TextIndex struct {
+	postings map[string][]textPosting
+	docLen   []int32        // row -> token count
+	ids      []string       // row -> external id
+	texts    []string       // row -> original text, for export
+	rows     map[string]int // external id -> live row
+
+	// statistics over live rows only
+	liveLen int64
+
+	mu sync.RWMutex
+}
+
+func NewTextIndex() *TextIndex {
+	return &TextIndex{
+		postings: make(map[string][]textPosting),
+		rows:     make(map[string]int),
+	}
+}
+
+var errNoTerms = fmt.Errorf("text has no indexable terms")
+
+// CheckInsert returns the error Insert would fail with for text, without
+// indexing it.
+func (t *TextIndex) CheckInsert(text string) error {
+	if len(Tokenize(text)) == 0 {
+		return errNoTerms
+	}
+	return nil
+}
+
+// Insert indexes text under id. Re-inserting an id replaces its text.
+func (t *TextIndex) Insert(id, text string) error {
+	tokens := Tokenize(text)
+	if len(tokens) == 0 {
+		return errNoTerms
+	}
+
+	tf := make(map[string]int32, len(tokens))
+	for _, tok := range tokens {
+		tf[tok]++
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// On overwrite the old postings stay behind until the next compaction;
+	// rows no longer referenced from t.rows are skipped at query time.
+	if old, ok := t.rows[id]; ok {
+		t.liveLen -= int64(t.docLen[old])
+	}
+
+	row := int32(len(t.ids))
+	t.ids = append(t.ids, id)
+	t.texts = append(t.texts, text)
+	t.docLen = append(t.docLen, int32(len(tokens)))
+	t.rows[id] = int(row)
+	t.liveLen += int64(len(tokens))
+
+	for term, n := range tf {
+		t.postings[term] = append(t.postings[term], textPosting{row: row, tf: n})
+	}
+	t.maybeCompact()
+	return nil
+}
+
+// maybeCompact compacts the index when needsCompact says so. Callers hold
+// t.mu.
+func (t *TextIndex) maybeCompact() {
+	if needsCompact(len(t.ids), len(t.rows)) {
+		t.compact()
+	}
+}
+
+// compact drops dead rows and their postings and renumbers the live rows
+// in order. Callers hold t.mu.
+func (t *TextIndex) compact() {
+	remap, n := liveRemap(t.ids, t.rows)
+	ids, texts, docLen := make([]string, 0, n), make([]string, 0, n), make([]int32, 0, n)
+	for row, id := range t.ids {
+		if remap[row] >= 0 {
+			ids = append(ids, id)
+			texts = append(texts, t.texts[row])
+			docLen = append(docLen, t.docLen[row])
+			t.rows[id] = int(remap[row])
+		}
+	}
+	t.ids, t.texts, t.docLen = ids, texts, docLen
+	for term, list := range t.postings {
+		out := list[:0]
+		for _, p := range list {
+			if r := remap[p.row]; r >= 0 {
+				out = append(out, textPosting{row: r, tf: p.tf})
+			}
+		}
+		if len(out) == 0 {
+			delete(t.postings, term)
+		} else {
+			t.postings[term] = out
+		}
+	}
+}
+
+// Search returns the k best BM25 matches for query.
+func (t *TextIndex) Search(query string, k int) ([]Match, error) {
+	return t.SearchContext(context.Background(), query, k)
+}
+
+// SearchContext is Search that stops when ctx is done, checked between
+// query terms.
+func (t *TextIndex) SearchContext(ctx context.Context, query string, k int) ([]Match, error) {
+	terms := Tokenize(query)
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("query has no indexable terms")
+	}
+	if k < 1 {
+		return nil, fmt.Errorf("k must be at least 1, got %d", k)
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	n := float64(len(t.rows))
+	if n == 0 {
+		return []Match{}, nil
+	}
+	avgdl := float64(t.liveLen) / n
+
+	seen := make(map[string]bool, len(terms))
+	scores := make(map[int32]float32)
+	for _, term := range terms {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		list := t.postings[term]
+		df := 0
+		for _, p := range list {
+			if t.live(p.row) {
+				df++
+			}
+		}
+		if df == 0 {
+			continue
+		}
+		idf := math.Log(1 + (n-float64(df)+0.5)/(float64(df)+0.5))
+
+		for _, p := range list {
+			if !t.live(p.row) {
+				continue
+			}
+			f := float64(p.tf)
+			dl := float64(t.docLen[p.row])
+			scores[p.row] += float32(idf * f * (bm25K1 + 1) / (f + bm25K1*(1-bm25B+bm25B*dl/avgdl)))
+		}
+	}
+
+	pq := &MatchQueue{}
+	heap.Init(pq)
+	for row, score := range scores {
+		pq.PushWithLimit(Match{ID: t.ids[row], Score: score}, k)
+	}
+
+	results := make([]Match, pq.Len())
+	for i := len(results) - 1; i >= 0; i-- {
+		results[i] = heap.Pop(pq).(Match)
+	}
+	return results, nil
+}
+
+// Delete removes id and reports whether it was present.
+func (t *TextIndex) Delete(id string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	row, ok := t.rows[id]
+	if ok {
+		t.liveLen -= int64(t.docLen[row])
+		delete(t.rows, id)
+		t.maybeCompact()
+	}
+	return ok
+}
+
+// Has reports whether id is stored.
+func (t *TextIndex) Has(id string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	_, ok := t.rows[id]
+	return ok
+}
+
+// Len returns the number of live documents.
+func (t *TextIndex) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.rows)
+}
+
+func (t *TextIndex) live(row int32) bool {
+	live, ok := t.rows[t.ids[row]]
+	return ok && live == int(row)
+}
@@ -0,0 +1,91 @@
+package index
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	got := Tokenize("The Quick-brown fox, jumps over 2 lazy dogs!")
+	want := []string{"quick", "brown", "fox", "jumps", "over", "2", "lazy", "dogs"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Tokenize() = %v, want %v", got, want)
+	}
+}
+
+func TestTextIndex_BM25(t *testing.T) {
+	idx := NewTextIndex()
+
+	idx.Insert("A", "vector database with hnsw index")
+	idx.Insert("B", "hnsw hnsw hnsw graph search")
+	idx.Insert("C", "a recipe for banana bread")
+	idx.Insert("D", "database migrations made easy")
+
+	results, err := idx.Search("hnsw database", 10)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results but got %v", results)
+	}
+	// A matches both terms, so it beats B's repeated single term.
+	if results[0].ID != "A" {
+		t.Errorf("expected A first, got %v", results)
+	}
+	for _, r := range results {
+		if r.ID == "C" {
+			t.Errorf("C shares no terms with the query: %v", results)
+		}
+	}
+
+	// overwrite removes the old terms
+	idx.Insert("C", "hnsw")
+	results, _ = idx.Search("banana", 10)
+	if len(results) != 0 {
+		t.Errorf("expected no results for stale term, got %v", results)
+	}
+	if idx.Len() != 4 {
+		t.Errorf("expected 4 live docs, got %d", idx.Len())
+	}
+
+	if _, err := idx.Search("the and of", 10); err == nil {
+		t.Error("expected error for stop-word-only query")
+	}
+}
+
+// See TestSparseIndex_Compaction.
+func TestTextIndex_Compaction(t *testing.T) {
+	churned, fresh := NewTextIndex(), NewTextIndex()
+	for round := 0; round < 5; round++ {
+		for i := 0; i < 500; i++ {
+			churned.Insert(fmt.Sprintf("d%d", i), fmt.Sprintf("doc %d round%d", i%13, round))
+		}
+	}
+	for i := 0; i < 500; i += 2 {
+		churned.Delete(fmt.Sprintf("d%d", i))
+	}
+	for i := 1; i < 500; i += 2 {
+		fresh.Insert(fmt.Sprintf("d%d", i), fmt.Sprintf("doc %d round4", i%13))
+	}
+
+	if rows := len(churned.ids); rows > 2*churned.Len()+compactMinDead {
+		t.Errorf("%d rows kept for %d live documents", rows, churned.Len())
+	}
+	if _, ok := churned.postings["round0"]; ok {
+		t.Error("a term only dead rows used still has postings")
+	}
+	want, _ := fresh.Search("doc 5 round4", 10)
+	got, _ := churned.Search("doc 5 round4", 10)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("search after churn = %v, want %v", got, want)
+	}
+
+	var a, b bytes.Buffer
+	churned.Save(&a)
+	fresh.Save(&b)
+	if !bytes.Equal(a.Bytes(), b.Bytes()) {
+		t.Errorf("snapshot after churn is %d bytes, %d for the live rows alone", a.Len(), b.Len())
+	}
+}
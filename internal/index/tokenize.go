@@ -0,0 +1,31 @@
+package index
+
+import (
+	"strings"
+	"unicode"
+)
+
+// stopWords are dropped by Tokenize. Kept deliberately short: BM25's IDF
+// already discounts common terms, this just keeps posting lists small.
+var stopWords = map[string]struct{}{
+	"a": {}, "an": {}, "and": {}, "are": {}, "as": {}, "at": {}, "be": {},
+	"by": {}, "for": {}, "from": {}, "in": {}, "is": {}, "it": {}, "of": {},
+	"on": {}, "or": {}, "that": {}, "the": {}, "to": {}, "was": {}, "with": {},
+}
+
+// Tokenize lower-cases text and splits it on anything that is not a letter
+// or digit, dropping stop words.
+func Tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	out := fields[:0]
+	for _, f := range fields {
+		if _, stop := stopWords[f]; stop {
+			continue
+		}
+		out = append(out, f)
+	}
+	return out
+}
@@ -0,0 +1,236 @@
+package index
+
+import (
+	"cmp"
+	"math/rand/v2"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+// vamanaCandidate is a graph node and its distance to the point a search
+// or a prune is for.
+type vamanaCandidate struct {
+	id   uint32
+	dist float32
+}
+
+func compareCandidates(a, b vamanaCandidate) int {
+	if c := cmp.Compare(a.dist, b.dist); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.id, b.id)
+}
+
+// robustPrune picks at most r neighbors for a node from cands, each with
+// its distance to the node, closest first. A candidate is dropped once a
+// chosen neighbor is closer to it, by a factor of alpha, than the node is:
+// the edge to that neighbor already leads there. alpha > 1 keeps some
+// longer edges, which shortens search paths.
+func robustPrune(cands []vamanaCandidate, alpha float32, r int, dist func(a, b uint32) float32) []uint32 {
+	slices.SortFunc(cands, compareCandidates)
+	cands = slices.CompactFunc(cands, func(a, b vamanaCandidate) bool { return a.id == b.id })
+
+	out := make([]uint32, 0, r)
+	dropped := make([]bool, len(cands))
+	for i, c := range cands {
+		if dropped[i] {
+			continue
+		}
+		if out = append(out, c.id); len(out) == r {
+			break
+		}
+		for j := i + 1; j < len(cands); j++ {
+			if !dropped[j] && alpha*dist(c.id, cands[j].id) <= cands[j].dist {
+				dropped[j] = true
+			}
+		}
+	}
+	return out
+}
+
+// beamList is the candidate list of a greedy graph search: the closest
+// size nodes seen so far, sorted, each marked once expanded.
+type beamList struct {
+	size     int
+	items    []vamanaCandidate
+	expanded []bool
+}
+
+func newBeamList(size int) *beamList {
+	return &beamList{size: size, items: make([]vamanaCandidate, 0, size+1), expanded: make([]bool, 0, size+1)}
+}
+
+// add inserts c unless the list is full of closer nodes.
+func (b *beamList) add(c vamanaCandidate) {
+	if len(b.items) == b.size && compareCandidates(c, b.items[len(b.items)-1]) >= 0 {
+		return
+	}
+	i, _ := slices.BinarySearchFunc(b.items, c, compareCandidates)
+	b.items = slices.Insert(b.items, i, c)
+	b.expanded = slices.Insert(b.expanded, i, false)
+	if len(b.items) > b.size {
+		b.items, b.expanded = b.items[:b.size], b.expanded[:b.size]
+	}
+}
+
+// next marks up to n of the closest unexpanded nodes expanded and appends
+// them to dst. An empty result ends the search.
+func (b *beamList) next(n int, dst []vamanaCandidate) []vamanaCandidate {
+	for i := range b.items {
+		if n == 0 {
+			break
+		}
+		if !b.expanded[i] {
+			b.expanded[i] = true
+			dst = append(dst, b.items[i])
+			n--
+		}
+	}
+	return dst
+}
+
+// vamanaGraph builds a Vamana graph over normalized vectors in memory. Each
+// adjacency list has its own lock so points are linked in parallel.
+type vamanaGraph struct {
+	vs     []vec.Vector
+	adj    [][]uint32
+	locks  []sync.Mutex
+	medoid uint32
+	r, l   int
+}
+
+// buildVamana links every vector of vs, in an order drawn from cfg.Seed,
+// with workers goroutines (GOMAXPROCS when <= 0). The first pass prunes
+// with alpha 1, which gives a sparse graph quickly; the second re-links
+// every point with cfg.Alpha to add the long edges. With one worker the
+// graph depends only on vs and cfg.
+func buildVamana(vs []vec.Vector, cfg DiskANNConfig, workers int) *vamanaGraph {
+	g := &vamanaGraph{
+		vs:     vs,
+		adj:    make([][]uint32, len(vs)),
+		locks:  make([]sync.Mutex, len(vs)),
+		medoid: medoid(vs),
+		r:      cfg.R,
+		l:      cfg.L,
+	}
+	order := rand.New(rand.NewPCG(cfg.Seed, 0)).Perm(len(vs))
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	for _, alpha := range []float32{1, cfg.Alpha} {
+		var (
+			next atomic.Int64
+			wg   sync.WaitGroup
+		)
+		for range min(workers, len(vs)) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					n := int(next.Add(1)) - 1
+					if n >= len(order) {
+						return
+					}
+					g.link(uint32(order[n]), alpha)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+	return g
+}
+
+// medoid returns the vector closest to the mean of vs, where every search
+// starts.
+func medoid(vs []vec.Vector) uint32 {
+	mean := make(vec.Vector, len(vs[0]))
+	for _, v := range vs {
+		for i, x := range v {
+			mean[i] += x
+		}
+	}
+	best, bestDot := 0, float32(0)
+	for i, v := range vs {
+		if d, _ := vec.DotProduct(mean, v); i == 0 || d > bestDot {
+			best, bestDot = i, d
+		}
+	}
+	return uint32(best)
+}
+
+func (g *vamanaGraph) dist(a, b uint32) float32 {
+	d, _ := vec.DotProduct(g.vs[a], g.vs[b])
+	return 1 - d
+}
+
+// search runs a greedy search for q from the medoid and returns every
+// node it expanded, with its distance to q.
+func (g *vamanaGraph) search(q vec.Vector, self uint32) []vamanaCandidate {
+	var (
+		list     = newBeamList(g.l)
+		seen     = map[uint32]bool{g.medoid: true, self: true}
+		expanded []vamanaCandidate
+		nbrs     []uint32
+	)
+	d, _ := vec.DotProduct(q, g.vs[g.medoid])
+	list.add(vamanaCandidate{g.medoid, 1 - d})
+	for {
+		n := len(expanded)
+		if expanded = list.next(1, expanded); len(expanded) == n {
+			return expanded
+		}
+		c := expanded[n].id
+		g.locks[c].Lock()
+		nbrs = append(nbrs[:0], g.adj[c]...)
+		g.locks[c].Unlock()
+		for _, nb := range nbrs {
+			if !seen[nb] {
+				seen[nb] = true
+				d, _ := vec.DotProduct(q, g.vs[nb])
+				list.add(vamanaCandidate{nb, 1 - d})
+			}
+		}
+	}
+}
+
+// link chooses p's neighbors from the nodes a search for it expands and
+// its current ones, then adds the reverse edges.
+func (g *vamanaGraph) link(p uint32, alpha float32) {
+	cands := g.search(g.vs[p], p)
+	cands = slices.DeleteFunc(cands, func(c vamanaCandidate) bool { return c.id == p })
+	g.locks[p].Lock()
+	for _, nb := range g.adj[p] {
+		cands = append(cands, vamanaCandidate{nb, g.dist(p, nb)})
+	}
+	g.locks[p].Unlock()
+
+	nbrs := robustPrune(cands, alpha, g.r, g.dist)
+	g.locks[p].Lock()
+	g.adj[p] = nbrs
+	g.locks[p].Unlock()
+	for _, nb := range nbrs {
+		g.addEdge(nb, p, alpha)
+	}
+}
+
+// addEdge adds the edge from -> to, pruning from's list when it is full.
+func (g *vamanaGraph) addEdge(from, to uint32, alpha float32) {
+	g.locks[from].Lock()
+	defer g.locks[from].Unlock()
+	if slices.Contains(g.adj[from], to) {
+		return
+	}
+	if len(g.adj[from]) < g.r {
+		g.adj[from] = append(g.adj[from], to)
+		return
+	}
+	cands := make([]vamanaCandidate, 0, len(g.adj[from])+1)
+	for _, nb := range append(g.adj[from], to) {
+		cands = append(cands, vamanaCandidate{nb, g.dist(from, nb)})
+	}
+	g.adj[from] = robustPrune(cands, alpha, g.r, g.dist)
+}
@@ -0,0 +1,254 @@
+// Package metrics exposes server internals in the Prometheus text format.
+// The index and storage packages keep their own cheap atomic counters and
+// observer hooks; this package only adapts them, so neither depends on
+// Prometheus.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"github.com/sandeep89846/nebuladb/internal/index"
+	"github.com/sandeep89846/nebuladb/internal/storage"
+)
+
+const namespace = "nebula"
+
+// Metrics owns a registry and the metrics that are observed directly
+// rather than read from counters at scrape time.
+type Metrics struct {
+	reg *prometheus.Registry
+
+	rpcs       *prometheus.CounterVec
+	rpcLatency *prometheus.HistogramVec
+
+	searchVisited   prometheus.Histogram
+	searchDistances prometheus.Histogram
+
+	walFsync  prometheus.Histogram
+	walReplay prometheus.Gauge
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		reg: prometheus.NewRegistry(),
+		rpcs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rpc_requests_total",
+			Help:      "gRPC requests handled, by method and status code.",
+		}, []string{"method", "code"}),
+		rpcLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "rpc_duration_seconds",
+			Help:      "gRPC handler latency, by method and status code.",
+			Buckets:   prometheus.ExponentialBuckets(0.0001, 2, 16), // 100us .. ~3s
+		}, []string{"method", "code"}),
+		searchVisited: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "search_visited_nodes",
+			Help:      "Graph nodes visited per HNSW search.",
+			Buckets:   prometheus.ExponentialBuckets(16, 2, 12),
+		}),
+		searchDistances: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "search_distance_computations",
+			Help:      "Distance computations per HNSW search.",
+			Buckets:   prometheus.ExponentialBuckets(16, 2, 12),
+		}),
+		walFsync: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "wal_fsync_duration_seconds",
+			Help:      "WAL fsync latency.",
+			Buckets:   prometheus.ExponentialBuckets(0.0001, 2, 14), // 100us .. ~0.8s
+		}),
+		walReplay: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "wal_replay_duration_seconds",
+			Help:      "Time taken to replay the WAL at startup.",
+		}),
+	}
+	m.reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.rpcs, m.rpcLatency,
+		m.searchVisited, m.searchDistances,
+		m.walFsync, m.walReplay,
+		poolCollector{},
+	)
+	return m
+}
+
+// Handler serves the registry for scraping.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.reg, promhttp.HandlerOpts{Registry: m.reg})
+}
+
+// UnaryServerInterceptor counts and times every unary RPC.
+func (m *Metrics) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		m.observeRPC(info.FullMethod, err, time.Since(start))
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor counts and times every streaming RPC.
+func (m *Metrics) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		m.observeRPC(info.FullMethod, err, time.Since(start))
+		return err
+	}
+}
+
+func (m *Metrics) observeRPC(method string, err error, d time.Duration) {
+	code := status.Code(err).String()
+	m.rpcs.WithLabelValues(method, code).Inc()
+	m.rpcLatency.WithLabelValues(method, code).Observe(d.Seconds())
+}
+
+// WatchHNSW exports the graph shape and search counters of h and records
+// the per-search histograms.
+func (m *Metrics) WatchHNSW(h *index.HNSW) {
+	h.SetSearchObserver(func(st index.SearchStats) {
+		m.searchVisited.Observe(float64(st.Visited))
+		m.searchDistances.Observe(float64(st.Distances))
+	})
+	m.reg.MustRegister(hnswCollector{h})
+}
+
+// WatchSize exports the live entry count of a secondary index under the
+// given index label.
+func (m *Metrics) WatchSize(name string, lenFn func() int) {
+	m.reg.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Name:        "index_entries",
+		Help:        "Live entries per index.",
+		ConstLabels: prometheus.Labels{"index": name},
+	}, func() float64 { return float64(lenFn()) }))
+}
+
+// WatchWAL exports the WAL counters and fsync latency.
+func (m *Metrics) WatchWAL(w *storage.WAL) {
+	w.SetSyncObserver(func(d time.Duration) { m.walFsync.Observe(d.Seconds()) })
+	m.reg.MustRegister(walCollector{w})
+}
+
+// ObserveReplay records how long startup replay took.
+func (m *Metrics) ObserveReplay(d time.Duration) {
+	m.walReplay.Set(d.Seconds())
+}
+
+var (
+	hnswEntries = prometheus.NewDesc(namespace+"_index_entries",
+		"Live entries per index.", nil, prometheus.Labels{"index": "hnsw"})
+	hnswDimension = prometheus.NewDesc(namespace+"_hnsw_dimension",
+		"Vector dimension of the HNSW index, 0 while empty.", nil, nil)
+	hnswTombstones = prometheus.NewDesc(namespace+"_hnsw_tombstones",
+		"Deleted HNSW nodes still kept in the graph.", nil, nil)
+	hnswMaxLevel = prometheus.NewDesc(namespace+"_hnsw_max_level",
+		"Highest HNSW layer, -1 while empty.", nil, nil)
+	hnswLayerNodes = prometheus.NewDesc(namespace+"_hnsw_layer_nodes",
+		"Nodes present on each HNSW layer.", []string{"layer"}, nil)
+	hnswSearches = prometheus.NewDesc(namespace+"_hnsw_searches_total",
+		"HNSW searches run.", nil, nil)
+	hnswVisited = prometheus.NewDesc(namespace+"_hnsw_visited_nodes_total",
+		"Graph nodes visited by HNSW searches.", nil, nil)
+	hnswDistances = prometheus.NewDesc(namespace+"_hnsw_distance_computations_total",
+		"Distance computations, by operation.", []string{"op"}, nil)
+)
+
+type hnswCollector struct{ h *index.HNSW }
+
+func (c hnswCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{hnswEntries, hnswTombstones, hnswDimension, hnswMaxLevel,
+		hnswLayerNodes, hnswSearches, hnswVisited, hnswDistances} {
+		ch <- d
+	}
+}
+
+func (c hnswCollector) Collect(ch chan<- prometheus.Metric) {
+	st := c.h.Stats()
+	ch <- prometheus.MustNewConstMetric(hnswEntries, prometheus.GaugeValue, float64(st.Nodes))
+	ch <- prometheus.MustNewConstMetric(hnswTombstones, prometheus.GaugeValue, float64(st.Deleted))
+	ch <- prometheus.MustNewConstMetric(hnswDimension, prometheus.GaugeValue, float64(st.Dimension))
+	ch <- prometheus.MustNewConstMetric(hnswMaxLevel, prometheus.GaugeValue, float64(st.MaxLevel))
+	for l, n := range st.LayerNodes {
+		ch <- prometheus.MustNewConstMetric(hnswLayerNodes, prometheus.GaugeValue, float64(n), strconv.Itoa(l))
+	}
+	ch <- prometheus.MustNewConstMetric(hnswSearches, prometheus.CounterValue, float64(st.Searches))
+	ch <- prometheus.MustNewConstMetric(hnswVisited, prometheus.CounterValue, float64(st.SearchVisited))
+	ch <- prometheus.MustNewConstMetric(hnswDistances, prometheus.CounterValue, float64(st.SearchDistances), "search")
+	ch <- prometheus.MustNewConstMetric(hnswDistances, prometheus.CounterValue, float64(st.InsertDistances), "insert")
+}
+
+var (
+	walBytes = prometheus.NewDesc(namespace+"_wal_bytes_written_total",
+		"Bytes appended to the WAL.", nil, nil)
+	walRecords = prometheus.NewDesc(namespace+"_wal_records_total",
+		"Records appended to the WAL.", nil, nil)
+	walReplayRecords = prometheus.NewDesc(namespace+"_wal_replay_records",
+		"Records processed by the current or last WAL replay.", nil, nil)
+	walReplayBytes = prometheus.NewDesc(namespace+"_wal_replay_bytes",
+		"Bytes processed by the current or last WAL replay.", nil, nil)
+	walReplayTotal = prometheus.NewDesc(namespace+"_wal_replay_total_bytes",
+		"WAL size when the current or last replay started.", nil, nil)
+)
+
+type walCollector struct{ w *storage.WAL }
+
+func (c walCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- walBytes
+	ch <- walRecords
+	ch <- walReplayRecords
+	ch <- walReplayBytes
+	ch <- walReplayTotal
+}
+
+func (c walCollector) Collect(ch chan<- prometheus.Metric) {
+	st := c.w.Stats()
+	ch <- prometheus.MustNewConstMetric(walBytes, prometheus.CounterValue, float64(st.BytesWritten))
+	ch <- prometheus.MustNewConstMetric(walRecords, prometheus.CounterValue, float64(st.Records))
+
+	p := c.w.ReplayProgress()
+	ch <- prometheus.MustNewConstMetric(walReplayRecords, prometheus.GaugeValue, float64(p.Records))
+	ch <- prometheus.MustNewConstMetric(walReplayBytes, prometheus.GaugeValue, float64(p.Bytes))
+	ch <- prometheus.MustNewConstMetric(walReplayTotal, prometheus.GaugeValue, float64(p.TotalBytes))
+}
+
+var (
+	poolGets = prometheus.NewDesc(namespace+"_pool_gets_total",
+		"Objects taken from an HNSW scratch pool.", []string{"pool"}, nil)
+	poolAllocs = prometheus.NewDesc(namespace+"_pool_allocations_total",
+		"Objects a scratch pool had to allocate because it was empty.", []string{"pool"}, nil)
+	poolInUse = prometheus.NewDesc(namespace+"_pool_in_use",
+		"Objects currently checked out of a scratch pool.", []string{"pool"}, nil)
+)
+
+type poolCollector struct{}
+
+func (poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolGets
+	ch <- poolAllocs
+	ch <- poolInUse
+}
+
+func (poolCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, p := range index.Pools() {
+		ch <- prometheus.MustNewConstMetric(poolGets, prometheus.CounterValue, float64(p.Gets), p.Name)
+		ch <- prometheus.MustNewConstMetric(poolAllocs, prometheus.CounterValue, float64(p.News), p.Name)
+		// Puts is read after Gets and may have overtaken it.
+		inUse := float64(p.Gets) - float64(p.Puts)
+		ch <- prometheus.MustNewConstMetric(poolInUse, prometheus.GaugeValue, max(inUse, 0), p.Name)
+	}
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sandeep89846/nebuladb/internal/index"
+	"github.com/sandeep89846/nebuladb/internal/storage"
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMetrics_Scrape(t *testing.T) {
+	m := New()
+
+	idx := index.NewHNSW(index.DefaultConfig())
+	m.WatchHNSW(idx)
+	for i := 0; i < 200; i++ {
+		idx.Insert(fmt.Sprintf("v%d", i), vec.Vector{float32(i%7 + 1), float32(i%5 + 1), float32(i%3 + 1)})
+	}
+	idx.Search(vec.Vector{1, 2, 3}, 5)
+
+	wal, err := storage.OpenWALWithOptions(filepath.Join(t.TempDir(), "wal"),
+		storage.WALOptions{Sync: storage.SyncAlways})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wal.Close()
+	m.WatchWAL(wal)
+	wal.WriteInsert("a", vec.Vector{1, 2})
+
+	intercept := m.UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/nebula.VectorService/Search"}
+	intercept(context.Background(), nil, info, func(context.Context, any) (any, error) {
+		return nil, status.Error(codes.InvalidArgument, "bad")
+	})
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+	out := string(body)
+
+	for _, want := range []string{
+		`nebula_index_entries{index="hnsw"} 200`,
+		`nebula_hnsw_layer_nodes{layer="0"} 200`,
+		`nebula_hnsw_searches_total 1`,
+		`nebula_hnsw_distance_computations_total{op="insert"}`,
+		`nebula_search_visited_nodes_count 1`,
+		`nebula_wal_records_total 1`,
+		`nebula_wal_bytes_written_total 20`,
+		`nebula_wal_fsync_duration_seconds_count 1`,
+		`nebula_rpc_requests_total{code="InvalidArgument",method="/nebula.VectorService/Search"} 1`,
+		`nebula_pool_in_use{pool="result"} 0`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("scrape is missing %q", want)
+		}
+	}
+}
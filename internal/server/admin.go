@@ -0,0 +1,51 @@
+package server
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sandeep89846/nebuladb/api/proto/nebulapb"
+)
+
+// Stats reports index sizes and WAL counters.
+func (s *Server) Stats(ctx context.Context, req *nebulapb.StatsRequest) (*nebulapb.StatsResponse, error) {
+	st := s.idx.Stats()
+	ws := s.wal.Stats()
+	return &nebulapb.StatsResponse{
+		Vectors:       int64(st.Nodes),
+		Deleted:       int64(st.Deleted),
+		Dimension:     int32(st.Dimension),
+		MaxLevel:      int32(st.MaxLevel),
+		SparseVectors: int64(s.sparse.Len()),
+		TextDocuments: int64(s.text.Len()),
+		WalBytes:      ws.BytesWritten,
+		WalRecords:    ws.Records,
+		Searches:      st.Searches,
+	}, nil
+}
+
+// Snapshot checkpoints to the path the server restored from.
+func (s *Server) Snapshot(ctx context.Context, req *nebulapb.SnapshotRequest) (*nebulapb.SnapshotResponse, error) {
+	if s.snapshotPath == "" {
+		return nil, status.Error(grpccodes.FailedPrecondition, "server has no snapshot path")
+	}
+	start := time.Now()
+	if err := s.Checkpoint(s.snapshotPath); err != nil {
+		log.Printf("Snapshot failed: %v", err)
+		return nil, status.Errorf(grpccodes.Internal, "snapshot failed: %v", err)
+	}
+	resp := &nebulapb.SnapshotResponse{
+		Path:       s.snapshotPath,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if fi, err := os.Stat(s.snapshotPath); err == nil {
+		resp.SizeBytes = fi.Size()
+	}
+	log.Printf(" Wrote snapshot %s in %dms.", resp.Path, resp.DurationMs)
+	return resp, nil
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log"
+
+	"google.golang.org/grpc"
+
+	"github.com/sandeep89846/nebuladb/api/proto/nebulapb"
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+// bulkChunk is how many streamed records are logged and indexed together.
+const bulkChunk = 1024
+
+// maxBulkErrors bounds the failures listed in a BulkInsertResponse.
+const maxBulkErrors = 100
+
+// BulkInsert applies streamed records in chunks. Plain dense records, the
+// bulk of any load, go to the WAL in one write per chunk and are indexed
+// in parallel; records with sparse, text or binary data take the Insert
+// path. Chunks already applied stay applied if the stream breaks.
+func (s *Server) BulkInsert(stream grpc.ClientStreamingServer[nebulapb.InsertRequest, nebulapb.BulkInsertResponse]) error {
+	resp := &nebulapb.BulkInsertResponse{}
+	chunk := make([]*nebulapb.InsertRequest, 0, bulkChunk)
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		chunk = append(chunk, req)
+		if len(chunk) == bulkChunk {
+			s.insertChunk(stream.Context(), chunk, resp)
+			chunk = chunk[:0]
+		}
+	}
+	s.insertChunk(stream.Context(), chunk, resp)
+	return stream.SendAndClose(resp)
+}
+
+func (s *Server) insertChunk(ctx context.Context, chunk []*nebulapb.InsertRequest, resp *nebulapb.BulkInsertResponse) {
+	if len(chunk) == 0 {
+		return
+	}
+	ctx = context.WithoutCancel(ctx) // as in Insert, once records are logged
+	s.writeMu.RLock()
+	defer s.writeMu.RUnlock()
+
+	fail := func(id, msg string) {
+		resp.Failed++
+		if len(resp.Errors) < maxBulkErrors {
+			resp.Errors = append(resp.Errors, &nebulapb.BulkInsertError{Id: id, Error: msg})
+		}
+	}
+
+	var (
+		ids []string
+		vs  []vec.Vector
+	)
+	for _, req := range chunk {
+		if !plainDense(req) {
+			if r := s.insert(ctx, req); r.Success {
+				resp.Inserted++
+			} else {
+				fail(req.Id, r.Error)
+			}
+			continue
+		}
+		v, err := decodeVector(req.Vector, req.PackedVector, req.Encoding)
+		if err == nil {
+			err = s.checkDim(len(v))
+		}
+		if err != nil {
+			fail(req.Id, err.Error())
+			continue
+		}
+		ids = append(ids, req.Id)
+		vs = append(vs, v)
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	err := s.traceWAL(ctx, "WAL.WriteInserts", func() error { return s.wal.WriteInserts(ids, vs) })
+	if err != nil {
+		log.Printf("WAL write error: %v", err)
+		for _, id := range ids {
+			fail(id, "persistence failed")
+		}
+		return
+	}
+
+	for i, err := range s.idx.InsertBatch(ctx, ids, vs, 0) {
+		if err != nil {
+			fail(ids[i], err.Error())
+		} else {
+			resp.Inserted++
+		}
+	}
+}
+
+// plainDense reports whether req carries only a float vector.
+func plainDense(req *nebulapb.InsertRequest) bool {
+	hasDense := len(req.Vector) != 0 || len(req.PackedVector) != 0
+	return hasDense && req.Encoding != nebulapb.VectorEncoding_BINARY && req.Sparse == nil && req.Text == ""
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/sandeep89846/nebuladb/api/proto/nebulapb"
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+// decodeVector picks the request vector out of either the repeated float
+// field or the packed bytes field, widening half-precision input to float32.
+func decodeVector(values []float32, packed []byte, enc nebulapb.VectorEncoding) (vec.Vector, error) {
+	if len(packed) == 0 {
+		return vec.Vector(values), nil
+	}
+	if len(values) != 0 {
+		return nil, fmt.Errorf("both vector and packed_vector set")
+	}
+
+	switch enc {
+	case nebulapb.VectorEncoding_FLOAT16:
+		h, err := vec.Float16FromBytes(packed)
+		if err != nil {
+			return nil, err
+		}
+		return h.Float32(), nil
+	case nebulapb.VectorEncoding_BFLOAT16:
+		b, err := vec.BFloat16FromBytes(packed)
+		if err != nil {
+			return nil, err
+		}
+		return b.Float32(), nil
+	case nebulapb.VectorEncoding_FLOAT32:
+		return vec.Float32FromBytes(packed)
+	}
+	return nil, fmt.Errorf("unsupported vector encoding %v", enc)
+}
+
+// decodeBits unpacks a BINARY-encoded request vector.
+func decodeBits(packed []byte) (vec.BitVector, error) {
+	if len(packed) == 0 {
+		return nil, fmt.Errorf("empty vector")
+	}
+	return vec.BitVectorFromBytes(packed), nil
+}
+
+// decodeSparse converts an optional proto sparse vector. A nil message
+// yields an empty vector.
+func decodeSparse(sp *nebulapb.SparseVector) (vec.SparseVector, error) {
+	if sp == nil || (len(sp.Indices) == 0 && len(sp.Values) == 0) {
+		return vec.SparseVector{}, nil
+	}
+	return vec.NewSparseVector(sp.Indices, sp.Values)
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"github.com/sandeep89846/nebuladb/api/proto/nebulapb"
+	"github.com/sandeep89846/nebuladb/internal/index"
+)
+
+// Export streams the dense index in insertion order, attaching each
+// record's sparse vector and text, then the records that have no dense
+// vector in id order. The sparse vectors and texts are copied up front,
+// so those of records inserted during the export may be missing.
+func (s *Server) Export(_ *nebulapb.ExportRequest, stream grpc.ServerStreamingServer[nebulapb.InsertRequest]) error {
+	ctx := stream.Context()
+	sparse := s.sparse.Vectors()
+	texts := s.text.Documents()
+	bitBytes := (s.idx.BitLen() + 7) / 8
+
+	err := s.idx.Range(ctx, func(e index.Entry) error {
+		rec := &nebulapb.InsertRequest{Id: e.ID, Vector: e.Vector}
+		if e.Bits != nil {
+			rec.PackedVector = e.Bits.Bytes(bitBytes)
+			rec.Encoding = nebulapb.VectorEncoding_BINARY
+		}
+		if sp, ok := sparse[e.ID]; ok {
+			rec.Sparse = &nebulapb.SparseVector{Indices: sp.Indices, Values: sp.Values}
+			delete(sparse, e.ID)
+		}
+		if t, ok := texts[e.ID]; ok {
+			rec.Text = t
+			delete(texts, e.ID)
+		}
+		return stream.Send(rec)
+	})
+	if err != nil {
+		return exportError(err)
+	}
+
+	rest := make([]string, 0, len(sparse)+len(texts))
+	for id := range sparse {
+		rest = append(rest, id)
+	}
+	for id := range texts {
+		if _, ok := sparse[id]; !ok {
+			rest = append(rest, id)
+		}
+	}
+	slices.Sort(rest)
+	for _, id := range rest {
+		if err := ctx.Err(); err != nil {
+			return exportError(err)
+		}
+		rec := &nebulapb.InsertRequest{Id: id, Text: texts[id]}
+		if sp, ok := sparse[id]; ok {
+			rec.Sparse = &nebulapb.SparseVector{Indices: sp.Indices, Values: sp.Values}
+		}
+		if err := stream.Send(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func exportError(err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	return err
+}
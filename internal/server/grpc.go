@@ -2,55 +2,199 @@ package server
 
 import (
 	"context"
+	"errors"
 	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/sandeep89846/nebuladb/api/proto/nebulapb"
 	"github.com/sandeep89846/nebuladb/internal/index"
 	"github.com/sandeep89846/nebuladb/internal/storage"
-	"github.com/sandeep89846/nebuladb/pkg/vec"
 )
 
+var tracer = otel.Tracer("github.com/sandeep89846/nebuladb/internal/server")
+
 // Server implements the gRPC VectorService.
 type Server struct {
 	nebulapb.UnimplementedVectorServiceServer
 
-	idx *index.HNSW
-	wal *storage.WAL
+	idx    *index.HNSW
+	sparse *index.SparseIndex
+	text   *index.TextIndex
+	wal    *storage.WAL
+
+	limits Limits
+
+	// writeMu is held shared by writes, from the WAL record to the index
+	// update, and exclusively by Checkpoint.
+	writeMu sync.RWMutex
+	// snapshotPath is where the Snapshot RPC checkpoints; set by Restore.
+	snapshotPath string
+
+	// ready is false while Restore runs; see Interceptors.
+	ready atomic.Bool
 }
 
-func NewServer(idx *index.HNSW, wal *storage.WAL) *Server {
-	return &Server{
-		idx: idx,
-		wal: wal,
+// NewServer returns a Server that is ready to serve the given indexes.
+func NewServer(idx *index.HNSW, sparse *index.SparseIndex, text *index.TextIndex, wal *storage.WAL) *Server {
+	s := &Server{
+		idx:    idx,
+		sparse: sparse,
+		text:   text,
+		wal:    wal,
 	}
+	s.ready.Store(true)
+	return s
 }
 
 // Insert handles adding vectors to both WAL and Index.
 func (s *Server) Insert(ctx context.Context, req *nebulapb.InsertRequest) (*nebulapb.InsertResponse, error) {
+	// Cancellation is honored up to the WAL write. Once a record is logged
+	// it will be replayed, so the index insert has to finish too.
+	if err := ctx.Err(); err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+	ctx = context.WithoutCancel(ctx)
+	s.writeMu.RLock()
+	defer s.writeMu.RUnlock()
+	return s.insert(ctx, req), nil
+}
 
-	if len(req.Vector) == 0 {
-		return &nebulapb.InsertResponse{Success: false, Error: "empty vector"}, nil
+// insert logs and indexes one record. Every part is validated before any
+// is logged, and all of them are logged in one write, so an insert that
+// fails leaves nothing behind. Callers hold writeMu shared.
+func (s *Server) insert(ctx context.Context, req *nebulapb.InsertRequest) *nebulapb.InsertResponse {
+	recs, err := s.insertRecords(req)
+	if err != nil {
+		return &nebulapb.InsertResponse{Success: false, Error: err.Error()}
 	}
 
-	v := vec.Vector(req.Vector)
-	if err := s.wal.WriteInsert(req.Id, v); err != nil {
+	err = s.traceWAL(ctx, "WAL.WriteRecords", func() error { return s.wal.WriteRecords(recs) })
+	if err != nil {
 		log.Printf("WAL write error: %v", err)
-		return &nebulapb.InsertResponse{Success: false, Error: "persistence failed"}, nil
+		return &nebulapb.InsertResponse{Success: false, Error: "persistence failed"}
 	}
 
-	if err := s.idx.Insert(req.Id, v); err != nil {
-		return &nebulapb.InsertResponse{Success: false, Error: err.Error()}, nil
+	for _, r := range recs {
+		switch r.Op {
+		case storage.OpInsert:
+			err = s.idx.InsertContext(ctx, r.ID, r.Vector)
+		case storage.OpInsertBinary:
+			err = s.idx.InsertBinaryContext(ctx, r.ID, r.Bits, r.BitLen)
+		case storage.OpInsertSparse:
+			err = s.sparse.InsertSparse(r.ID, r.Sparse)
+		case storage.OpInsertText:
+			err = s.text.Insert(r.ID, r.Text)
+		}
+		if err != nil {
+			return &nebulapb.InsertResponse{Success: false, Error: err.Error()}
+		}
+	}
+	return &nebulapb.InsertResponse{Success: true}
+}
+
+// insertRecords decodes and validates every part of req and returns the WAL
+// records for them, dense or binary first.
+func (s *Server) insertRecords(req *nebulapb.InsertRequest) ([]storage.Record, error) {
+	sp, err := decodeSparse(req.Sparse)
+	if err == nil {
+		err = s.checkDim(sp.Len())
+	}
+	if err != nil {
+		return nil, err
+	}
+	hasDense := len(req.Vector) != 0 || len(req.PackedVector) != 0
+	if !hasDense && sp.Len() == 0 && req.Text == "" {
+		return nil, errors.New("empty vector")
 	}
 
-	return &nebulapb.InsertResponse{Success: true}, nil
+	var recs []storage.Record
+	switch {
+	case hasDense && req.Encoding == nebulapb.VectorEncoding_BINARY:
+		v, err := decodeBits(req.PackedVector)
+		n := 8 * len(req.PackedVector) // as many bits as were sent
+		if err == nil {
+			err = s.checkDim(n)
+		}
+		if err == nil {
+			err = s.idx.CheckInsertBinary(req.Id, v, n)
+		}
+		if err != nil {
+			return nil, err
+		}
+		recs = append(recs, storage.Record{Op: storage.OpInsertBinary, ID: req.Id, Bits: v, BitLen: n})
+	case hasDense:
+		v, err := decodeVector(req.Vector, req.PackedVector, req.Encoding)
+		if err == nil {
+			err = s.checkDim(len(v))
+		}
+		if err == nil {
+			err = s.idx.CheckInsert(req.Id, v)
+		}
+		if err != nil {
+			return nil, err
+		}
+		recs = append(recs, storage.Record{Op: storage.OpInsert, ID: req.Id, Vector: v})
+	}
+	if sp.Len() != 0 {
+		recs = append(recs, storage.Record{Op: storage.OpInsertSparse, ID: req.Id, Sparse: sp})
+	}
+	if req.Text != "" {
+		if err := s.text.CheckInsert(req.Text); err != nil {
+			return nil, err
+		}
+		recs = append(recs, storage.Record{Op: storage.OpInsertText, ID: req.Id, Text: req.Text})
+	}
+	return recs, nil
 }
 
 // Search handles query requests.
 func (s *Server) Search(ctx context.Context, req *nebulapb.SearchRequest) (*nebulapb.SearchResponse, error) {
-	matches, err := s.idx.Search(vec.Vector(req.Vector), int(req.K))
+	sp, err := decodeSparse(req.Sparse)
 	if err != nil {
+		return nil, status.Error(grpccodes.InvalidArgument, err.Error())
+	}
+	k := int(req.K)
+	if err := s.checkK(k); err != nil {
 		return nil, err
 	}
+	if req.Ef < 0 {
+		return nil, status.Errorf(grpccodes.InvalidArgument, "ef must not be negative, got %d", req.Ef)
+	}
+	if req.Ef > 0 {
+		// ef costs about what k does, so the same limit applies.
+		if err := s.checkK(int(req.Ef)); err != nil {
+			return nil, err
+		}
+		ctx = index.WithEf(ctx, int(req.Ef))
+	}
+	if err := s.checkDim(sp.Len()); err != nil {
+		return nil, status.Error(grpccodes.InvalidArgument, err.Error())
+	}
+	hasDense := len(req.Vector) != 0 || len(req.PackedVector) != 0
+	hasText := req.TextQuery != ""
+
+	var matches []index.Match
+	switch {
+	case countTrue(hasDense, sp.Len() != 0, hasText) > 1:
+		matches, err = s.hybridSearch(ctx, req, sp)
+	case sp.Len() != 0:
+		matches, err = s.sparse.SearchSparseContext(ctx, sp, k)
+	case hasText:
+		matches, err = s.text.SearchContext(ctx, req.TextQuery, k)
+	default:
+		matches, err = s.denseSearch(ctx, req, k)
+	}
+	partial := errors.Is(err, index.ErrPartial)
+	if err != nil && !partial {
+		return nil, searchError(err)
+	}
 
 	// Convert internal matches to Proto matches
 	pbMatches := make([]*nebulapb.SearchResponse_Match, len(matches))
@@ -61,5 +205,112 @@ func (s *Server) Search(ctx context.Context, req *nebulapb.SearchRequest) (*nebu
 		}
 	}
 
-	return &nebulapb.SearchResponse{Matches: pbMatches}, nil
+	return &nebulapb.SearchResponse{Matches: pbMatches, Partial: partial}, nil
+}
+
+// denseSearch runs the request's dense (float or binary) query against HNSW.
+// For best_effort requests it may return partial matches with
+// index.ErrPartial.
+func (s *Server) denseSearch(ctx context.Context, req *nebulapb.SearchRequest, k int) ([]index.Match, error) {
+	if req.BestEffort {
+		var cancel context.CancelFunc
+		ctx, cancel = bestEffortContext(ctx)
+		defer cancel()
+	}
+
+	if req.Encoding == nebulapb.VectorEncoding_BINARY {
+		q, err := decodeBits(req.PackedVector)
+		if err != nil {
+			return nil, status.Error(grpccodes.InvalidArgument, err.Error())
+		}
+		if err := s.checkDim(8 * len(req.PackedVector)); err != nil {
+			return nil, status.Error(grpccodes.InvalidArgument, err.Error())
+		}
+		return s.idx.SearchBinaryContext(ctx, q, k)
+	}
+
+	q, err := decodeVector(req.Vector, req.PackedVector, req.Encoding)
+	if err != nil {
+		return nil, status.Error(grpccodes.InvalidArgument, err.Error())
+	}
+	if err := s.checkDim(len(q)); err != nil {
+		return nil, status.Error(grpccodes.InvalidArgument, err.Error())
+	}
+	return s.idx.SearchContext(ctx, q, k)
+}
+
+// bestEffortContext stops a best-effort search a little before the caller's
+// deadline, leaving a tenth of the remaining time to fuse and send what it
+// found.
+func bestEffortContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx = index.WithBestEffort(ctx)
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return ctx, func() {}
+	}
+	return context.WithDeadline(ctx, deadline.Add(-time.Until(deadline)/10))
+}
+
+// traceWAL runs a WAL write inside a span named after it, so slow appends
+// and fsyncs show up next to the index work.
+func (s *Server) traceWAL(ctx context.Context, name string, write func() error) error {
+	_, span := tracer.Start(ctx, name)
+	defer span.End()
+	err := write()
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+// searchError gives an index search error its status. The indexes fail
+// only on a bad query, such as one of the wrong dimension, or when the
+// context ends.
+func searchError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	return status.Error(grpccodes.InvalidArgument, err.Error())
+}
+
+// Get returns the dense or binary vector stored under req.Id. A binary
+// vector is sent in as many bytes as it was inserted with.
+func (s *Server) Get(ctx context.Context, req *nebulapb.GetRequest) (*nebulapb.GetResponse, error) {
+	resp := &nebulapb.GetResponse{Id: req.Id}
+	if bits, ok := s.idx.BitVector(req.Id); ok {
+		resp.PackedVector = bits.Bytes((s.idx.BitLen() + 7) / 8)
+		resp.Encoding = nebulapb.VectorEncoding_BINARY
+		return resp, nil
+	}
+	v, ok := s.idx.Vector(req.Id)
+	if !ok {
+		return nil, status.Errorf(grpccodes.NotFound, "no vector with id %q", req.Id)
+	}
+	resp.Vector = v
+	return resp, nil
+}
+
+// Delete logs the deletion, then removes req.Id from every index.
+func (s *Server) Delete(ctx context.Context, req *nebulapb.DeleteRequest) (*nebulapb.DeleteResponse, error) {
+	s.writeMu.RLock()
+	defer s.writeMu.RUnlock()
+	if !s.idx.Has(req.Id) && !s.sparse.Has(req.Id) && !s.text.Has(req.Id) {
+		return nil, status.Errorf(grpccodes.NotFound, "no vector with id %q", req.Id)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+
+	err := s.traceWAL(ctx, "WAL.WriteDelete", func() error { return s.wal.WriteDelete(req.Id) })
+	if err != nil {
+		log.Printf("WAL write error: %v", err)
+		return nil, status.Error(grpccodes.Internal, "persistence failed")
+	}
+	s.idx.Delete(req.Id)
+	s.sparse.Delete(req.Id)
+	s.text.Delete(req.Id)
+	return &nebulapb.DeleteResponse{}, nil
 }
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sandeep89846/nebuladb/api/proto/nebulapb"
+	"github.com/sandeep89846/nebuladb/internal/index"
+	"github.com/sandeep89846/nebuladb/internal/storage"
+)
+
+// walRecords counts the records logged to w.
+func walRecords(t *testing.T, w *storage.WAL) int {
+	t.Helper()
+	n := 0
+	if err := w.ReplayRecords(func(storage.Record) error { n++; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+// A request whose later part is invalid must not log or index the earlier
+// ones, so the same id can be retried once fixed.
+func TestServer_InsertAllOrNothing(t *testing.T) {
+	walPath := filepath.Join(t.TempDir(), "nebula.wal")
+	wal, err := storage.OpenWAL(walPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wal.Close()
+	srv := NewServer(index.NewHNSW(index.DefaultConfig()), index.NewSparseIndex(), index.NewTextIndex(), wal)
+	ctx := context.Background()
+	sparse := &nebulapb.SparseVector{Indices: []uint32{1}, Values: []float32{1}}
+
+	bad := []*nebulapb.InsertRequest{
+		{Id: "a", Vector: []float32{1, 0}, Sparse: sparse, Text: "!!"},
+		{Id: "a", Vector: []float32{1, 0}, Sparse: &nebulapb.SparseVector{Indices: []uint32{1}, Values: []float32{1, 2}}},
+		{Id: "a", Vector: []float32{0, 0}, Text: "hello"},
+	}
+	for _, req := range bad {
+		if resp, _ := srv.Insert(ctx, req); resp.Success {
+			t.Fatalf("invalid insert %v succeeded", req)
+		}
+	}
+	if got, _ := srv.idx.Search([]float32{1, 0}, 1); len(got) != 0 || srv.sparse.Len() != 0 || srv.text.Len() != 0 || walRecords(t, wal) != 0 {
+		t.Fatalf("a failed insert left state behind: %d WAL records", walRecords(t, wal))
+	}
+
+	resp, _ := srv.Insert(ctx, &nebulapb.InsertRequest{Id: "a", Vector: []float32{1, 0}, Sparse: sparse, Text: "hello"})
+	if !resp.Success {
+		t.Fatalf("retry failed: %s", resp.Error)
+	}
+	if n := walRecords(t, wal); srv.sparse.Len() != 1 || srv.text.Len() != 1 || n != 3 {
+		t.Errorf("retry indexed %d sparse and %d text rows, logged %d records", srv.sparse.Len(), srv.text.Len(), n)
+	}
+	if resp, _ := srv.Insert(ctx, &nebulapb.InsertRequest{Id: "a", Vector: []float32{0, 1}, Text: "other"}); resp.Success {
+		t.Error("duplicate dense id accepted")
+	}
+	if n := walRecords(t, wal); n != 3 {
+		t.Errorf("rejected duplicate was logged: %d records", n)
+	}
+}
+
+// Malformed queries are the client's fault and must say so, not surface
+// as Unknown, which the gateway turns into a 500.
+func TestServer_SearchInvalidArgument(t *testing.T) {
+	wal, err := storage.OpenWAL(filepath.Join(t.TempDir(), "nebula.wal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wal.Close()
+	srv := NewServer(index.NewHNSW(index.DefaultConfig()), index.NewSparseIndex(), index.NewTextIndex(), wal)
+	ctx := context.Background()
+	if resp, _ := srv.Insert(ctx, &nebulapb.InsertRequest{Id: "a", Vector: []float32{1, 0, 0}}); !resp.Success {
+		t.Fatalf("insert failed: %s", resp.Error)
+	}
+
+	for name, req := range map[string]*nebulapb.SearchRequest{
+		"dimension": {Vector: []float32{1, 0}, K: 1},
+		"packed":    {PackedVector: []byte{1, 2, 3}, Encoding: nebulapb.VectorEncoding_FLOAT16, K: 1},
+		"sparse":    {Sparse: &nebulapb.SparseVector{Indices: []uint32{1}, Values: []float32{1, 2}}, K: 1},
+		"text":      {TextQuery: "!!", K: 1},
+	} {
+		if _, err := srv.Search(ctx, req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%s: got %v, want InvalidArgument", name, err)
+		}
+	}
+}
+
+// A binary vector comes back from Get in as many bytes as it went in, and
+// scores count only those bits.
+func TestServer_BinaryLength(t *testing.T) {
+	wal, err := storage.OpenWAL(filepath.Join(t.TempDir(), "nebula.wal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wal.Close()
+	cfg := index.DefaultConfig()
+	cfg.Metric = index.MetricHamming
+	srv := NewServer(index.NewHNSW(cfg), index.NewSparseIndex(), index.NewTextIndex(), wal)
+	ctx := context.Background()
+
+	packed := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+	other := append([]byte(nil), packed...)
+	other[11] ^= 0x80
+	for id, p := range map[string][]byte{"a": packed, "b": other} {
+		resp, _ := srv.Insert(ctx, &nebulapb.InsertRequest{Id: id, PackedVector: p, Encoding: nebulapb.VectorEncoding_BINARY})
+		if !resp.Success {
+			t.Fatalf("insert %s: %s", id, resp.Error)
+		}
+	}
+
+	got, err := srv.Get(ctx, &nebulapb.GetRequest{Id: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.PackedVector, packed) {
+		t.Errorf("Get() = %v, want %v", got.PackedVector, packed)
+	}
+
+	res, err := srv.Search(ctx, &nebulapb.SearchRequest{K: 2, PackedVector: packed, Encoding: nebulapb.VectorEncoding_BINARY})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Matches) != 2 || res.Matches[1].Score != 1-1.0/96 {
+		t.Errorf("Search() = %v, want b at %v", res.Matches, 1-1.0/96)
+	}
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sandeep89846/nebuladb/api/proto/nebulapb"
+	"github.com/sandeep89846/nebuladb/internal/index"
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+// hybridSearch queries every index the request has a query for (HNSW,
+// sparse, text) with an enlarged k and fuses the result lists according to
+// req.Hybrid. A partial dense result is fused as is and reported with
+// index.ErrPartial.
+func (s *Server) hybridSearch(ctx context.Context, req *nebulapb.SearchRequest, sp vec.SparseVector) ([]index.Match, error) {
+	opts := req.Hybrid
+	if opts == nil {
+		opts = &nebulapb.HybridOptions{}
+	}
+
+	k := int(req.K)
+	candidates := int(opts.Candidates)
+	if candidates < k {
+		candidates = 4 * k
+	}
+	if limit := s.limits.MaxK; limit > 0 && candidates > limit {
+		candidates = max(k, limit)
+	}
+
+	defaultWeights := opts.DenseWeight == 0 && opts.SparseWeight == 0 && opts.TextWeight == 0
+	weight := func(w float32) float32 {
+		if defaultWeights {
+			return 1
+		}
+		return w
+	}
+
+	var lists [][]index.Match
+	var weights []float32
+	var partial error
+
+	if len(req.Vector) != 0 || len(req.PackedVector) != 0 {
+		dense, err := s.denseSearch(ctx, req, candidates)
+		if errors.Is(err, index.ErrPartial) {
+			partial = err
+		} else if err != nil {
+			return nil, err
+		}
+		lists = append(lists, dense)
+		weights = append(weights, weight(opts.DenseWeight))
+	}
+	if sp.Len() != 0 {
+		sparse, err := s.sparse.SearchSparseContext(ctx, sp, candidates)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, sparse)
+		weights = append(weights, weight(opts.SparseWeight))
+	}
+	if req.TextQuery != "" {
+		text, err := s.text.SearchContext(ctx, req.TextQuery, candidates)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, text)
+		weights = append(weights, weight(opts.TextWeight))
+	}
+
+	method := index.FusionWeighted
+	if opts.Method == nebulapb.FusionMethod_RECIPROCAL_RANK {
+		method = index.FusionRRF
+	}
+
+	return index.Fuse(method, lists, weights, int(opts.RrfK), k), partial
+}
+
+func countTrue(bs ...bool) int {
+	n := 0
+	for _, b := range bs {
+		if b {
+			n++
+		}
+	}
+	return n
+}
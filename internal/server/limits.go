@@ -0,0 +1,38 @@
+package server
+
+import (
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Limits caps the work a single request may ask for. Zero fields are
+// unlimited. Concurrency and rate limits live in package admission.
+type Limits struct {
+	MaxK         int // results per search, and hybrid candidates
+	MaxDimension int // dense dimensions, bits, or sparse non-zeros
+}
+
+// SetLimits installs per-request limits. Call before serving.
+func (s *Server) SetLimits(l Limits) { s.limits = l }
+
+// checkK rejects a k below 1 or above MaxK.
+func (s *Server) checkK(k int) error {
+	if k < 1 {
+		return status.Errorf(codes.InvalidArgument, "k must be at least 1, got %d", k)
+	}
+	if s.limits.MaxK > 0 && k > s.limits.MaxK {
+		return status.Errorf(codes.InvalidArgument, "k %d exceeds the limit of %d", k, s.limits.MaxK)
+	}
+	return nil
+}
+
+// checkDim rejects vectors with more than MaxDimension components. The
+// error is a plain one so Insert can report it in its response.
+func (s *Server) checkDim(n int) error {
+	if s.limits.MaxDimension > 0 && n > s.limits.MaxDimension {
+		return fmt.Errorf("vector dimension %d exceeds the limit of %d", n, s.limits.MaxDimension)
+	}
+	return nil
+}
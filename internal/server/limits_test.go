@@ -0,0 +1,52 @@
+package server
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sandeep89846/nebuladb/api/proto/nebulapb"
+	"github.com/sandeep89846/nebuladb/internal/index"
+	"github.com/sandeep89846/nebuladb/internal/storage"
+)
+
+func TestServer_Limits(t *testing.T) {
+	wal, err := storage.OpenWAL(filepath.Join(t.TempDir(), "nebula.wal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wal.Close()
+	srv := NewServer(index.NewHNSW(index.DefaultConfig()), index.NewSparseIndex(), index.NewTextIndex(), wal)
+	srv.SetLimits(Limits{MaxK: 10, MaxDimension: 4})
+	ctx := context.Background()
+
+	resp, _ := srv.Insert(ctx, &nebulapb.InsertRequest{Id: "big", Vector: make([]float32, 5)})
+	if resp.Success {
+		t.Error("insert above max_dimension succeeded")
+	}
+	resp, _ = srv.Insert(ctx, &nebulapb.InsertRequest{Id: "ok", Vector: []float32{1, 0, 0, 0}})
+	if !resp.Success {
+		t.Fatalf("insert within limits failed: %s", resp.Error)
+	}
+
+	tests := []struct {
+		req  *nebulapb.SearchRequest
+		want codes.Code
+	}{
+		{&nebulapb.SearchRequest{Vector: []float32{1, 0, 0, 0}, K: 10}, codes.OK},
+		{&nebulapb.SearchRequest{Vector: []float32{1, 0, 0, 0}, K: 11}, codes.InvalidArgument},
+		{&nebulapb.SearchRequest{Vector: []float32{1, 0, 0, 0}, K: 0}, codes.InvalidArgument},
+		{&nebulapb.SearchRequest{Vector: []float32{1, 0, 0, 0}, K: -1}, codes.InvalidArgument},
+		{&nebulapb.SearchRequest{Vector: []float32{1, 0, 0, 0}, K: 1, Ef: -1}, codes.InvalidArgument},
+		{&nebulapb.SearchRequest{Vector: make([]float32, 5), K: 1}, codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		_, err := srv.Search(ctx, tt.req)
+		if got := status.Code(err); got != tt.want {
+			t.Errorf("Search(k=%d, dim=%d) = %v, want %v", tt.req.K, len(tt.req.Vector), got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sandeep89846/nebuladb/api/proto/nebulapb"
+	"github.com/sandeep89846/nebuladb/internal/index"
+	"github.com/sandeep89846/nebuladb/internal/storage"
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+// NewRestoringServer returns a Server with no indexes yet. It can be
+// registered and served straight away: VectorService calls fail with
+// Unavailable until Restore completes, while health checks and reflection
+// keep working.
+func NewRestoringServer(wal *storage.WAL) *Server {
+	return &Server{wal: wal}
+}
+
+// replayBatch is the most WAL inserts indexed together during Restore.
+const replayBatch = 4096
+
+// Restore loads the snapshot at snapshotPath, if there is one, replays the
+// WAL on top of it and marks the server ready. cfg configures the dense
+// index. It returns the number of records replayed.
+func (s *Server) Restore(snapshotPath string, cfg index.Config) (int, error) {
+	idx, sparse, text, err := LoadSnapshot(snapshotPath, cfg)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		idx = index.NewHNSW(cfg)
+		sparse = index.NewSparseIndex()
+		text = index.NewTextIndex()
+	case err != nil:
+		return 0, err
+	default:
+		log.Printf(" Loaded snapshot %s", snapshotPath)
+	}
+
+	// Dense and binary inserts are indexed in parallel batches. A delete
+	// flushes the pending batch first, as it may undo one of its inserts.
+	var (
+		count  int
+		ids    []string
+		vs     []vec.Vector
+		bits   []vec.BitVector
+		bitLen int // of every vector in bits
+	)
+	flush := func() {
+		var errs []error
+		if len(vs) > 0 {
+			errs = idx.InsertBatch(context.Background(), ids, vs, 0)
+		} else if len(bits) > 0 {
+			errs = idx.InsertBinaryBatch(context.Background(), ids, bits, bitLen, 0)
+		}
+		for i, err := range errs {
+			if err != nil {
+				log.Printf("Replay error for ID %s: %v", ids[i], err)
+			}
+		}
+		ids, vs, bits = ids[:0], vs[:0], bits[:0]
+	}
+	err = s.wal.ReplayRecords(func(r storage.Record) error {
+		var err error
+		switch r.Op {
+		case storage.OpInsert:
+			if len(bits) > 0 || len(ids) == replayBatch {
+				flush()
+			}
+			ids, vs = append(ids, r.ID), append(vs, r.Vector)
+		case storage.OpInsertBinary:
+			if len(vs) > 0 || len(ids) == replayBatch || (len(bits) > 0 && r.BitLen != bitLen) {
+				flush()
+			}
+			ids, bits, bitLen = append(ids, r.ID), append(bits, r.Bits), r.BitLen
+		case storage.OpInsertSparse:
+			err = sparse.InsertSparse(r.ID, r.Sparse)
+		case storage.OpInsertText:
+			err = text.Insert(r.ID, r.Text)
+		case storage.OpDelete:
+			flush()
+			idx.Delete(r.ID)
+			sparse.Delete(r.ID)
+			text.Delete(r.ID)
+		default:
+			return nil
+		}
+		if err != nil {
+			log.Printf("Replay error for ID %s: %v", r.ID, err)
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+	flush()
+
+	s.idx, s.sparse, s.text = idx, sparse, text
+	s.snapshotPath = snapshotPath
+	s.ready.Store(true) // publishes the fields above to the handlers
+	return count, nil
+}
+
+// Ready reports whether the indexes are loaded.
+func (s *Server) Ready() bool { return s.ready.Load() }
+
+// Indexes returns the indexes being served. Call once Ready.
+func (s *Server) Indexes() (*index.HNSW, *index.SparseIndex, *index.TextIndex) {
+	return s.idx, s.sparse, s.text
+}
+
+var errNotReady = status.Error(codes.Unavailable, "server is restoring its state, retry later")
+
+// vectorServicePrefix matches the full method names of VectorService.
+var vectorServicePrefix = "/" + nebulapb.VectorService_ServiceDesc.ServiceName + "/"
+
+// UnaryInterceptor rejects VectorService calls until the server is ready.
+func (s *Server) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !s.ready.Load() && strings.HasPrefix(info.FullMethod, vectorServicePrefix) {
+			return nil, errNotReady
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor is UnaryInterceptor for streaming calls.
+func (s *Server) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !s.ready.Load() && strings.HasPrefix(info.FullMethod, vectorServicePrefix) {
+			return errNotReady
+		}
+		return handler(srv, ss)
+	}
+}
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/sandeep89846/nebuladb/api/proto/nebulapb"
+	"github.com/sandeep89846/nebuladb/internal/index"
+	"github.com/sandeep89846/nebuladb/internal/storage"
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+func TestServer_UnavailableUntilRestored(t *testing.T) {
+	dir := t.TempDir()
+	walPath := filepath.Join(dir, "nebula.wal")
+
+	w, err := storage.OpenWAL(walPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.WriteInsert("a", vec.Vector{1, 0, 0})
+	w.WriteInsert("b", vec.Vector{0, 1, 0})
+	w.WriteInsertText("a", "hello world")
+	w.Close()
+
+	wal, err := storage.OpenWAL(walPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wal.Close()
+
+	srv := NewRestoringServer(wal)
+	gs := grpc.NewServer(
+		grpc.UnaryInterceptor(srv.UnaryInterceptor()),
+		grpc.StreamInterceptor(srv.StreamInterceptor()),
+	)
+	nebulapb.RegisterVectorServiceServer(gs, srv)
+	lis := bufconn.Listen(1 << 20)
+	go gs.Serve(lis)
+	defer gs.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := nebulapb.NewVectorServiceClient(conn)
+
+	req := &nebulapb.SearchRequest{Vector: []float32{1, 0, 0}, K: 1}
+	if _, err := client.Search(context.Background(), req); status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected Unavailable before restore, got %v", err)
+	}
+
+	count, err := srv.Restore(filepath.Join(dir, "nebula.snap"), index.DefaultConfig())
+	if err != nil || count != 3 {
+		t.Fatalf("Restore = %d, %v", count, err)
+	}
+	st, _ := os.Stat(walPath)
+	if p := wal.ReplayProgress(); p.Records != 3 || p.Bytes != uint64(st.Size()) || p.TotalBytes != p.Bytes {
+		t.Errorf("unexpected replay progress %+v for a %d byte WAL", p, st.Size())
+	}
+
+	resp, err := client.Search(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Search after restore failed: %v", err)
+	}
+	if len(resp.Matches) != 1 || resp.Matches[0].Id != "a" {
+		t.Errorf("expected a, got %v", resp.Matches)
+	}
+}
+
+func TestServer_DeleteSurvivesRestart(t *testing.T) {
+	walPath := filepath.Join(t.TempDir(), "nebula.wal")
+	wal, err := storage.OpenWAL(walPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(index.NewHNSW(index.DefaultConfig()), index.NewSparseIndex(), index.NewTextIndex(), wal)
+	ctx := context.Background()
+	srv.Insert(ctx, &nebulapb.InsertRequest{Id: "a", Vector: []float32{1, 0}, Text: "hello"})
+	srv.Insert(ctx, &nebulapb.InsertRequest{Id: "b", Vector: []float32{0, 1}})
+	if _, err := srv.Delete(ctx, &nebulapb.DeleteRequest{Id: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.Delete(ctx, &nebulapb.DeleteRequest{Id: "a"}); status.Code(err) != codes.NotFound {
+		t.Errorf("second delete: %v, want NotFound", err)
+	}
+	// replay batches inserts; the delete must still land between these two
+	srv.Delete(ctx, &nebulapb.DeleteRequest{Id: "b"})
+	srv.Insert(ctx, &nebulapb.InsertRequest{Id: "b", Vector: []float32{1, 1}})
+	wal.Close()
+
+	wal, err = storage.OpenWAL(walPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wal.Close()
+	restored := NewRestoringServer(wal)
+	if _, err := restored.Restore(filepath.Join(t.TempDir(), "none.snap"), index.DefaultConfig()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := restored.Get(ctx, &nebulapb.GetRequest{Id: "a"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Get(a) after restart: %v, want NotFound", err)
+	}
+	if got, err := restored.Get(ctx, &nebulapb.GetRequest{Id: "b"}); err != nil || got.Vector[0] != got.Vector[1] {
+		t.Errorf("Get(b) after restart = %v, %v, want the re-inserted vector", got, err)
+	}
+	if restored.text.Has("a") {
+		t.Error("deleted text survived the restart")
+	}
+}
+
+func TestServer_SnapshotAndStats(t *testing.T) {
+	dir := t.TempDir()
+	walPath, snapPath := filepath.Join(dir, "nebula.wal"), filepath.Join(dir, "nebula.snap")
+	wal, err := storage.OpenWAL(walPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewRestoringServer(wal)
+	if _, err := srv.Restore(snapPath, index.DefaultConfig()); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	srv.Insert(ctx, &nebulapb.InsertRequest{Id: "a", Vector: []float32{1, 0}, Text: "hello"})
+	srv.Insert(ctx, &nebulapb.InsertRequest{Id: "b", Vector: []float32{0, 1}})
+	srv.Delete(ctx, &nebulapb.DeleteRequest{Id: "b"})
+
+	st, err := srv.Stats(ctx, &nebulapb.StatsRequest{})
+	if err != nil || st.Vectors != 1 || st.Deleted != 1 || st.Dimension != 2 || st.TextDocuments != 1 || st.WalRecords != 4 {
+		t.Errorf("Stats = %v, %v", st, err)
+	}
+
+	snap, err := srv.Snapshot(ctx, &nebulapb.SnapshotRequest{})
+	if err != nil || snap.Path != snapPath || snap.SizeBytes == 0 {
+		t.Fatalf("Snapshot = %v, %v", snap, err)
+	}
+	if fi, _ := os.Stat(walPath); fi.Size() != 0 {
+		t.Errorf("WAL is %d bytes after snapshot, want 0", fi.Size())
+	}
+	wal.Close()
+
+	wal, err = storage.OpenWAL(walPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wal.Close()
+	restored := NewRestoringServer(wal)
+	if n, err := restored.Restore(snapPath, index.DefaultConfig()); err != nil || n != 0 {
+		t.Fatalf("Restore = %d, %v", n, err)
+	}
+	if _, err := restored.Get(ctx, &nebulapb.GetRequest{Id: "a"}); err != nil {
+		t.Errorf("Get(a) from snapshot: %v", err)
+	}
+
+	if _, err := NewServer(index.NewHNSW(index.DefaultConfig()), index.NewSparseIndex(), index.NewTextIndex(), wal).
+		Snapshot(ctx, &nebulapb.SnapshotRequest{}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Snapshot without a path: %v", err)
+	}
+}
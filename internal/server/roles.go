@@ -0,0 +1,24 @@
+package server
+
+import (
+	"github.com/sandeep89846/nebuladb/api/proto/nebulapb"
+	"github.com/sandeep89846/nebuladb/internal/auth"
+)
+
+// MethodRoles is the role each RPC requires when API keys are enabled.
+// Anything missing here, including future operational RPCs, needs admin.
+var MethodRoles = auth.MethodRoles{
+	nebulapb.VectorService_Search_FullMethodName:     auth.RoleRead,
+	nebulapb.VectorService_Get_FullMethodName:        auth.RoleRead,
+	nebulapb.VectorService_Insert_FullMethodName:     auth.RoleWrite,
+	nebulapb.VectorService_Delete_FullMethodName:     auth.RoleWrite,
+	nebulapb.VectorService_BulkInsert_FullMethodName: auth.RoleWrite,
+	nebulapb.VectorService_Stats_FullMethodName:      auth.RoleRead,
+	nebulapb.VectorService_Export_FullMethodName:     auth.RoleRead,
+	// Snapshot blocks writes for its duration.
+	nebulapb.VectorService_Snapshot_FullMethodName: auth.RoleAdmin,
+
+	// Reflection exposes the schema only, so any valid key may use it.
+	"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo":      auth.RoleRead,
+	"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo": auth.RoleRead,
+}
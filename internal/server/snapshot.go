@@ -0,0 +1,68 @@
+package server
+
+import (
+	"io"
+
+	"github.com/sandeep89846/nebuladb/internal/index"
+	"github.com/sandeep89846/nebuladb/internal/storage"
+)
+
+// Checkpoint writes a snapshot of every index to path and then empties the
+// WAL, whose records the snapshot now covers. Writes must be stopped for
+// the duration, otherwise records between the dump and the truncate are
+// lost; Checkpoint blocks Insert and Delete until it returns.
+//
+// A crash after the snapshot lands but before the truncate leaves records
+// that are replayed on top of the snapshot. Re-inserts of existing HNSW
+// ids are rejected and sparse/text re-inserts overwrite, so that replay is
+// harmless.
+func (s *Server) Checkpoint(path string) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if err := s.wal.Sync(); err != nil {
+		return err
+	}
+	if err := WriteSnapshot(path, s.idx, s.sparse, s.text); err != nil {
+		return err
+	}
+	return s.wal.Truncate()
+}
+
+// WriteSnapshot writes the indexes to path in the layout LoadSnapshot
+// reads. The indexes must not change while it runs.
+func WriteSnapshot(path string, idx *index.HNSW, sparse *index.SparseIndex, text *index.TextIndex) error {
+	return storage.WriteSnapshot(path, func(w io.Writer) error {
+		if err := idx.Save(w); err != nil {
+			return err
+		}
+		if err := sparse.Save(w); err != nil {
+			return err
+		}
+		return text.Save(w)
+	})
+}
+
+// LoadSnapshot restores the indexes written by Checkpoint. cfg configures
+// the dense index; see index.LoadHNSW.
+func LoadSnapshot(path string, cfg index.Config) (*index.HNSW, *index.SparseIndex, *index.TextIndex, error) {
+	var (
+		idx    *index.HNSW
+		sparse *index.SparseIndex
+		text   *index.TextIndex
+	)
+	err := storage.ReadSnapshot(path, func(r io.Reader) error {
+		var err error
+		if idx, err = index.LoadHNSW(r, cfg); err != nil {
+			return err
+		}
+		if sparse, err = index.LoadSparseIndex(r); err != nil {
+			return err
+		}
+		text, err = index.LoadTextIndex(r)
+		return err
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return idx, sparse, text, nil
+}
@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// snapshotMagic starts every snapshot file.
+const snapshotMagic = "NEBSNAP\x00"
+
+var ErrSnapshotCorrupt = errors.New("snapshot checksum mismatch")
+
+// WriteSnapshot atomically replaces the file at path with the bytes fn
+// writes. Format: [Magic(8)][Body(...)][CRC(4)], the CRC covering the body.
+// The body goes to a temporary file that is fsynced and renamed over path,
+// so a crash leaves either the old snapshot or the new one.
+func WriteSnapshot(path string, fn func(w io.Writer) error) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp) // no-op after a successful rename
+
+	crc := crc32.NewIEEE()
+	bw := bufio.NewWriter(f)
+	if _, err := bw.WriteString(snapshotMagic); err != nil {
+		f.Close()
+		return err
+	}
+	if err := fn(io.MultiWriter(bw, crc)); err != nil {
+		f.Close()
+		return err
+	}
+	if err := binary.Write(bw, binary.LittleEndian, crc.Sum32()); err != nil {
+		f.Close()
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+// ReadSnapshot opens the snapshot at path and passes its body to fn. fn
+// must consume the whole body; the checksum is verified afterwards, and
+// ErrSnapshotCorrupt is returned on mismatch even if fn succeeded. A
+// missing file returns an error matching os.ErrNotExist.
+func ReadSnapshot(path string, fn func(r io.Reader) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	st, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	bodyLen := st.Size() - int64(len(snapshotMagic)) - 4
+	if bodyLen < 0 {
+		return fmt.Errorf("snapshot %s: file too short", path)
+	}
+
+	br := bufio.NewReader(f)
+	magic := make([]byte, len(snapshotMagic))
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return err
+	}
+	if string(magic) != snapshotMagic {
+		return fmt.Errorf("snapshot %s: bad magic", path)
+	}
+
+	crc := crc32.NewIEEE()
+	body := &checksumReader{r: bufio.NewReader(io.LimitReader(br, bodyLen)), h: crc}
+	if err := fn(body); err != nil {
+		return fmt.Errorf("snapshot %s: %v", path, err)
+	}
+	if body.n != bodyLen {
+		return fmt.Errorf("snapshot %s: %d trailing bytes", path, bodyLen-body.n)
+	}
+
+	var want uint32
+	if err := binary.Read(br, binary.LittleEndian, &want); err != nil {
+		return fmt.Errorf("snapshot %s: read crc: %v", path, err)
+	}
+	if crc.Sum32() != want {
+		return ErrSnapshotCorrupt
+	}
+	return nil
+}
+
+// checksumReader hashes everything read through it.
+type checksumReader struct {
+	r io.Reader
+	h hash.Hash32
+	n int64
+}
+
+func (c *checksumReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.h.Write(p[:n])
+	c.n += int64(n)
+	return n, err
+}
+
+// syncDir fsyncs a directory so a rename inside it is durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSnapshot_RoundTripAndCorruption(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nebula.snap")
+	body := []byte("index state")
+
+	err := WriteSnapshot(path, func(w io.Writer) error {
+		_, err := w.Write(body)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("WriteSnapshot failed: %v", err)
+	}
+
+	var got []byte
+	err = ReadSnapshot(path, func(r io.Reader) error {
+		got, err = io.ReadAll(r)
+		return err
+	})
+	if err != nil || string(got) != string(body) {
+		t.Fatalf("ReadSnapshot = %q, %v", got, err)
+	}
+
+	// flip a body byte
+	raw, _ := os.ReadFile(path)
+	raw[len(snapshotMagic)] ^= 0xff
+	os.WriteFile(path, raw, 0644)
+	err = ReadSnapshot(path, func(r io.Reader) error {
+		_, err := io.ReadAll(r)
+		return err
+	})
+	if !errors.Is(err, ErrSnapshotCorrupt) {
+		t.Errorf("expected ErrSnapshotCorrupt, got %v", err)
+	}
+
+	err = ReadSnapshot(filepath.Join(t.TempDir(), "missing"), func(io.Reader) error { return nil })
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected ErrNotExist, got %v", err)
+	}
+}
+
+func TestWAL_Truncate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wal.bin")
+	wal, err := OpenWAL(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wal.Close()
+
+	wal.WriteInsertText("a", "before")
+	if err := wal.Truncate(); err != nil {
+		t.Fatalf("Truncate failed: %v", err)
+	}
+	wal.WriteInsertText("b", "after")
+
+	var ids []string
+	wal.ReplayRecords(func(r Record) error {
+		ids = append(ids, r.ID)
+		return nil
+	})
+	if len(ids) != 1 || ids[0] != "b" {
+		t.Errorf("expected only the post-truncate record, got %v", ids)
+	}
+}
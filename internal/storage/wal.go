@@ -9,50 +9,361 @@ import (
 	"math"
 	"os"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/sandeep89846/nebuladb/pkg/vec"
 )
 
 const (
-	OpInsert = 1
-	OpDelete = 2
+	OpInsert       = 1
+	OpDelete       = 2
+	OpInsertBinary = 3
+	OpInsertSparse = 4
+	OpInsertText   = 5
 )
 
+// OpName returns a short lowercase name for op, for tools and logs.
+func OpName(op byte) string {
+	switch op {
+	case OpInsert:
+		return "insert"
+	case OpDelete:
+		return "delete"
+	case OpInsertBinary:
+		return "insert-binary"
+	case OpInsertSparse:
+		return "insert-sparse"
+	case OpInsertText:
+		return "insert-text"
+	}
+	return fmt.Sprintf("op(%d)", op)
+}
+
+// Record is a single decoded WAL entry.
+type Record struct {
+	Op     byte
+	ID     string
+	Vector vec.Vector       // OpInsert
+	Bits   vec.BitVector    // OpInsertBinary
+	BitLen int              // OpInsertBinary: the length of Bits in bits
+	Sparse vec.SparseVector // OpInsertSparse
+	Text   string           // OpInsertText
+}
+
+// SyncPolicy controls when the WAL fsyncs. Every record is always flushed
+// to the OS before the write returns; the policy decides when it is forced
+// to stable storage.
+type SyncPolicy int
+
+const (
+	SyncNone     SyncPolicy = iota // leave it to the OS until Close
+	SyncAlways                     // fsync after every record
+	SyncInterval                   // fsync from a background ticker
+)
+
+func (p SyncPolicy) String() string {
+	switch p {
+	case SyncNone:
+		return "none"
+	case SyncAlways:
+		return "always"
+	case SyncInterval:
+		return "interval"
+	}
+	return fmt.Sprintf("SyncPolicy(%d)", int(p))
+}
+
+// ParseSyncPolicy is the inverse of SyncPolicy.String.
+func ParseSyncPolicy(s string) (SyncPolicy, error) {
+	for _, p := range []SyncPolicy{SyncNone, SyncAlways, SyncInterval} {
+		if p.String() == s {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown sync policy %q", s)
+}
+
+type WALOptions struct {
+	Sync         SyncPolicy
+	SyncInterval time.Duration // used by SyncInterval
+}
+
 type WAL struct {
 	file *os.File
 	bw   *bufio.Writer
 	mu   sync.Mutex
+
+	opts  WALOptions
+	dirty bool          // written since the last fsync
+	stop  chan struct{} // closes the SyncInterval goroutine
+	done  chan struct{}
+
+	bytesWritten atomic.Uint64
+	records      atomic.Uint64
+	syncs        atomic.Uint64
+	onSync       atomic.Pointer[func(time.Duration)]
+
+	replayRecords atomic.Uint64
+	replayBytes   atomic.Uint64
+	replayTotal   atomic.Uint64
+}
+
+// ReplayProgress reports how far the current or last replay has got.
+type ReplayProgress struct {
+	Records    uint64
+	Bytes      uint64
+	TotalBytes uint64 // file size when the replay started
+}
+
+// ReplayProgress may be called from any goroutine while ReplayRecords runs.
+func (w *WAL) ReplayProgress() ReplayProgress {
+	return ReplayProgress{
+		Records:    w.replayRecords.Load(),
+		Bytes:      w.replayBytes.Load(),
+		TotalBytes: w.replayTotal.Load(),
+	}
+}
+
+// WALStats are cumulative counters since the WAL was opened.
+type WALStats struct {
+	BytesWritten uint64 // framed record bytes, CRC included
+	Records      uint64
+	Syncs        uint64
+}
+
+func (w *WAL) Stats() WALStats {
+	return WALStats{
+		BytesWritten: w.bytesWritten.Load(),
+		Records:      w.records.Load(),
+		Syncs:        w.syncs.Load(),
+	}
+}
+
+// SetSyncObserver registers fn to be called with the duration of every
+// fsync. It runs with the WAL locked and must be fast. Pass nil to remove it.
+func (w *WAL) SetSyncObserver(fn func(time.Duration)) {
+	if fn == nil {
+		w.onSync.Store(nil)
+		return
+	}
+	w.onSync.Store(&fn)
+}
+
+// fsync syncs the file and reports the latency. Callers hold w.mu.
+func (w *WAL) fsync() error {
+	start := time.Now()
+	err := w.file.Sync()
+	w.syncs.Add(1)
+	if fn := w.onSync.Load(); fn != nil {
+		(*fn)(time.Since(start))
+	}
+	return err
 }
 
 func OpenWAL(path string) (*WAL, error) {
+	return OpenWALWithOptions(path, WALOptions{})
+}
+
+func OpenWALWithOptions(path string, opts WALOptions) (*WAL, error) {
+	if opts.Sync == SyncInterval && opts.SyncInterval <= 0 {
+		return nil, fmt.Errorf("sync interval must be positive")
+	}
+
 	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0644)
 	if err != nil {
 		return nil, err
 	}
 
-	return &WAL{
+	w := &WAL{
 		file: f,
 		bw:   bufio.NewWriter(f),
-	}, nil
+		opts: opts,
+	}
+	if opts.Sync == SyncInterval {
+		w.stop = make(chan struct{})
+		w.done = make(chan struct{})
+		go w.syncLoop()
+	}
+	return w, nil
+}
+
+// syncLoop fsyncs dirty data every SyncInterval until Close.
+func (w *WAL) syncLoop() {
+	defer close(w.done)
+	t := time.NewTicker(w.opts.SyncInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-t.C:
+			w.mu.Lock()
+			if w.dirty {
+				if err := w.fsync(); err == nil {
+					w.dirty = false
+				}
+			}
+			w.mu.Unlock()
+		}
+	}
+}
+
+// Sync flushes buffered data and fsyncs the file, regardless of policy.
+func (w *WAL) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.bw.Flush(); err != nil {
+		return err
+	}
+	if err := w.fsync(); err != nil {
+		return err
+	}
+	w.dirty = false
+	return nil
 }
 
 // WriteInsert appends an insertion record to the log.
 // Format: [CRC(4)][Op(1)][KeyLen(2)][KeyBytes(...)][VecLen(4)][VecBytes(...)]
 func (w *WAL) WriteInsert(id string, v vec.Vector) error {
+	return w.writeRecord(OpInsert, id, insertPayload(v))
+}
+
+func insertPayload(v vec.Vector) []byte {
+	vecLen := uint32(len(v))
+
+	payload := make([]byte, 4+int(vecLen)*4)
+	binary.LittleEndian.PutUint32(payload, vecLen)
+	offset := 4
+	for _, f := range v {
+		bits := mathFloat32bits(f)
+		binary.LittleEndian.PutUint32(payload[offset:], bits)
+		offset += 4
+	}
+	return payload
+}
+
+// WriteInsertBinary appends an insertion record for v, n bits long, to the
+// log.
+// Format: [CRC(4)][Op(1)][KeyLen(2)][KeyBytes(...)][BitLen(4)][Words(8 each)]
+func (w *WAL) WriteInsertBinary(id string, v vec.BitVector, n int) error {
+	return w.writeRecord(OpInsertBinary, id, binaryPayload(v, n))
+}
+
+func binaryPayload(v vec.BitVector, n int) []byte {
+	payload := make([]byte, 4+len(v)*8)
+	binary.LittleEndian.PutUint32(payload, uint32(n))
+	for i, word := range v {
+		binary.LittleEndian.PutUint64(payload[4+8*i:], word)
+	}
+	return payload
+}
+
+// WriteInsertSparse appends a sparse vector insertion record to the log.
+// Format: [CRC(4)][Op(1)][KeyLen(2)][KeyBytes(...)][NNZ(4)][(Index(4) Value(4))...]
+func (w *WAL) WriteInsertSparse(id string, v vec.SparseVector) error {
+	return w.writeRecord(OpInsertSparse, id, sparsePayload(v))
+}
+
+func sparsePayload(v vec.SparseVector) []byte {
+	payload := make([]byte, 4+v.Len()*8)
+	binary.LittleEndian.PutUint32(payload, uint32(v.Len()))
+	for i := range v.Indices {
+		binary.LittleEndian.PutUint32(payload[4+8*i:], v.Indices[i])
+		binary.LittleEndian.PutUint32(payload[8+8*i:], mathFloat32bits(v.Values[i]))
+	}
+	return payload
+}
+
+// WriteInsertText appends a text field record to the log.
+// Format: [CRC(4)][Op(1)][KeyLen(2)][KeyBytes(...)][TextLen(4)][TextBytes(...)]
+func (w *WAL) WriteInsertText(id, text string) error {
+	return w.writeRecord(OpInsertText, id, textPayload(text))
+}
+
+func textPayload(text string) []byte {
+	payload := make([]byte, 4+len(text))
+	binary.LittleEndian.PutUint32(payload, uint32(len(text)))
+	copy(payload[4:], text)
+	return payload
+}
+
+// WriteDelete appends a record removing id from every index.
+// Format: [CRC(4)][Op(1)][KeyLen(2)][KeyBytes(...)][Len(4) = 0]
+func (w *WAL) WriteDelete(id string) error {
+	return w.writeRecord(OpDelete, id, make([]byte, 4))
+}
+
+// WriteInserts appends dense insertion records for every id/vector pair
+// with a single flush and, under SyncAlways, a single fsync. It is the
+// bulk load counterpart of WriteInsert.
+func (w *WAL) WriteInserts(ids []string, vs []vec.Vector) error {
+	if len(ids) != len(vs) {
+		return fmt.Errorf("WriteInserts: %d ids for %d vectors", len(ids), len(vs))
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i, v := range vs {
+		if err := w.appendRecord(OpInsert, ids[i], insertPayload(v)); err != nil {
+			return err
+		}
+	}
+	return w.flush()
+}
+
+// WriteRecords appends recs with a single flush and, under SyncAlways, a
+// single fsync, so no other write lands between them. The records of one
+// call are acknowledged together: a write error fails them all.
+func (w *WAL) WriteRecords(recs []Record) error {
+	payloads := make([][]byte, len(recs))
+	for i, r := range recs {
+		switch r.Op {
+		case OpInsert:
+			payloads[i] = insertPayload(r.Vector)
+		case OpInsertBinary:
+			payloads[i] = binaryPayload(r.Bits, r.BitLen)
+		case OpInsertSparse:
+			payloads[i] = sparsePayload(r.Sparse)
+		case OpInsertText:
+			payloads[i] = textPayload(r.Text)
+		case OpDelete:
+			payloads[i] = make([]byte, 4)
+		default:
+			return fmt.Errorf("WriteRecords: unknown op %d", r.Op)
+		}
+	}
 	w.mu.Lock()
 	defer w.mu.Unlock()
+	for i, r := range recs {
+		if err := w.appendRecord(r.Op, r.ID, payloads[i]); err != nil {
+			return err
+		}
+	}
+	return w.flush()
+}
 
+// writeRecord appends one record and flushes it to the file.
+func (w *WAL) writeRecord(op byte, id string, payload []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.appendRecord(op, id, payload); err != nil {
+		return err
+	}
+	return w.flush()
+}
+
+// appendRecord frames [Op][KeyLen][Key][payload] and prefixes the CRC into
+// the write buffer. Callers hold w.mu.
+func (w *WAL) appendRecord(op byte, id string, payload []byte) error {
 	// prepare data.
 	keyBytes := []byte(id)
 	keyLen := uint16(len(keyBytes))
-	vecLen := uint32(len(v))
 
-	// Total size = 1(Op) + 2(KeyLen) + len(key) + 4(VecLen) + len(vec)*4
-	payloadSize := 1 + 2 + len(keyBytes) + 4 + (int(vecLen) * 4)
-	buf := make([]byte, payloadSize)
+	// Total size = 1(Op) + 2(KeyLen) + len(key) + len(payload)
+	buf := make([]byte, 1+2+len(keyBytes)+len(payload))
 
 	offset := 0
-	buf[offset] = OpInsert
+	buf[offset] = op
 	offset++
 
 	// precautionary: using little endian.
@@ -62,14 +373,7 @@ func (w *WAL) WriteInsert(id string, v vec.Vector) error {
 	copy(buf[offset:], keyBytes)
 	offset += len(keyBytes)
 
-	binary.LittleEndian.PutUint32(buf[offset:], vecLen)
-	offset += 4
-
-	for _, f := range v {
-		bits := mathFloat32bits(f)
-		binary.LittleEndian.PutUint32(buf[offset:], bits)
-		offset += 4
-	}
+	copy(buf[offset:], payload)
 
 	crc := crc32.ChecksumIEEE(buf)
 
@@ -80,73 +384,108 @@ func (w *WAL) WriteInsert(id string, v vec.Vector) error {
 	if _, err := w.bw.Write(buf); err != nil {
 		return err
 	}
+	w.bytesWritten.Add(uint64(4 + len(buf)))
+	w.records.Add(1)
+	return nil
+}
 
-	return w.bw.Flush()
+// flush hands buffered records to the OS and applies the sync policy.
+// Callers hold w.mu.
+func (w *WAL) flush() error {
+	if err := w.bw.Flush(); err != nil {
+		return err
+	}
+	if w.opts.Sync == SyncAlways {
+		return w.fsync()
+	}
+	w.dirty = true
+	return nil
 }
 
+// Truncate discards every record and fsyncs the now-empty file. Call it
+// only once the records are covered by a durable snapshot.
+func (w *WAL) Truncate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.bw.Flush(); err != nil {
+		return err
+	}
+	if err := w.file.Truncate(0); err != nil {
+		return err
+	}
+	if err := w.fsync(); err != nil {
+		return err
+	}
+	w.dirty = false
+	return nil
+}
+
+// Close flushes and fsyncs anything written since the last fsync, whatever
+// the sync policy, then closes the file.
 func (w *WAL) Close() error {
+	if w.stop != nil {
+		close(w.stop)
+		<-w.done
+	}
+
 	w.mu.Lock()
 	defer w.mu.Unlock()
 	if err := w.bw.Flush(); err != nil {
 		return err
 	}
+	if w.dirty {
+		if err := w.fsync(); err != nil {
+			return err
+		}
+	}
 	return w.file.Close()
 }
 
-// Replay calls the callback function for every valid entry in the WAL.
+// Replay calls the callback function for every valid dense insert in the WAL.
 // This is used on startup to rebuild the index.
 func (w *WAL) Replay(onInsert func(id string, v vec.Vector)) error {
+	return w.ReplayRecords(func(r Record) error {
+		if r.Op == OpInsert {
+			onInsert(r.ID, r.Vector)
+		}
+		return nil
+	})
+}
+
+// ReplayRecords calls fn for every entry in the WAL, in order. Replay stops
+// at the first error returned by fn, and at the first record that is torn
+// or fails its checksum, with a *CorruptError.
+func (w *WAL) ReplayRecords(fn func(Record) error) error {
 	// Need to read from the start
 	if _, err := w.file.Seek(0, 0); err != nil {
 		return err
 	}
+	st, err := w.file.Stat()
+	if err != nil {
+		return err
+	}
 
-	br := bufio.NewReader(w.file)
+	w.replayRecords.Store(0)
+	w.replayBytes.Store(0)
+	w.replayTotal.Store(uint64(st.Size()))
 
+	r := newWALReader(w.file, st.Size())
 	for {
-
-		var crc uint32
-		err := binary.Read(br, binary.LittleEndian, &crc)
+		e, err := r.next()
 		if err == io.EOF {
 			break
 		}
-		if err != nil {
-			return fmt.Errorf("read crc: %v", err)
+		if err == nil && !e.CRCOK {
+			err = &CorruptError{Offset: e.Offset, Err: ErrChecksum}
 		}
-
-		op, err := br.ReadByte()
 		if err != nil {
-			return fmt.Errorf("read op: %v", err)
+			return err
 		}
-
-		var keyLen uint16
-		if err := binary.Read(br, binary.LittleEndian, &keyLen); err != nil {
-			return fmt.Errorf("read key len: %v", err)
-		}
-
-		keyBytes := make([]byte, keyLen)
-		if _, err := io.ReadFull(br, keyBytes); err != nil {
-			return fmt.Errorf("read key: %v", err)
-		}
-		id := string(keyBytes)
-
-		var vecLen uint32
-		if err := binary.Read(br, binary.LittleEndian, &vecLen); err != nil {
-			return fmt.Errorf("read vec len: %v", err)
-		}
-
-		v := make(vec.Vector, vecLen)
-		for i := 0; i < int(vecLen); i++ {
-			var bits uint32
-			if err := binary.Read(br, binary.LittleEndian, &bits); err != nil {
-				return fmt.Errorf("read vec data: %v", err)
-			}
-			v[i] = mathFloat32frombits(bits)
-		}
-
-		if op == OpInsert {
-			onInsert(id, v)
+		if err := fn(e.Record); err != nil {
+			return err
 		}
+		w.replayRecords.Add(1)
+		w.replayBytes.Add(uint64(e.Size))
 	}
 
 	// Reset pointer to end for appending
@@ -154,6 +493,19 @@ func (w *WAL) Replay(onInsert func(id string, v vec.Vector)) error {
 	return nil
 }
 
+// payloadLen is the size of a record body after its length field.
+func payloadLen(op byte, n uint32) int {
+	switch op {
+	case OpInsertText:
+		return int(n)
+	case OpInsertSparse:
+		return 8 * int(n)
+	case OpInsertBinary:
+		return 8 * ((int(n) + 63) / 64)
+	}
+	return 4 * int(n)
+}
+
 func mathFloat32bits(f float32) uint32 {
 	return math.Float32bits(f)
 }
@@ -2,6 +2,8 @@ package storage
 
 import (
 	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/sandeep89846/nebuladb/pkg/vec"
@@ -59,3 +61,88 @@ func TestWAL_WriteAndReplay(t *testing.T) {
 		t.Errorf("Expected 2 entries, got %d", replayedCount)
 	}
 }
+
+func TestWAL_ReplayRecords(t *testing.T) {
+
+	tmpFile := "test_wal_records.bin"
+	defer os.Remove(tmpFile)
+
+	wal, err := OpenWAL(tmpFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bits := vec.BitVector{0xdeadbeef, 42}
+	if err := wal.WriteInsert("dense", vec.Vector{1, 2}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := wal.WriteInsertBinary("binary", bits, 100); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	sparse := vec.SparseVector{Indices: []uint32{3, 90000}, Values: []float32{0.5, 2}}
+	if err := wal.WriteInsertSparse("sparse", sparse); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := wal.WriteInsertText("text", "hello wal"); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	var records []Record
+	err = wal.ReplayRecords(func(r Record) error {
+		records = append(records, r)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	wal.Close()
+
+	if len(records) != 4 {
+		t.Fatalf("Expected 4 entries, got %d", len(records))
+	}
+	if records[0].Op != OpInsert || len(records[0].Vector) != 2 {
+		t.Errorf("Unexpected dense record: %+v", records[0])
+	}
+	if records[1].Op != OpInsertBinary || records[1].ID != "binary" || records[1].BitLen != 100 ||
+		len(records[1].Bits) != 2 || records[1].Bits[0] != bits[0] || records[1].Bits[1] != bits[1] {
+		t.Errorf("Unexpected binary record: %+v", records[1])
+	}
+	if r := records[2]; r.Op != OpInsertSparse || r.Sparse.Len() != 2 ||
+		r.Sparse.Indices[1] != 90000 || r.Sparse.Values[0] != 0.5 {
+		t.Errorf("Unexpected sparse record: %+v", r)
+	}
+	if r := records[3]; r.Op != OpInsertText || r.ID != "text" || r.Text != "hello wal" {
+		t.Errorf("Unexpected text record: %+v", r)
+	}
+	var scanned []Record
+	err = ScanWAL(tmpFile, func(r Record) error {
+		scanned = append(scanned, r)
+		return nil
+	})
+	if err != nil || !reflect.DeepEqual(scanned, records) {
+		t.Errorf("ScanWAL = %+v, %v; want the replayed records", scanned, err)
+	}
+}
+
+func TestWAL_CloseSyncs(t *testing.T) {
+	for _, policy := range []SyncPolicy{SyncNone, SyncAlways} {
+		wal, err := OpenWALWithOptions(filepath.Join(t.TempDir(), "wal.bin"), WALOptions{Sync: policy})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := wal.WriteInsert("a", vec.Vector{1, 2}); err != nil {
+			t.Fatal(err)
+		}
+		synced := wal.Stats().Syncs
+		if err := wal.Close(); err != nil {
+			t.Fatal(err)
+		}
+		want := synced
+		if policy == SyncNone {
+			want++ // the write was left dirty
+		}
+		if got := wal.Stats().Syncs; got != want {
+			t.Errorf("%s: %d fsyncs after Close, want %d", policy, got, want)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+// ErrChecksum is the cause of a CorruptError for a record whose CRC does
+// not match its contents.
+var ErrChecksum = errors.New("checksum mismatch")
+
+// CorruptError reports a WAL record that cannot be used: its checksum is
+// wrong, or it is cut short, typically by a crash mid-write. Offset is
+// where the record starts; everything before it is intact.
+type CorruptError struct {
+	Offset int64
+	Err    error
+}
+
+func (e *CorruptError) Error() string {
+	return fmt.Sprintf("corrupt WAL record at offset %d: %v", e.Offset, e.Err)
+}
+
+func (e *CorruptError) Unwrap() error { return e.Err }
+
+// WALEntry is a record as laid out in the file.
+type WALEntry struct {
+	Offset int64
+	Size   int64 // framed bytes, CRC included
+	CRCOK  bool
+	Record Record
+	raw    []byte // the framed bytes, for RepairWAL
+}
+
+// walReader decodes framed records from the start of a WAL file.
+type walReader struct {
+	br   *bufio.Reader
+	off  int64
+	size int64 // file size; lengths running past it mean a torn record
+}
+
+func newWALReader(r io.Reader, size int64) *walReader {
+	return &walReader{br: bufio.NewReader(r), size: size}
+}
+
+// next returns the next record, whatever its checksum, io.EOF at the end of
+// the file, or a *CorruptError when the remaining bytes cannot be framed as
+// a record. Reading cannot continue after an error.
+func (r *walReader) next() (WALEntry, error) {
+	start := r.off
+	corrupt := func(format string, args ...any) (WALEntry, error) {
+		return WALEntry{}, &CorruptError{Offset: start, Err: fmt.Errorf(format, args...)}
+	}
+
+	// [CRC(4)][Op(1)][KeyLen(2)]
+	head := make([]byte, 7)
+	n, err := io.ReadFull(r.br, head)
+	if err == io.EOF {
+		return WALEntry{}, io.EOF
+	}
+	if err != nil {
+		return corrupt("torn record: %d trailing bytes", n)
+	}
+	keyLen := int64(binary.LittleEndian.Uint16(head[5:]))
+	if start+7+keyLen+4 > r.size {
+		return corrupt("torn record: key and length run past the end of the file")
+	}
+	// [Key][Len(4)]
+	mid := make([]byte, keyLen+4)
+	if _, err := io.ReadFull(r.br, mid); err != nil {
+		return corrupt("read key: %v", err)
+	}
+	op := head[4]
+	n32 := binary.LittleEndian.Uint32(mid[keyLen:])
+	size := 7 + keyLen + 4 + int64(payloadLen(op, n32))
+	if start+size > r.size {
+		return corrupt("torn record: %d-byte %s record runs past the end of the file", size, OpName(op))
+	}
+
+	raw := make([]byte, size)
+	copy(raw, head)
+	copy(raw[7:], mid)
+	if _, err := io.ReadFull(r.br, raw[7+len(mid):]); err != nil {
+		return corrupt("read payload: %v", err)
+	}
+	r.off += size
+
+	e := WALEntry{
+		Offset: start,
+		Size:   size,
+		CRCOK:  crc32.ChecksumIEEE(raw[4:]) == binary.LittleEndian.Uint32(raw),
+		raw:    raw,
+	}
+	e.Record = decodeRecord(op, string(mid[:keyLen]), n32, raw[7+len(mid):])
+	return e, nil
+}
+
+// decodeRecord builds a Record from a payload of n elements.
+func decodeRecord(op byte, id string, n uint32, p []byte) Record {
+	rec := Record{Op: op, ID: id}
+	switch op {
+	case OpInsertText:
+		rec.Text = string(p)
+	case OpInsertSparse:
+		rec.Sparse.Indices = make([]uint32, n)
+		rec.Sparse.Values = make([]float32, n)
+		for i := range rec.Sparse.Indices {
+			rec.Sparse.Indices[i] = binary.LittleEndian.Uint32(p[8*i:])
+			rec.Sparse.Values[i] = mathFloat32frombits(binary.LittleEndian.Uint32(p[8*i+4:]))
+		}
+	case OpInsertBinary:
+		rec.Bits = make(vec.BitVector, (n+63)/64)
+		rec.BitLen = int(n)
+		for i := range rec.Bits {
+			rec.Bits[i] = binary.LittleEndian.Uint64(p[8*i:])
+		}
+	default:
+		rec.Vector = make(vec.Vector, n)
+		for i := range rec.Vector {
+			rec.Vector[i] = mathFloat32frombits(binary.LittleEndian.Uint32(p[4*i:]))
+		}
+	}
+	return rec
+}
+
+func openForRead(path string) (*os.File, *walReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return f, newWALReader(f, st.Size()), nil
+}
+
+// ScanWAL calls fn for every entry of the WAL file at path without opening
+// it for writing, so it is safe on the WAL of a running server. Like
+// ReplayRecords it stops at the first corrupt record.
+func ScanWAL(path string, fn func(Record) error) error {
+	return InspectWAL(path, func(e WALEntry) error {
+		if !e.CRCOK {
+			return &CorruptError{Offset: e.Offset, Err: ErrChecksum}
+		}
+		return fn(e.Record)
+	})
+}
+
+// InspectWAL calls fn for every record of the WAL file at path, including
+// those failing their checksum. A record whose lengths are intact can be
+// skipped even when its contents are not, so inspection continues past
+// it. It returns a *CorruptError for a tail that cannot be read as a
+// record; the offset is where the readable part of the file ends.
+func InspectWAL(path string, fn func(WALEntry) error) error {
+	f, r, err := openForRead(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	for {
+		e, err := r.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+}
+
+// RepairStats describes what RepairWAL kept and dropped.
+type RepairStats struct {
+	Kept        int
+	BadChecksum int   // records dropped for a checksum mismatch
+	TornBytes   int64 // unreadable bytes dropped from the end
+}
+
+// RepairWAL writes the records of src that pass their checksum to a new
+// file dst, dropping the rest and any torn tail. dst must not exist; src
+// is not modified. A bad record in the middle of the file is only
+// skipped when its length fields are intact, otherwise everything from it
+// on counts as the torn tail.
+func RepairWAL(src, dst string) (RepairStats, error) {
+	var st RepairStats
+	f, r, err := openForRead(src)
+	if err != nil {
+		return st, err
+	}
+	defer f.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return st, err
+	}
+	bw := bufio.NewWriter(out)
+	for {
+		e, err := r.next()
+		if err == io.EOF {
+			break
+		}
+		var ce *CorruptError
+		if errors.As(err, &ce) {
+			st.TornBytes = r.size - ce.Offset
+			break
+		}
+		if err != nil {
+			out.Close()
+			return st, err
+		}
+		if !e.CRCOK {
+			st.BadChecksum++
+			continue
+		}
+		if _, err := bw.Write(e.raw); err != nil {
+			out.Close()
+			return st, err
+		}
+		st.Kept++
+	}
+
+	if err := bw.Flush(); err != nil {
+		out.Close()
+		return st, err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return st, err
+	}
+	return st, out.Close()
+}
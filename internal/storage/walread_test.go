@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+// damagedWAL writes four records, flips a byte in the vector of the
+// second and cuts the fourth short. It returns the path and the offset of
+// the fourth record.
+func damagedWAL(t *testing.T) (string, int64) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "nebula.wal")
+	w, err := OpenWAL(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.WriteInsert("a", vec.Vector{1, 2})
+	w.WriteInsert("b", vec.Vector{3, 4})
+	w.WriteInsertText("c", "hello")
+	end := int64(w.Stats().BytesWritten)
+	w.WriteInsert("d", vec.Vector{5, 6, 7})
+	w.Close()
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b[(4+1+2+1+4+8)+(4+1+2+1+4)] ^= 0xff // first vector byte of "b"
+	b = b[:end+10]
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path, end
+}
+
+func TestInspectWAL(t *testing.T) {
+	path, end := damagedWAL(t)
+
+	var entries []WALEntry
+	err := InspectWAL(path, func(e WALEntry) error {
+		entries = append(entries, e)
+		return nil
+	})
+	var ce *CorruptError
+	if !errors.As(err, &ce) || ce.Offset != end {
+		t.Fatalf("InspectWAL error = %v, want a CorruptError at %d", err, end)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+	for i, want := range []bool{true, false, true} {
+		if entries[i].CRCOK != want {
+			t.Errorf("entry %d (%s): CRCOK = %t, want %t", i, entries[i].Record.ID, entries[i].CRCOK, want)
+		}
+	}
+	if entries[1].Offset != entries[0].Size || entries[2].Record.Text != "hello" {
+		t.Errorf("unexpected entries %+v", entries)
+	}
+}
+
+func TestReplayRecords_Corrupt(t *testing.T) {
+	path, _ := damagedWAL(t)
+	w, err := OpenWAL(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	var ids []string
+	err = w.ReplayRecords(func(r Record) error {
+		ids = append(ids, r.ID)
+		return nil
+	})
+	if !errors.Is(err, ErrChecksum) || len(ids) != 1 {
+		t.Errorf("replay = %v after %v, want a checksum error after [a]", err, ids)
+	}
+}
+
+func TestRepairWAL(t *testing.T) {
+	path, end := damagedWAL(t)
+	dst := path + ".repaired"
+
+	st, err := RepairWAL(path, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Kept != 2 || st.BadChecksum != 1 || st.TornBytes != 10 {
+		t.Errorf("RepairWAL stats = %+v", st)
+	}
+	if _, err := RepairWAL(path, dst); !errors.Is(err, os.ErrExist) {
+		t.Errorf("second repair to the same file: %v, want ErrExist", err)
+	}
+
+	w, err := OpenWAL(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	var ids []string
+	if err := w.ReplayRecords(func(r Record) error {
+		ids = append(ids, r.ID)
+		return nil
+	}); err != nil {
+		t.Fatalf("replaying the repaired WAL: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != "a" || ids[1] != "c" {
+		t.Errorf("repaired WAL holds %v, want [a c]", ids)
+	}
+	if fi, _ := os.Stat(dst); fi.Size() >= end {
+		t.Errorf("repaired WAL is %d bytes, want less than %d", fi.Size(), end)
+	}
+}
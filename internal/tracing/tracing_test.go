@@ -0,0 +1,76 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc"
+
+	"github.com/sandeep89846/nebuladb/internal/index"
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+func TestInterceptor_SpansNestIndexWork(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	otel.SetTracerProvider(tp)
+	defer tp.Shutdown(context.Background())
+
+	idx := index.NewHNSW(index.DefaultConfig())
+	for i := 0; i < 100; i++ {
+		idx.Insert(fmt.Sprintf("v%d", i), vec.Vector{float32(i%7 + 1), float32(i%5 + 1), float32(i%3 + 1)})
+	}
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/nebula.VectorService/Search"}
+	UnaryServerInterceptor()(context.Background(), nil, info, func(ctx context.Context, _ any) (any, error) {
+		return idx.SearchContext(ctx, vec.Vector{1, 2, 3}, 5)
+	})
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, s := range rec.Ended() {
+		spans[s.Name()] = s
+	}
+	rpc, search := spans[info.FullMethod], spans["HNSW.Search"]
+	if rpc == nil || search == nil || spans["HNSW.searchLayer"] == nil {
+		t.Fatalf("missing spans, got %v", spans)
+	}
+	if search.Parent().SpanID() != rpc.SpanContext().SpanID() {
+		t.Error("HNSW.Search is not a child of the RPC span")
+	}
+
+	attrs := make(map[string]int64)
+	for _, kv := range search.Attributes() {
+		attrs[string(kv.Key)] = kv.Value.AsInt64()
+	}
+	if attrs["ef"] != 50 || attrs["visited"] == 0 || attrs["k"] != 5 {
+		t.Errorf("unexpected search attributes %v", attrs)
+	}
+	for _, key := range []string{"layer_descents", "lock_wait_us"} {
+		if _, ok := attrs[key]; !ok {
+			t.Errorf("missing %s attribute", key)
+		}
+	}
+}
+
+func TestSetup_FileExporter(t *testing.T) {
+	shutdown, err := Setup(context.Background(), Options{
+		Exporter:    ExporterFile,
+		File:        filepath.Join(t.TempDir(), "traces.jsonl"),
+		SampleRatio: 1,
+	})
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown failed: %v", err)
+	}
+
+	if _, err := Setup(context.Background(), Options{Exporter: "zipkin"}); err == nil {
+		t.Error("expected error for unknown exporter")
+	}
+}
@@ -0,0 +1,168 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// DefaultConcurrency is the number of inserts InsertBatch keeps in flight
+// when given 0.
+const DefaultConcurrency = 8
+
+// BatchError lists the items of a batch that failed.
+type BatchError struct {
+	Failed []ItemError
+}
+
+type ItemError struct {
+	ID  string
+	Err error
+}
+
+func (e *BatchError) Error() string {
+	msgs := make([]string, 0, 3)
+	for i, f := range e.Failed {
+		if i == 3 {
+			msgs = append(msgs, fmt.Sprintf("and %d more", len(e.Failed)-i))
+			break
+		}
+		msgs = append(msgs, fmt.Sprintf("%q: %v", f.ID, f.Err))
+	}
+	return fmt.Sprintf("client: %d inserts failed: %s", len(e.Failed), strings.Join(msgs, "; "))
+}
+
+// InsertBatch inserts items with up to concurrency calls in flight. All
+// items are attempted; failures are reported together as a *BatchError,
+// in item order. Items not sent because ctx ended count as failed.
+func (c *Client) InsertBatch(ctx context.Context, items []Item, concurrency int) error {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	errs := make([]error, len(items))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range items {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer func() { <-sem; wg.Done() }()
+			errs[i] = c.InsertItem(ctx, items[i])
+		}(i)
+	}
+	wg.Wait()
+
+	var be BatchError
+	for i, err := range errs {
+		if err != nil {
+			be.Failed = append(be.Failed, ItemError{ID: items[i].ID, Err: err})
+		}
+	}
+	if len(be.Failed) > 0 {
+		return &be
+	}
+	return nil
+}
+
+// Batcher buffers items and inserts them with InsertBatch once size are
+// pending. Call Flush after the last Add. It is safe for concurrent use.
+type Batcher struct {
+	c           *Client
+	size        int
+	concurrency int
+
+	mu      sync.Mutex
+	pending []Item
+}
+
+// NewBatcher returns a Batcher flushing every size items with up to
+// concurrency inserts in flight.
+func (c *Client) NewBatcher(size, concurrency int) *Batcher {
+	if size <= 0 {
+		size = 1
+	}
+	return &Batcher{c: c, size: size, concurrency: concurrency}
+}
+
+// Add queues it, flushing if the batch is full. The error is that of the
+// flush, if one ran.
+func (b *Batcher) Add(ctx context.Context, it Item) error {
+	b.mu.Lock()
+	b.pending = append(b.pending, it)
+	if len(b.pending) < b.size {
+		b.mu.Unlock()
+		return nil
+	}
+	batch := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+	return b.c.InsertBatch(ctx, batch, b.concurrency)
+}
+
+// Flush inserts any pending items.
+func (b *Batcher) Flush(ctx context.Context) error {
+	b.mu.Lock()
+	batch := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+	if len(batch) == 0 {
+		return nil
+	}
+	return b.c.InsertBatch(ctx, batch, b.concurrency)
+}
+
+// BulkResult summarizes a BulkInsert. Errors lists the first failures
+// only; Failed counts all of them.
+type BulkResult struct {
+	Inserted int64
+	Failed   int64
+	Errors   []ItemError
+}
+
+// BulkInsert streams items from next to the server's bulk path until next
+// returns io.EOF. It is the fastest way to load a large dataset; a failed
+// item is counted in the result and does not stop the load. Streams are
+// not retried: on error, the items sent in chunks the server already
+// applied stay inserted.
+func (c *Client) BulkInsert(ctx context.Context, next func() (Item, error)) (*BulkResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // ends the stream on an early return
+	stream, err := c.rpc.BulkInsert(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		it, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		req, err := c.insertRequest(it)
+		if err != nil {
+			return nil, err
+		}
+		if err := stream.Send(req); err != nil {
+			// The server ended the stream; CloseAndRecv has its status.
+			break
+		}
+	}
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		return nil, err
+	}
+	res := &BulkResult{Inserted: resp.Inserted, Failed: resp.Failed}
+	for _, e := range resp.Errors {
+		res.Errors = append(res.Errors, ItemError{ID: e.Id, Err: errors.New(e.Error)})
+	}
+	return res, nil
+}
@@ -0,0 +1,290 @@
+// Package client is a Go client for NebulaDB's VectorService. It wraps the
+// generated gRPC client with retries, API-key auth, insert batching and
+// conversion to and from pkg/vec types.
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/sandeep89846/nebuladb/api/proto/nebulapb"
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+// ErrNotFound is returned by Get and Delete for an unknown id.
+var ErrNotFound = errors.New("client: not found")
+
+// ErrPartial is returned with the matches of a best-effort search that ran
+// out of time.
+var ErrPartial = errors.New("client: partial results")
+
+// Client is safe for concurrent use.
+type Client struct {
+	conn *grpc.ClientConn
+	rpc  nebulapb.VectorServiceClient
+	enc  nebulapb.VectorEncoding
+}
+
+type options struct {
+	creds    credentials.TransportCredentials
+	apiKey   string
+	retry    RetryPolicy
+	enc      nebulapb.VectorEncoding
+	dialOpts []grpc.DialOption
+}
+
+type Option func(*options)
+
+// WithTransportCredentials sets the connection security, for example
+// credentials.NewTLS. The default is an insecure connection.
+func WithTransportCredentials(creds credentials.TransportCredentials) Option {
+	return func(o *options) { o.creds = creds }
+}
+
+// WithAPIKey sends key as x-api-key on every call.
+func WithAPIKey(key string) Option {
+	return func(o *options) { o.apiKey = key }
+}
+
+// WithRetry replaces DefaultRetryPolicy.
+func WithRetry(p RetryPolicy) Option {
+	return func(o *options) { o.retry = p }
+}
+
+// WithEncoding selects how dense vectors are sent: FLOAT32 (the default),
+// FLOAT16 or BFLOAT16. The half-precision encodings halve request size at
+// the cost of precision.
+func WithEncoding(enc nebulapb.VectorEncoding) Option {
+	return func(o *options) { o.enc = enc }
+}
+
+// WithDialOptions passes extra options to grpc.NewClient.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *options) { o.dialOpts = append(o.dialOpts, opts...) }
+}
+
+// New creates a client for target, in grpc.NewClient syntax. The
+// connection is made lazily and re-established by gRPC as needed.
+func New(target string, opts ...Option) (*Client, error) {
+	o := options{creds: insecure.NewCredentials(), retry: DefaultRetryPolicy}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.enc == nebulapb.VectorEncoding_BINARY {
+		return nil, fmt.Errorf("client: BINARY is not a dense encoding; use InsertItem with Bits")
+	}
+
+	interceptors := []grpc.UnaryClientInterceptor{o.retry.interceptor()}
+	var streamInterceptors []grpc.StreamClientInterceptor
+	if o.apiKey != "" {
+		key := o.apiKey
+		interceptors = append(interceptors, func(ctx context.Context, method string, req, reply any,
+			cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			ctx = metadata.AppendToOutgoingContext(ctx, "x-api-key", key)
+			return invoker(ctx, method, req, reply, cc, opts...)
+		})
+		streamInterceptors = append(streamInterceptors, func(ctx context.Context, desc *grpc.StreamDesc,
+			cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			ctx = metadata.AppendToOutgoingContext(ctx, "x-api-key", key)
+			return streamer(ctx, desc, cc, method, opts...)
+		})
+	}
+	dialOpts := append([]grpc.DialOption{
+		grpc.WithTransportCredentials(o.creds),
+		grpc.WithChainUnaryInterceptor(interceptors...),
+		grpc.WithChainStreamInterceptor(streamInterceptors...),
+	}, o.dialOpts...)
+
+	conn, err := grpc.NewClient(target, dialOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, rpc: nebulapb.NewVectorServiceClient(conn), enc: o.enc}, nil
+}
+
+func (c *Client) Close() error { return c.conn.Close() }
+
+// Raw returns the generated client, for calls this package doesn't wrap.
+// It shares the connection and interceptors.
+func (c *Client) Raw() nebulapb.VectorServiceClient { return c.rpc }
+
+// Item is one record to insert. Set Vector or Bits (for Hamming and
+// Jaccard indexes), and optionally Sparse and Text.
+type Item struct {
+	ID     string
+	Vector vec.Vector
+	Bits   vec.BitVector
+	// BitLen is the length of Bits in bits, sent rounded up to whole
+	// bytes. Zero means every bit of every word.
+	BitLen int
+	Sparse vec.SparseVector
+	Text   string
+}
+
+// Insert adds a dense vector.
+func (c *Client) Insert(ctx context.Context, id string, v vec.Vector) error {
+	return c.InsertItem(ctx, Item{ID: id, Vector: v})
+}
+
+// insertRequest converts it, encoding dense vectors as configured.
+func (c *Client) insertRequest(it Item) (*nebulapb.InsertRequest, error) {
+	req := &nebulapb.InsertRequest{Id: it.ID, Sparse: sparseToProto(it.Sparse), Text: it.Text}
+	if it.Bits != nil {
+		n := it.BitLen
+		if n == 0 {
+			n = it.Bits.Len()
+		}
+		if n < 0 || n > it.Bits.Len() {
+			return nil, fmt.Errorf("client: %q: %d words cannot hold %d bits", it.ID, len(it.Bits), n)
+		}
+		req.PackedVector = it.Bits.Bytes((n + 7) / 8)
+		req.Encoding = nebulapb.VectorEncoding_BINARY
+	} else if it.Vector != nil {
+		var err error
+		req.Vector, req.PackedVector, err = EncodeVector(it.Vector, c.enc)
+		if err != nil {
+			return nil, err
+		}
+		req.Encoding = c.enc
+	}
+	return req, nil
+}
+
+// InsertItem adds it to every index it has data for. When an attempt
+// failed in a way that may have hidden its success and the retry reports
+// that the id already exists, the first attempt is taken to have landed
+// and InsertItem succeeds. The server applies all parts of an insert or
+// none, so the record is then complete; it cannot tell this from an id
+// that was already present before the call.
+func (c *Client) InsertItem(ctx context.Context, it Item) error {
+	req, err := c.insertRequest(it)
+	if err != nil {
+		return err
+	}
+	var attempts int
+	resp, err := c.rpc.Insert(context.WithValue(ctx, attemptsKey{}, &attempts), req)
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		if attempts > 1 && strings.Contains(resp.Error, "already exists") {
+			return nil
+		}
+		return fmt.Errorf("client: insert %q: %s", it.ID, resp.Error)
+	}
+	return nil
+}
+
+// Match is one search result.
+type Match struct {
+	ID    string
+	Score float32
+}
+
+// Search returns the nearest matches to q, 10 unless K is given. q may be
+// nil for a pure sparse or text search. A best-effort search that ran out
+// of time returns its matches with ErrPartial.
+func (c *Client) Search(ctx context.Context, q vec.Vector, opts ...SearchOption) ([]Match, error) {
+	req := &nebulapb.SearchRequest{K: defaultK}
+	if q != nil {
+		var err error
+		req.Vector, req.PackedVector, err = EncodeVector(q, c.enc)
+		if err != nil {
+			return nil, err
+		}
+		req.Encoding = c.enc
+	}
+	return c.search(ctx, req, opts)
+}
+
+// SearchBits searches a Hamming or Jaccard index.
+func (c *Client) SearchBits(ctx context.Context, q vec.BitVector, opts ...SearchOption) ([]Match, error) {
+	req := &nebulapb.SearchRequest{
+		K:            defaultK,
+		PackedVector: q.Bytes(8 * len(q)),
+		Encoding:     nebulapb.VectorEncoding_BINARY,
+	}
+	return c.search(ctx, req, opts)
+}
+
+func (c *Client) search(ctx context.Context, req *nebulapb.SearchRequest, opts []SearchOption) ([]Match, error) {
+	for _, opt := range opts {
+		opt(req)
+	}
+	resp, err := c.rpc.Search(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	matches := make([]Match, len(resp.Matches))
+	for i, m := range resp.Matches {
+		matches[i] = Match{ID: m.Id, Score: m.Score}
+	}
+	if resp.Partial {
+		return matches, ErrPartial
+	}
+	return matches, nil
+}
+
+// Get returns the stored vector for id, at the magnitude it was inserted
+// with and rounded to the server's storage precision.
+func (c *Client) Get(ctx context.Context, id string) (vec.Vector, error) {
+	resp, err := c.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Encoding == nebulapb.VectorEncoding_BINARY {
+		return nil, fmt.Errorf("client: %q is a binary vector; use GetBits", id)
+	}
+	return DecodeVector(resp.Vector, resp.PackedVector, resp.Encoding)
+}
+
+// GetBits returns the stored vector for id from a binary index.
+func (c *Client) GetBits(ctx context.Context, id string) (vec.BitVector, error) {
+	resp, err := c.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Encoding != nebulapb.VectorEncoding_BINARY {
+		return nil, fmt.Errorf("client: %q is not a binary vector; use Get", id)
+	}
+	return vec.BitVectorFromBytes(resp.PackedVector), nil
+}
+
+func (c *Client) get(ctx context.Context, id string) (*nebulapb.GetResponse, error) {
+	resp, err := c.rpc.Get(ctx, &nebulapb.GetRequest{Id: id})
+	return resp, notFound(err)
+}
+
+// Delete removes id from every index.
+func (c *Client) Delete(ctx context.Context, id string) error {
+	_, err := c.rpc.Delete(ctx, &nebulapb.DeleteRequest{Id: id})
+	return notFound(err)
+}
+
+// notFound wraps NOT_FOUND errors in ErrNotFound, keeping the status.
+func notFound(err error) error {
+	if status.Code(err) == codes.NotFound {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+	return err
+}
+
+// Stats reports index sizes and WAL counters.
+func (c *Client) Stats(ctx context.Context) (*nebulapb.StatsResponse, error) {
+	return c.rpc.Stats(ctx, &nebulapb.StatsRequest{})
+}
+
+// Snapshot makes the server checkpoint its indexes and empty its WAL. It
+// needs an admin key when API keys are enabled.
+func (c *Client) Snapshot(ctx context.Context) (*nebulapb.SnapshotResponse, error) {
+	return c.rpc.Snapshot(ctx, &nebulapb.SnapshotRequest{})
+}
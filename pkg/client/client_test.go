@@ -0,0 +1,316 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/sandeep89846/nebuladb/api/proto/nebulapb"
+	"github.com/sandeep89846/nebuladb/internal/auth"
+	"github.com/sandeep89846/nebuladb/internal/index"
+	"github.com/sandeep89846/nebuladb/internal/server"
+	"github.com/sandeep89846/nebuladb/internal/storage"
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+// newTestClient serves a fresh in-process server over bufconn, with extra
+// interceptors in front of the server's own.
+func newTestClient(t *testing.T, interceptors []grpc.UnaryServerInterceptor, opts ...Option) *Client {
+	t.Helper()
+	wal, err := storage.OpenWAL(filepath.Join(t.TempDir(), "nebula.wal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { wal.Close() })
+
+	srv := server.NewServer(index.NewHNSW(index.DefaultConfig()), index.NewSparseIndex(), index.NewTextIndex(), wal)
+	gs := grpc.NewServer(grpc.ChainUnaryInterceptor(append(interceptors, srv.UnaryInterceptor())...))
+	nebulapb.RegisterVectorServiceServer(gs, srv)
+	lis := bufconn.Listen(1 << 20)
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+
+	opts = append(opts, WithDialOptions(grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return lis.DialContext(ctx)
+	})))
+	c, err := New("passthrough:///bufnet", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestClient_CRUD(t *testing.T) {
+	for _, enc := range []nebulapb.VectorEncoding{nebulapb.VectorEncoding_FLOAT32, nebulapb.VectorEncoding_FLOAT16} {
+		t.Run(enc.String(), func(t *testing.T) {
+			c := newTestClient(t, nil, WithEncoding(enc))
+			ctx := context.Background()
+
+			if err := c.Insert(ctx, "a", vec.Vector{1, 0, 0}); err != nil {
+				t.Fatal(err)
+			}
+			err := c.InsertItem(ctx, Item{ID: "b", Vector: vec.Vector{0, 1, 0}, Text: "brown fox"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := c.Insert(ctx, "a", vec.Vector{1, 0, 0}); err == nil {
+				t.Error("expected duplicate insert to fail")
+			}
+
+			matches, err := c.Search(ctx, vec.Vector{1, 0.1, 0}, K(1), Ef(50))
+			if err != nil || len(matches) != 1 || matches[0].ID != "a" {
+				t.Errorf("Search = %v, %v", matches, err)
+			}
+			matches, err = c.Search(ctx, nil, Text("fox"))
+			if err != nil || len(matches) != 1 || matches[0].ID != "b" {
+				t.Errorf("text Search = %v, %v", matches, err)
+			}
+
+			v, err := c.Get(ctx, "b")
+			if err != nil || len(v) != 3 || v[1] != 1 {
+				t.Errorf("Get = %v, %v", v, err)
+			}
+			if err := c.Delete(ctx, "b"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := c.Get(ctx, "b"); !errors.Is(err, ErrNotFound) || status.Code(err) != codes.NotFound {
+				t.Errorf("Get after delete: %v", err)
+			}
+			if err := c.Delete(ctx, "b"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("second Delete: %v", err)
+			}
+		})
+	}
+}
+
+// flaky fails the first n calls with code.
+func flaky(n int32, code codes.Code, calls *atomic.Int32) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if calls.Add(1) <= n {
+			return nil, status.Error(code, "injected")
+		}
+		return handler(ctx, req)
+	}
+}
+
+func TestClient_Retry(t *testing.T) {
+	fast := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+	tests := []struct {
+		name      string
+		failures  int32
+		code      codes.Code
+		wantCode  codes.Code
+		wantCalls int32
+	}{
+		{"recovers", 2, codes.Unavailable, codes.OK, 3},
+		{"rate limited", 1, codes.ResourceExhausted, codes.OK, 2},
+		{"gives up", 5, codes.Unavailable, codes.Unavailable, 3},
+		{"not retryable", 1, codes.InvalidArgument, codes.InvalidArgument, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			c := newTestClient(t, []grpc.UnaryServerInterceptor{flaky(tt.failures, tt.code, &calls)}, WithRetry(fast))
+			_, err := c.Search(context.Background(), vec.Vector{1, 0})
+			if status.Code(err) != tt.wantCode || calls.Load() != tt.wantCalls {
+				t.Errorf("got %v after %d calls, want %v after %d", err, calls.Load(), tt.wantCode, tt.wantCalls)
+			}
+		})
+	}
+}
+
+// lostReply runs the first n calls but fails them with UNAVAILABLE, as if
+// the connection dropped before the reply arrived.
+func lostReply(n int32, calls *atomic.Int32) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		if calls.Add(1) <= n {
+			return nil, status.Error(codes.Unavailable, "injected")
+		}
+		return resp, err
+	}
+}
+
+func TestClient_RetriedInsert(t *testing.T) {
+	fast := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+	var calls atomic.Int32
+	c := newTestClient(t, []grpc.UnaryServerInterceptor{lostReply(1, &calls)}, WithRetry(fast))
+	ctx := context.Background()
+
+	if err := c.Insert(ctx, "a", vec.Vector{1, 0}); err != nil || calls.Load() != 2 {
+		t.Fatalf("insert whose first reply was lost: %v after %d calls", err, calls.Load())
+	}
+	if err := c.InsertBatch(ctx, []Item{{ID: "a", Vector: vec.Vector{0, 1}}}, 1); err == nil {
+		t.Error("expected a duplicate insert on the first attempt to fail")
+	}
+}
+
+func TestClient_Batch(t *testing.T) {
+	c := newTestClient(t, nil)
+	ctx := context.Background()
+
+	items := make([]Item, 200)
+	for i := range items {
+		items[i] = Item{ID: fmt.Sprintf("v%d", i), Vector: vec.Vector{float32(i), 1, 0}}
+	}
+	if err := c.InsertBatch(ctx, items[:100], 4); err != nil {
+		t.Fatal(err)
+	}
+
+	b := c.NewBatcher(32, 4)
+	for _, it := range items[100:] {
+		if err := b.Add(ctx, it); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"v0", "v99", "v100", "v199"} {
+		if _, err := c.Get(ctx, id); err != nil {
+			t.Errorf("Get(%s): %v", id, err)
+		}
+	}
+
+	// duplicates fail individually, the rest still go in
+	err := c.InsertBatch(ctx, []Item{items[0], {ID: "new", Vector: vec.Vector{1, 1, 1}}, items[5]}, 2)
+	var be *BatchError
+	if !errors.As(err, &be) || len(be.Failed) != 2 || be.Failed[0].ID != "v0" || be.Failed[1].ID != "v5" {
+		t.Errorf("InsertBatch with duplicates: %v", err)
+	}
+	if _, err := c.Get(ctx, "new"); err != nil {
+		t.Errorf("non-duplicate item was not inserted: %v", err)
+	}
+}
+
+func TestClient_APIKey(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "keys.yaml")
+	os.WriteFile(keyFile, []byte("keys: [{name: reader, key: secret, roles: [read]}]"), 0600)
+	keys, err := auth.LoadKeyStore(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authn := []grpc.UnaryServerInterceptor{auth.NewAuthenticator(keys, server.MethodRoles).UnaryInterceptor()}
+
+	c := newTestClient(t, authn, WithAPIKey("secret"))
+	if _, err := c.Search(context.Background(), vec.Vector{1}); err != nil {
+		t.Errorf("Search with key: %v", err)
+	}
+	if err := c.Insert(context.Background(), "a", vec.Vector{1}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Insert with read key: %v", err)
+	}
+
+	anon := newTestClient(t, authn)
+	if _, err := anon.Search(context.Background(), vec.Vector{1}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Search without key: %v", err)
+	}
+}
+
+func TestEncodeDecodeVector(t *testing.T) {
+	v := vec.Vector{0.5, -1, 2, 0}
+	for _, enc := range []nebulapb.VectorEncoding{
+		nebulapb.VectorEncoding_FLOAT32, nebulapb.VectorEncoding_FLOAT16, nebulapb.VectorEncoding_BFLOAT16,
+	} {
+		values, packed, err := EncodeVector(v, enc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := DecodeVector(values, packed, enc)
+		if err != nil || fmt.Sprint(got) != fmt.Sprint(v) {
+			t.Errorf("%v round trip = %v, %v", enc, got, err)
+		}
+	}
+	if _, _, err := EncodeVector(v, nebulapb.VectorEncoding_BINARY); err == nil {
+		t.Error("expected BINARY to be rejected")
+	}
+}
+
+func TestClient_BulkInsert(t *testing.T) {
+	c := newTestClient(t, nil)
+	ctx := context.Background()
+
+	items := make([]Item, 0, 2503)
+	for i := 0; i < 2500; i++ { // spans several server chunks
+		items = append(items, Item{ID: fmt.Sprintf("v%d", i), Vector: vec.Vector{float32(i), 1, 0}})
+	}
+	items = append(items,
+		Item{ID: "doc", Vector: vec.Vector{0, 0, 1}, Text: "bulk text"}, // takes the Insert path
+		Item{ID: "v7", Vector: vec.Vector{1, 1, 1}},                     // duplicate
+		Item{ID: "short", Vector: vec.Vector{1}},                        // wrong dimension
+	)
+	i := 0
+	res, err := c.BulkInsert(ctx, func() (Item, error) {
+		if i == len(items) {
+			return Item{}, io.EOF
+		}
+		i++
+		return items[i-1], nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Inserted != 2501 || res.Failed != 2 || len(res.Errors) != 2 {
+		t.Fatalf("BulkInsert = %+v", res)
+	}
+	failed := map[string]bool{res.Errors[0].ID: true, res.Errors[1].ID: true}
+	if !failed["v7"] || !failed["short"] {
+		t.Errorf("unexpected failures %v", res.Errors)
+	}
+
+	st, err := c.Stats(ctx)
+	if err != nil || st.Vectors != 2501 || st.TextDocuments != 1 {
+		t.Errorf("Stats after bulk insert = %v, %v", st, err)
+	}
+	matches, err := c.Search(ctx, nil, Text("bulk"))
+	if err != nil || len(matches) != 1 || matches[0].ID != "doc" {
+		t.Errorf("text search = %v, %v", matches, err)
+	}
+}
+
+func TestClient_Export(t *testing.T) {
+	c := newTestClient(t, nil)
+	ctx := context.Background()
+
+	sp, _ := vec.NewSparseVector([]uint32{4, 1}, []float32{0.5, 2})
+	items := []Item{
+		{ID: "a", Vector: vec.Vector{3, 4, 0}},
+		{ID: "b", Vector: vec.Vector{0, -2, 0}, Sparse: sp, Text: "brown fox"},
+		{ID: "gone", Vector: vec.Vector{1, 1, 1}},
+		{ID: "sparse-only", Sparse: sp},
+		{ID: "text-only", Text: "lazy dog"},
+	}
+	for _, it := range items {
+		if err := c.InsertItem(ctx, it); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.Delete(ctx, "gone"); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []Item
+	err := c.Export(ctx, func(it Item) error {
+		got = append(got, it)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Item{items[0], items[1], items[3], items[4]} // dense first, then by id
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Export =\n%v\nwant\n%v", got, want)
+	}
+}
@@ -0,0 +1,54 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/sandeep89846/nebuladb/api/proto/nebulapb"
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+// EncodeVector converts v for a request's vector and packed_vector fields.
+// FLOAT32 uses the repeated field, the half-precision encodings the packed
+// one.
+func EncodeVector(v vec.Vector, enc nebulapb.VectorEncoding) ([]float32, []byte, error) {
+	switch enc {
+	case nebulapb.VectorEncoding_FLOAT32:
+		return v, nil, nil
+	case nebulapb.VectorEncoding_FLOAT16:
+		return nil, vec.ToFloat16Vector(v).Bytes(), nil
+	case nebulapb.VectorEncoding_BFLOAT16:
+		return nil, vec.ToBFloat16Vector(v).Bytes(), nil
+	}
+	return nil, nil, fmt.Errorf("client: unsupported dense encoding %v", enc)
+}
+
+// DecodeVector is the inverse of EncodeVector, accepting either field.
+func DecodeVector(values []float32, packed []byte, enc nebulapb.VectorEncoding) (vec.Vector, error) {
+	if len(packed) == 0 {
+		return vec.Vector(values), nil
+	}
+	switch enc {
+	case nebulapb.VectorEncoding_FLOAT32:
+		return vec.Float32FromBytes(packed)
+	case nebulapb.VectorEncoding_FLOAT16:
+		h, err := vec.Float16FromBytes(packed)
+		if err != nil {
+			return nil, err
+		}
+		return h.Float32(), nil
+	case nebulapb.VectorEncoding_BFLOAT16:
+		b, err := vec.BFloat16FromBytes(packed)
+		if err != nil {
+			return nil, err
+		}
+		return b.Float32(), nil
+	}
+	return nil, fmt.Errorf("client: unsupported dense encoding %v", enc)
+}
+
+func sparseToProto(s vec.SparseVector) *nebulapb.SparseVector {
+	if s.Len() == 0 {
+		return nil
+	}
+	return &nebulapb.SparseVector{Indices: s.Indices, Values: s.Values}
+}
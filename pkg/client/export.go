@@ -0,0 +1,45 @@
+package client
+
+import (
+	"context"
+	"io"
+
+	"github.com/sandeep89846/nebuladb/api/proto/nebulapb"
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+// Export calls fn with every live record on the server, dense records
+// first. Vectors carry their original values, at the server's storage
+// precision. Export stops at the first error from fn or the stream.
+func (c *Client) Export(ctx context.Context, fn func(Item) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // ends the stream on an early return
+	stream, err := c.rpc.Export(ctx, &nebulapb.ExportRequest{})
+	if err != nil {
+		return err
+	}
+	for {
+		rec, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		it := Item{ID: rec.Id, Text: rec.Text}
+		if rec.Encoding == nebulapb.VectorEncoding_BINARY {
+			it.Bits = vec.BitVectorFromBytes(rec.PackedVector)
+			it.BitLen = 8 * len(rec.PackedVector)
+		} else if it.Vector, err = DecodeVector(rec.Vector, rec.PackedVector, rec.Encoding); err != nil {
+			return err
+		}
+		if rec.Sparse != nil {
+			if it.Sparse, err = vec.NewSparseVector(rec.Sparse.Indices, rec.Sparse.Values); err != nil {
+				return err
+			}
+		}
+		if err := fn(it); err != nil {
+			return err
+		}
+	}
+}
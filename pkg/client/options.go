@@ -0,0 +1,45 @@
+package client
+
+import (
+	"github.com/sandeep89846/nebuladb/api/proto/nebulapb"
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+const defaultK = 10
+
+// SearchOption adjusts a search request. There is no filter option: the
+// server stores no metadata to filter on yet, so results can only be
+// narrowed by the caller after the search.
+type SearchOption func(*nebulapb.SearchRequest)
+
+// K sets the number of matches returned.
+func K(k int) SearchOption {
+	return func(r *nebulapb.SearchRequest) { r.K = int32(k) }
+}
+
+// Ef sets the HNSW search width for this query, trading speed for recall.
+// The server default (index.ef_search) applies when unset.
+func Ef(ef int) SearchOption {
+	return func(r *nebulapb.SearchRequest) { r.Ef = int32(ef) }
+}
+
+// BestEffort returns the matches found shortly before the context's
+// deadline instead of failing; Search then reports ErrPartial.
+func BestEffort() SearchOption {
+	return func(r *nebulapb.SearchRequest) { r.BestEffort = true }
+}
+
+// Sparse adds a sparse query, fused with the dense one if both are given.
+func Sparse(s vec.SparseVector) SearchOption {
+	return func(r *nebulapb.SearchRequest) { r.Sparse = sparseToProto(s) }
+}
+
+// Text adds a BM25 keyword query.
+func Text(q string) SearchOption {
+	return func(r *nebulapb.SearchRequest) { r.TextQuery = q }
+}
+
+// Hybrid sets how dense, sparse and text results are fused.
+func Hybrid(h *nebulapb.HybridOptions) SearchOption {
+	return func(r *nebulapb.SearchRequest) { r.Hybrid = h }
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RetryPolicy retries calls failing with UNAVAILABLE (server down or
+// restoring), RESOURCE_EXHAUSTED (rate or concurrency limits) or ABORTED,
+// waiting a random time up to an exponentially growing bound between
+// attempts.
+//
+// An UNAVAILABLE or ABORTED call may have run on the server even though
+// its reply was lost, so an insert is not safe to simply repeat: the retry
+// finds its own id. InsertItem therefore treats "already exists" on a
+// retried attempt as success.
+type RetryPolicy struct {
+	MaxAttempts    int // including the first; 1 disables retries
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    4,
+	InitialBackoff: 50 * time.Millisecond,
+	MaxBackoff:     2 * time.Second,
+}
+
+func retryable(c codes.Code) bool {
+	return c == codes.Unavailable || c == codes.ResourceExhausted || c == codes.Aborted
+}
+
+// backoff is the wait before retry n (0-based), with full jitter.
+func (p RetryPolicy) backoff(n int) time.Duration {
+	bound := p.InitialBackoff << n
+	if bound > p.MaxBackoff || bound <= 0 {
+		bound = p.MaxBackoff
+	}
+	if bound <= 0 {
+		return 0
+	}
+	return rand.N(bound)
+}
+
+// attemptsKey carries an *int through a call's context that the retry
+// interceptor sets to the number of attempts made.
+type attemptsKey struct{}
+
+func (p RetryPolicy) interceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any,
+		cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		attempts, _ := ctx.Value(attemptsKey{}).(*int)
+		var err error
+		for n := 0; ; n++ {
+			if attempts != nil {
+				*attempts = n + 1
+			}
+			err = invoker(ctx, method, req, reply, cc, opts...)
+			if err == nil || !retryable(status.Code(err)) || n+1 >= p.MaxAttempts {
+				return err
+			}
+			t := time.NewTimer(p.backoff(n))
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return err
+			case <-t.C:
+			}
+		}
+	}
+}
@@ -0,0 +1,232 @@
+// Package dataset reads vector datasets in the formats benchmark suites and
+// pipelines use: .fvecs, .bvecs and .ivecs (the TEXMEX format of SIFT,
+// GIST and Deep1B), NumPy .npy arrays, and JSON Lines. Readers stream rows,
+// so files larger than memory can be imported.
+package dataset
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+// Row is one record of a dataset. Only JSONL rows can carry an ID, sparse
+// vector or text; see WithIDs for the other formats.
+type Row struct {
+	ID     string
+	Vector vec.Vector
+	Sparse vec.SparseVector
+	Text   string
+}
+
+// Reader yields rows in file order. Next returns io.EOF after the last.
+type Reader interface {
+	Next() (Row, error)
+}
+
+// Writer is the output side of Reader. Formats without IDs, sparse vectors
+// or text drop those fields. Close finishes the file but does not close
+// the underlying writer.
+type Writer interface {
+	Write(Row) error
+	Close() error
+}
+
+// ErrNoVector is returned when a row without a dense vector is written to
+// a format that holds nothing else.
+var ErrNoVector = errors.New("dataset: row has no dense vector")
+
+// Format names a file format.
+type Format string
+
+const (
+	FormatFvecs Format = "fvecs"
+	FormatBvecs Format = "bvecs"
+	FormatIvecs Format = "ivecs"
+	FormatNpy   Format = "npy"
+	FormatJSONL Format = "jsonl"
+)
+
+// FormatOf guesses the format from a file name's extension.
+func FormatOf(path string) (Format, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".fvecs":
+		return FormatFvecs, nil
+	case ".bvecs":
+		return FormatBvecs, nil
+	case ".ivecs":
+		return FormatIvecs, nil
+	case ".npy":
+		return FormatNpy, nil
+	case ".jsonl", ".ndjson", ".json":
+		return FormatJSONL, nil
+	default:
+		return "", fmt.Errorf("dataset: unknown format for %q; name it", path)
+	}
+}
+
+// NewReader reads r in format f. JSONL rows are read with DefaultJSONLFields.
+func NewReader(r io.Reader, f Format) (Reader, error) {
+	switch f {
+	case FormatFvecs:
+		return NewFvecsReader(r), nil
+	case FormatBvecs:
+		return NewBvecsReader(r), nil
+	case FormatIvecs:
+		return NewIvecsReader(r), nil
+	case FormatNpy:
+		return NewNpyReader(r)
+	case FormatJSONL:
+		return NewJSONLReader(r, DefaultJSONLFields), nil
+	}
+	return nil, fmt.Errorf("dataset: unknown format %q", f)
+}
+
+// File is a Reader over an open file.
+type File struct {
+	Reader
+	f *os.File
+}
+
+func (f *File) Close() error { return f.f.Close() }
+
+// Open reads the file at path in format f, or the format its extension
+// suggests when f is empty.
+func Open(path string, f Format) (*File, error) {
+	if f == "" {
+		var err error
+		if f, err = FormatOf(path); err != nil {
+			return nil, err
+		}
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewReader(bufio.NewReaderSize(file, 1<<20), f)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &File{Reader: r, f: file}, nil
+}
+
+// IDFunc names row i (0-based) of a dataset.
+type IDFunc func(i int) (string, error)
+
+// SequentialIDs names rows prefix+start, prefix+(start+1), ...
+func SequentialIDs(prefix string, start int) IDFunc {
+	return func(i int) (string, error) {
+		return prefix + strconv.Itoa(start+i), nil
+	}
+}
+
+// LineIDs takes IDs from r, one per line, for datasets whose IDs are kept
+// in a separate file. Running out of lines is an error.
+func LineIDs(r io.Reader) IDFunc {
+	sc := bufio.NewScanner(r)
+	return func(i int) (string, error) {
+		if !sc.Scan() {
+			if err := sc.Err(); err != nil {
+				return "", err
+			}
+			return "", fmt.Errorf("dataset: ID file ended at row %d", i)
+		}
+		return strings.TrimSpace(sc.Text()), nil
+	}
+}
+
+// WithIDs names the rows of r that have no ID with ids.
+func WithIDs(r Reader, ids IDFunc) Reader {
+	return &idReader{r: r, ids: ids}
+}
+
+type idReader struct {
+	r   Reader
+	ids IDFunc
+	n   int
+}
+
+func (r *idReader) Next() (Row, error) {
+	row, err := r.r.Next()
+	if err != nil {
+		return row, err
+	}
+	i := r.n
+	r.n++
+	if row.ID == "" {
+		if row.ID, err = r.ids(i); err != nil {
+			return Row{}, err
+		}
+	}
+	return row, nil
+}
+
+// WithLineIDs writes the ID of each row passed to w to ids, one per line,
+// in the layout LineIDs reads.
+func WithLineIDs(w Writer, ids io.Writer) Writer {
+	return &idWriter{w: w, ids: bufio.NewWriter(ids)}
+}
+
+type idWriter struct {
+	w   Writer
+	ids *bufio.Writer
+}
+
+func (w *idWriter) Write(row Row) error {
+	if strings.ContainsAny(row.ID, "\r\n") {
+		return fmt.Errorf("dataset: ID %q spans lines", row.ID)
+	}
+	if err := w.w.Write(row); err != nil {
+		return err
+	}
+	w.ids.WriteString(row.ID)
+	return w.ids.WriteByte('\n')
+}
+
+func (w *idWriter) Close() error {
+	if err := w.w.Close(); err != nil {
+		return err
+	}
+	return w.ids.Flush()
+}
+
+// Limit stops r after n rows.
+func Limit(r Reader, n int) Reader {
+	return &limitReader{r: r, left: n}
+}
+
+type limitReader struct {
+	r    Reader
+	left int
+}
+
+func (r *limitReader) Next() (Row, error) {
+	if r.left <= 0 {
+		return Row{}, io.EOF
+	}
+	r.left--
+	return r.r.Next()
+}
+
+// ReadAll collects the remaining rows of r.
+func ReadAll(r Reader) ([]Row, error) {
+	var rows []Row
+	for {
+		row, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return rows, err
+		}
+		rows = append(rows, row)
+	}
+}
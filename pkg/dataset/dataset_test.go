@@ -0,0 +1,214 @@
+package dataset
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+var testVectors = []vec.Vector{{1, 2, 3}, {-4, 0.5, 6}}
+
+func readAll(t *testing.T, r Reader) []Row {
+	t.Helper()
+	rows, err := ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+func vectors(rows []Row) []vec.Vector {
+	vs := make([]vec.Vector, len(rows))
+	for i, r := range rows {
+		vs[i] = r.Vector
+	}
+	return vs
+}
+
+func TestVecsFormats(t *testing.T) {
+	var f bytes.Buffer
+	WriteFvecs(&f, testVectors)
+	if got := vectors(readAll(t, NewFvecsReader(bytes.NewReader(f.Bytes())))); !reflect.DeepEqual(got, testVectors) {
+		t.Errorf("fvecs = %v", got)
+	}
+
+	b := []byte{2, 0, 0, 0, 7, 255}
+	if got := vectors(readAll(t, NewBvecsReader(bytes.NewReader(b)))); !reflect.DeepEqual(got, []vec.Vector{{7, 255}}) {
+		t.Errorf("bvecs = %v", got)
+	}
+
+	var iv bytes.Buffer
+	binary.Write(&iv, binary.LittleEndian, []int32{3, 10, -1, 42, 1, 5})
+	if got := vectors(readAll(t, NewIvecsReader(bytes.NewReader(iv.Bytes())))); !reflect.DeepEqual(got, []vec.Vector{{10, -1, 42}, {5}}) {
+		t.Errorf("ivecs = %v", got)
+	}
+	gt, err := ReadIvecs(bytes.NewReader(iv.Bytes()))
+	if err != nil || !reflect.DeepEqual(gt, [][]int32{{10, -1, 42}, {5}}) {
+		t.Errorf("ReadIvecs = %v, %v", gt, err)
+	}
+
+	if _, err := ReadAll(NewFvecsReader(bytes.NewReader(f.Bytes()[:f.Len()-2]))); err == nil {
+		t.Error("expected an error for a truncated fvecs file")
+	}
+}
+
+func TestNpy(t *testing.T) {
+	var f4 bytes.Buffer
+	if err := WriteNpy(&f4, testVectors); err != nil {
+		t.Fatal(err)
+	}
+	if f4.Len()%4 != 0 || (f4.Len()-4*6)%64 != 0 {
+		t.Errorf("data is not 64-byte aligned in a %d byte file", f4.Len())
+	}
+	r, err := NewNpyReader(bytes.NewReader(f4.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := vectors(readAll(t, r)); !reflect.DeepEqual(got, testVectors) {
+		t.Errorf("f4 npy = %v", got)
+	}
+
+	var f8 bytes.Buffer
+	WriteNpyHeader(&f8, "<f8", 1, 2)
+	binary.Write(&f8, binary.LittleEndian, []float64{0.25, -8})
+	r, err = NewNpyReader(&f8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := vectors(readAll(t, r)); !reflect.DeepEqual(got, []vec.Vector{{0.25, -8}}) {
+		t.Errorf("f8 npy = %v", got)
+	}
+
+	for _, descr := range []string{">f4", "<c8", "|O"} {
+		var bad bytes.Buffer
+		WriteNpyHeader(&bad, descr, 1, 1)
+		if _, err := NewNpyReader(&bad); err == nil {
+			t.Errorf("expected dtype %s to be rejected", descr)
+		}
+	}
+}
+
+func TestJSONL(t *testing.T) {
+	in := `{"id": "a", "vector": [1, 2], "text": "hi"}
+
+{"id": 7, "sparse": {"indices": [9, 2], "values": [1, 0.5]}}
+{"vector": [3]}
+`
+	rows := readAll(t, NewJSONLReader(strings.NewReader(in), DefaultJSONLFields))
+	if len(rows) != 3 || rows[0].ID != "a" || rows[0].Text != "hi" || rows[1].ID != "7" ||
+		rows[1].Sparse.Indices[0] != 2 || rows[2].ID != "" {
+		t.Errorf("rows = %+v", rows)
+	}
+
+	custom := JSONLFields{ID: "doc_id", Vector: "emb"}
+	rows = readAll(t, NewJSONLReader(strings.NewReader(`{"doc_id": "x", "emb": [1], "text": "ignored"}`), custom))
+	if len(rows) != 1 || rows[0].ID != "x" || rows[0].Text != "" || rows[0].Vector[0] != 1 {
+		t.Errorf("custom fields: %+v", rows)
+	}
+
+	_, err := ReadAll(NewJSONLReader(strings.NewReader("{\"id\": \"a\"}\n{oops\n"), DefaultJSONLFields))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("bad line error = %v", err)
+	}
+}
+
+func TestIDs(t *testing.T) {
+	var f bytes.Buffer
+	WriteFvecs(&f, []vec.Vector{{1}, {2}, {3}})
+
+	rows := readAll(t, WithIDs(NewFvecsReader(bytes.NewReader(f.Bytes())), SequentialIDs("sift-", 10)))
+	if rows[0].ID != "sift-10" || rows[2].ID != "sift-12" {
+		t.Errorf("sequential IDs: %+v", rows)
+	}
+
+	r := WithIDs(NewFvecsReader(bytes.NewReader(f.Bytes())), LineIDs(strings.NewReader("x\ny\n")))
+	rows, err := ReadAll(r)
+	if err == nil || len(rows) != 2 || rows[1].ID != "y" {
+		t.Errorf("short ID file: %+v, %v", rows, err)
+	}
+
+	rows = readAll(t, Limit(NewFvecsReader(bytes.NewReader(f.Bytes())), 2))
+	if len(rows) != 2 {
+		t.Errorf("Limit(2) returned %d rows", len(rows))
+	}
+	if _, err := Limit(NewFvecsReader(&bytes.Buffer{}), 1).Next(); err != io.EOF {
+		t.Errorf("empty file: %v", err)
+	}
+}
+
+func TestFormatOf(t *testing.T) {
+	for path, want := range map[string]Format{
+		"sift_base.fvecs": FormatFvecs, "bigann.BVECS": FormatBvecs, "gt.ivecs": FormatIvecs,
+		"emb.npy": FormatNpy, "docs.jsonl": FormatJSONL, "docs.ndjson": FormatJSONL,
+	} {
+		if got, err := FormatOf(path); got != want || err != nil {
+			t.Errorf("FormatOf(%s) = %q, %v", path, got, err)
+		}
+	}
+	if _, err := FormatOf("data.csv"); err == nil {
+		t.Error("expected an error for .csv")
+	}
+}
+
+func writeAll(t *testing.T, w Writer, rows []Row) {
+	t.Helper()
+	for _, r := range rows {
+		if err := w.Write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriters(t *testing.T) {
+	sp, _ := vec.NewSparseVector([]uint32{3, 9}, []float32{1, 0.5})
+	rows := []Row{
+		{ID: "a", Vector: testVectors[0], Text: "line\n\"quoted\""},
+		{ID: "b", Vector: testVectors[1], Sparse: sp},
+	}
+
+	var jl bytes.Buffer
+	writeAll(t, NewJSONLWriter(&jl, DefaultJSONLFields), append(rows, Row{ID: "c", Text: "text only"}))
+	got := readAll(t, NewJSONLReader(&jl, DefaultJSONLFields))
+	if len(got) != 3 || !reflect.DeepEqual(got[:2], rows) || got[2].ID != "c" || got[2].Vector != nil {
+		t.Errorf("jsonl round trip = %+v", got)
+	}
+
+	var fv, ids bytes.Buffer
+	writeAll(t, WithLineIDs(NewFvecsWriter(&fv), &ids), rows)
+	got = readAll(t, WithIDs(NewFvecsReader(&fv), LineIDs(&ids)))
+	if got[1].ID != "b" || !reflect.DeepEqual(vectors(got), testVectors) {
+		t.Errorf("fvecs + ID file round trip = %+v", got)
+	}
+	if err := NewFvecsWriter(&fv).Write(Row{ID: "c"}); err != ErrNoVector {
+		t.Errorf("fvecs row without a vector: %v", err)
+	}
+
+	path := t.TempDir() + "/out.npy"
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := NewNpyWriter(f)
+	writeAll(t, w, rows)
+	if err := w.Write(Row{Vector: vec.Vector{1}}); err == nil {
+		t.Error("expected an error for a row of another dimension")
+	}
+	f.Close()
+	in, err := Open(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	if got := vectors(readAll(t, in)); !reflect.DeepEqual(got, testVectors) {
+		t.Errorf("npy round trip = %v", got)
+	}
+}
@@ -0,0 +1,29 @@
+package dataset
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sandeep89846/nebuladb/pkg/client"
+)
+
+// Import streams the rows of r to the server's bulk insert path. Rows must
+// have IDs; wrap r with WithIDs for formats that carry none. progress, if
+// not nil, is called with the number of rows sent after every 10000.
+func Import(ctx context.Context, c *client.Client, r Reader, progress func(sent int)) (*client.BulkResult, error) {
+	sent := 0
+	return c.BulkInsert(ctx, func() (client.Item, error) {
+		row, err := r.Next()
+		if err != nil {
+			return client.Item{}, err
+		}
+		if row.ID == "" {
+			return client.Item{}, errors.New("dataset: row has no ID; use WithIDs")
+		}
+		sent++
+		if progress != nil && sent%10000 == 0 {
+			progress(sent)
+		}
+		return client.Item{ID: row.ID, Vector: row.Vector, Sparse: row.Sparse, Text: row.Text}, nil
+	})
+}
@@ -0,0 +1,149 @@
+package dataset
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+// JSONLFields names the keys of a JSONL row. Empty names are not read.
+// The ID may be a JSON string or number.
+type JSONLFields struct {
+	ID     string
+	Vector string
+	Sparse string // {"indices": [...], "values": [...]}
+	Text   string
+}
+
+var DefaultJSONLFields = JSONLFields{ID: "id", Vector: "vector", Sparse: "sparse", Text: "text"}
+
+type jsonlReader struct {
+	sc     *bufio.Scanner
+	fields JSONLFields
+	line   int
+}
+
+// NewJSONLReader reads one JSON object per line, skipping blank lines.
+func NewJSONLReader(r io.Reader, fields JSONLFields) Reader {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 1<<20), 64<<20)
+	return &jsonlReader{sc: sc, fields: fields}
+}
+
+func (r *jsonlReader) Next() (Row, error) {
+	for r.sc.Scan() {
+		r.line++
+		b := r.sc.Bytes()
+		if len(b) == 0 {
+			continue
+		}
+		row, err := r.parse(b)
+		if err != nil {
+			return Row{}, fmt.Errorf("jsonl: line %d: %w", r.line, err)
+		}
+		return row, nil
+	}
+	if err := r.sc.Err(); err != nil {
+		return Row{}, fmt.Errorf("jsonl: line %d: %w", r.line+1, err)
+	}
+	return Row{}, io.EOF
+}
+
+func (r *jsonlReader) parse(b []byte) (Row, error) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return Row{}, err
+	}
+	var row Row
+	if raw, ok := obj[r.fields.ID]; ok && r.fields.ID != "" {
+		var id any
+		if err := json.Unmarshal(raw, &id); err != nil {
+			return Row{}, err
+		}
+		switch id := id.(type) {
+		case string:
+			row.ID = id
+		case float64:
+			row.ID = strconv.FormatFloat(id, 'f', -1, 64)
+		default:
+			return Row{}, fmt.Errorf("%q is neither a string nor a number", r.fields.ID)
+		}
+	}
+	if raw, ok := obj[r.fields.Vector]; ok && r.fields.Vector != "" {
+		if err := json.Unmarshal(raw, &row.Vector); err != nil {
+			return Row{}, fmt.Errorf("%q: %w", r.fields.Vector, err)
+		}
+	}
+	if raw, ok := obj[r.fields.Sparse]; ok && r.fields.Sparse != "" {
+		var sp struct {
+			Indices []uint32  `json:"indices"`
+			Values  []float32 `json:"values"`
+		}
+		if err := json.Unmarshal(raw, &sp); err != nil {
+			return Row{}, fmt.Errorf("%q: %w", r.fields.Sparse, err)
+		}
+		var err error
+		if row.Sparse, err = vec.NewSparseVector(sp.Indices, sp.Values); err != nil {
+			return Row{}, fmt.Errorf("%q: %w", r.fields.Sparse, err)
+		}
+	}
+	if raw, ok := obj[r.fields.Text]; ok && r.fields.Text != "" {
+		if err := json.Unmarshal(raw, &row.Text); err != nil {
+			return Row{}, fmt.Errorf("%q: %w", r.fields.Text, err)
+		}
+	}
+	return row, nil
+}
+
+type jsonlWriter struct {
+	w      *bufio.Writer
+	fields JSONLFields
+	line   []byte
+}
+
+// NewJSONLWriter writes one JSON object per row under the keys in fields,
+// in the layout NewJSONLReader reads. Empty values and keys are left out.
+func NewJSONLWriter(w io.Writer, fields JSONLFields) Writer {
+	return &jsonlWriter{w: bufio.NewWriterSize(w, 1<<20), fields: fields}
+}
+
+func (w *jsonlWriter) Write(row Row) error {
+	b := append(w.line[:0], '{')
+	add := func(key string, v any) error {
+		if key == "" {
+			return nil
+		}
+		if len(b) > 1 {
+			b = append(b, ", "...)
+		}
+		k, _ := json.Marshal(key)
+		enc, err := json.Marshal(v)
+		b = append(append(append(b, k...), ": "...), enc...)
+		return err
+	}
+	var err error
+	if row.ID != "" {
+		err = add(w.fields.ID, row.ID)
+	}
+	if len(row.Vector) > 0 && err == nil {
+		err = add(w.fields.Vector, row.Vector)
+	}
+	if row.Sparse.Len() > 0 && err == nil {
+		err = add(w.fields.Sparse, map[string]any{"indices": row.Sparse.Indices, "values": row.Sparse.Values})
+	}
+	if row.Text != "" && err == nil {
+		err = add(w.fields.Text, row.Text)
+	}
+	if err != nil {
+		return fmt.Errorf("jsonl: row %q: %w", row.ID, err)
+	}
+	w.line = append(b, '}', '\n')
+	_, err = w.w.Write(w.line)
+	return err
+}
+
+func (w *jsonlWriter) Close() error { return w.w.Flush() }
@@ -0,0 +1,256 @@
+package dataset
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+var npyMagic = []byte("\x93NUMPY")
+
+var (
+	npyDescr   = regexp.MustCompile(`'descr'\s*:\s*'([^']*)'`)
+	npyFortran = regexp.MustCompile(`'fortran_order'\s*:\s*(True|False)`)
+	npyShape   = regexp.MustCompile(`'shape'\s*:\s*\(([^)]*)\)`)
+)
+
+// npyType decodes one little-endian element of a NumPy dtype.
+type npyType struct {
+	size   int
+	decode func(b []byte) float32
+}
+
+var npyTypes = map[string]npyType{
+	"f4": {4, func(b []byte) float32 { return math.Float32frombits(binary.LittleEndian.Uint32(b)) }},
+	"f8": {8, func(b []byte) float32 { return float32(math.Float64frombits(binary.LittleEndian.Uint64(b))) }},
+	"f2": {2, func(b []byte) float32 { return vec.Float16(binary.LittleEndian.Uint16(b)).Float32() }},
+	"u1": {1, func(b []byte) float32 { return float32(b[0]) }},
+	"i1": {1, func(b []byte) float32 { return float32(int8(b[0])) }},
+	"i4": {4, func(b []byte) float32 { return float32(int32(binary.LittleEndian.Uint32(b))) }},
+	"i8": {8, func(b []byte) float32 { return float32(int64(binary.LittleEndian.Uint64(b))) }},
+}
+
+// NpyHeader describes a 2-D array: Rows vectors of Dim components.
+type NpyHeader struct {
+	Descr string
+	Rows  int
+	Dim   int
+}
+
+type npyReader struct {
+	r      io.Reader
+	header NpyHeader
+	typ    npyType
+	row    int
+	buf    []byte
+}
+
+// NewNpyReader reads a 2-D C-order array of float32, float64, float16,
+// int8, uint8, int32 or int64, one row per vector.
+func NewNpyReader(r io.Reader) (Reader, error) {
+	h, err := readNpyHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	typ, ok := npyTypes[strings.TrimLeft(h.Descr, "<|")]
+	if !ok || strings.HasPrefix(h.Descr, ">") {
+		return nil, fmt.Errorf("npy: unsupported dtype %q", h.Descr)
+	}
+	return &npyReader{r: r, header: h, typ: typ, buf: make([]byte, h.Dim*typ.size)}, nil
+}
+
+func readNpyHeader(r io.Reader) (NpyHeader, error) {
+	var h NpyHeader
+	pre := make([]byte, 8)
+	if _, err := io.ReadFull(r, pre); err != nil {
+		return h, fmt.Errorf("npy: %w", err)
+	}
+	if string(pre[:6]) != string(npyMagic) {
+		return h, errors.New("npy: not a NumPy file")
+	}
+	var n int
+	switch pre[6] {
+	case 1:
+		var l uint16
+		if err := binary.Read(r, binary.LittleEndian, &l); err != nil {
+			return h, fmt.Errorf("npy: %w", err)
+		}
+		n = int(l)
+	case 2, 3:
+		var l uint32
+		if err := binary.Read(r, binary.LittleEndian, &l); err != nil {
+			return h, fmt.Errorf("npy: %w", err)
+		}
+		n = int(l)
+	default:
+		return h, fmt.Errorf("npy: unsupported version %d", pre[6])
+	}
+	if n > 1<<20 {
+		return h, fmt.Errorf("npy: header of %d bytes", n)
+	}
+	raw := make([]byte, n)
+	if _, err := io.ReadFull(r, raw); err != nil {
+		return h, fmt.Errorf("npy: %w", err)
+	}
+	dict := string(raw)
+
+	m := npyDescr.FindStringSubmatch(dict)
+	if m == nil {
+		return h, errors.New("npy: header has no descr")
+	}
+	h.Descr = m[1]
+	if m := npyFortran.FindStringSubmatch(dict); m == nil || m[1] != "False" {
+		return h, errors.New("npy: only C-order arrays are supported")
+	}
+	m = npyShape.FindStringSubmatch(dict)
+	if m == nil {
+		return h, errors.New("npy: header has no shape")
+	}
+	var dims []int
+	for _, f := range strings.Split(m[1], ",") {
+		if f = strings.TrimSpace(f); f == "" {
+			continue
+		}
+		d, err := strconv.Atoi(f)
+		if err != nil {
+			return h, fmt.Errorf("npy: bad shape %q", m[1])
+		}
+		dims = append(dims, d)
+	}
+	if len(dims) != 2 || dims[1] <= 0 {
+		return h, fmt.Errorf("npy: shape (%s) is not rows x dimension", m[1])
+	}
+	h.Rows, h.Dim = dims[0], dims[1]
+	return h, nil
+}
+
+func (r *npyReader) Next() (Row, error) {
+	if r.row == r.header.Rows {
+		return Row{}, io.EOF
+	}
+	if _, err := io.ReadFull(r.r, r.buf); err != nil {
+		return Row{}, fmt.Errorf("npy: row %d of %d: %w", r.row, r.header.Rows, err)
+	}
+	v := make(vec.Vector, r.header.Dim)
+	for i := range v {
+		v[i] = r.typ.decode(r.buf[i*r.typ.size:])
+	}
+	r.row++
+	return Row{Vector: v}, nil
+}
+
+// WriteNpy writes vs, which must share a dimension, as a float32 array.
+func WriteNpy(w io.Writer, vs []vec.Vector) error {
+	dim := 0
+	if len(vs) > 0 {
+		dim = len(vs[0])
+	}
+	if err := WriteNpyHeader(w, "<f4", len(vs), dim); err != nil {
+		return err
+	}
+	for _, v := range vs {
+		if len(v) != dim {
+			return vec.ErrDimensionMismatch
+		}
+		b := make([]byte, 4*dim)
+		for i, f := range v {
+			binary.LittleEndian.PutUint32(b[4*i:], math.Float32bits(f))
+		}
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteNpyHeader writes a version 1.0 header for a rows x dim C-order
+// array of descr, padded so the data starts 64-byte aligned.
+func WriteNpyHeader(w io.Writer, descr string, rows, dim int) error {
+	_, err := w.Write(npyHeader(descr, fmt.Sprintf("%d, %d", rows, dim)))
+	return err
+}
+
+func npyHeader(descr, shape string) []byte {
+	dict := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': (%s), }", descr, shape)
+	total := len(npyMagic) + 2 + 2 + len(dict) + 1
+	pad := (64 - total%64) % 64
+	header := dict + strings.Repeat(" ", pad) + "\n"
+
+	b := append([]byte{}, npyMagic...)
+	b = append(b, 1, 0)
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(header)))
+	return append(b, header...)
+}
+
+// npyRowsWidth is the space reserved for the row count in a streamed
+// header, enough for any int64, so rewriting it keeps the header length.
+const npyRowsWidth = 20
+
+type npyWriter struct {
+	f    io.WriteSeeker
+	w    *bufio.Writer
+	rows int
+	dim  int
+	buf  []byte
+}
+
+// NewNpyWriter writes rows as a float32 array. The row count is not known
+// up front, so Close seeks back to fill it into the header. Rows must have
+// vectors of one dimension.
+func NewNpyWriter(w io.WriteSeeker) Writer {
+	return &npyWriter{f: w, w: bufio.NewWriterSize(w, 1<<20)}
+}
+
+func (w *npyWriter) header() []byte {
+	return npyHeader("<f4", fmt.Sprintf("%*d, %d", npyRowsWidth, w.rows, w.dim))
+}
+
+func (w *npyWriter) Write(row Row) error {
+	if len(row.Vector) == 0 {
+		return ErrNoVector
+	}
+	if w.rows == 0 {
+		w.dim = len(row.Vector)
+		w.buf = make([]byte, 4*w.dim)
+		if _, err := w.w.Write(w.header()); err != nil {
+			return err
+		}
+	}
+	if len(row.Vector) != w.dim {
+		return vec.ErrDimensionMismatch
+	}
+	for i, f := range row.Vector {
+		binary.LittleEndian.PutUint32(w.buf[4*i:], math.Float32bits(f))
+	}
+	w.rows++
+	_, err := w.w.Write(w.buf)
+	return err
+}
+
+func (w *npyWriter) Close() error {
+	if w.rows == 0 {
+		if err := WriteNpyHeader(w.w, "<f4", 0, 0); err != nil {
+			return err
+		}
+		return w.w.Flush()
+	}
+	if err := w.w.Flush(); err != nil {
+		return err
+	}
+	if _, err := w.f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := w.f.Write(w.header()); err != nil {
+		return err
+	}
+	_, err := w.f.Seek(0, io.SeekEnd)
+	return err
+}
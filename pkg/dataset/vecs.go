@@ -0,0 +1,133 @@
+package dataset
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/sandeep89846/nebuladb/pkg/vec"
+)
+
+// maxVecsDim rejects garbage dimensions before allocating for them.
+const maxVecsDim = 1 << 20
+
+// vecsReader reads the TEXMEX layout: each vector is a little-endian int32
+// dimension followed by that many components of elemSize bytes.
+type vecsReader struct {
+	r        io.Reader
+	elemSize int
+	decode   func(b []byte, out vec.Vector)
+	row      int
+	buf      []byte
+}
+
+// NewFvecsReader reads float32 components.
+func NewFvecsReader(r io.Reader) Reader {
+	return &vecsReader{r: r, elemSize: 4, decode: func(b []byte, out vec.Vector) {
+		for i := range out {
+			out[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+		}
+	}}
+}
+
+// NewBvecsReader reads uint8 components, widened to float32.
+func NewBvecsReader(r io.Reader) Reader {
+	return &vecsReader{r: r, elemSize: 1, decode: func(b []byte, out vec.Vector) {
+		for i := range out {
+			out[i] = float32(b[i])
+		}
+	}}
+}
+
+// NewIvecsReader reads int32 components, converted to float32. Ground
+// truth neighbor lists are better read with ReadIvecs.
+func NewIvecsReader(r io.Reader) Reader {
+	return &vecsReader{r: r, elemSize: 4, decode: func(b []byte, out vec.Vector) {
+		for i := range out {
+			out[i] = float32(int32(binary.LittleEndian.Uint32(b[4*i:])))
+		}
+	}}
+}
+
+func (r *vecsReader) Next() (Row, error) {
+	var head [4]byte
+	if _, err := io.ReadFull(r.r, head[:]); err != nil {
+		if err == io.EOF {
+			return Row{}, io.EOF
+		}
+		return Row{}, fmt.Errorf("dataset: row %d: %w", r.row, err)
+	}
+	dim := int(int32(binary.LittleEndian.Uint32(head[:])))
+	if dim <= 0 || dim > maxVecsDim {
+		return Row{}, fmt.Errorf("dataset: row %d: invalid dimension %d", r.row, dim)
+	}
+	if n := dim * r.elemSize; cap(r.buf) < n {
+		r.buf = make([]byte, n)
+	}
+	b := r.buf[:dim*r.elemSize]
+	if _, err := io.ReadFull(r.r, b); err != nil {
+		return Row{}, fmt.Errorf("dataset: row %d: %w", r.row, err)
+	}
+	v := make(vec.Vector, dim)
+	r.decode(b, v)
+	r.row++
+	return Row{Vector: v}, nil
+}
+
+// ReadIvecs reads a whole .ivecs file as integer rows, the layout of the
+// ground truth neighbor lists shipped with SIFT and GIST.
+func ReadIvecs(r io.Reader) ([][]int32, error) {
+	var rows [][]int32
+	for {
+		var dim int32
+		if err := binary.Read(r, binary.LittleEndian, &dim); err != nil {
+			if err == io.EOF {
+				return rows, nil
+			}
+			return nil, fmt.Errorf("dataset: row %d: %w", len(rows), err)
+		}
+		if dim <= 0 || dim > maxVecsDim {
+			return nil, fmt.Errorf("dataset: row %d: invalid dimension %d", len(rows), dim)
+		}
+		row := make([]int32, dim)
+		if err := binary.Read(r, binary.LittleEndian, row); err != nil {
+			return nil, fmt.Errorf("dataset: row %d: %w", len(rows), err)
+		}
+		rows = append(rows, row)
+	}
+}
+
+// WriteFvecs writes vectors in .fvecs layout.
+func WriteFvecs(w io.Writer, vs []vec.Vector) error {
+	for _, v := range vs {
+		b := make([]byte, 4+4*len(v))
+		binary.LittleEndian.PutUint32(b, uint32(len(v)))
+		for i, f := range v {
+			binary.LittleEndian.PutUint32(b[4+4*i:], math.Float32bits(f))
+		}
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type fvecsWriter struct {
+	w *bufio.Writer
+}
+
+// NewFvecsWriter writes rows in .fvecs layout. Rows must have a vector.
+func NewFvecsWriter(w io.Writer) Writer {
+	return &fvecsWriter{w: bufio.NewWriterSize(w, 1<<20)}
+}
+
+func (w *fvecsWriter) Write(row Row) error {
+	if len(row.Vector) == 0 {
+		return ErrNoVector
+	}
+	return WriteFvecs(w.w, []vec.Vector{row.Vector})
+}
+
+func (w *fvecsWriter) Close() error { return w.w.Flush() }
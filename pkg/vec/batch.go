@@ -0,0 +1,161 @@
+package vec
+
+// Matrix is a row-major block of equal-length vectors stored in a single
+// contiguous slice. Row i occupies Data[i*Dim : (i+1)*Dim].
+type Matrix struct {
+	Dim  int
+	Data []float32
+}
+
+// NewMatrix allocates a zeroed matrix with the given number of rows.
+func NewMatrix(dim, rows int) Matrix {
+	return Matrix{Dim: dim, Data: make([]float32, dim*rows)}
+}
+
+// Rows returns the number of complete rows held by the matrix.
+func (m Matrix) Rows() int {
+	if m.Dim <= 0 {
+		return 0
+	}
+	return len(m.Data) / m.Dim
+}
+
+// Row returns row i as a Vector that aliases the matrix storage.
+func (m Matrix) Row(i int) Vector {
+	return Vector(m.Data[i*m.Dim : (i+1)*m.Dim : (i+1)*m.Dim])
+}
+
+// Append copies v onto the end of the matrix.
+func (m *Matrix) Append(v Vector) error {
+	if len(v) != m.Dim {
+		return ErrDimensionMismatch
+	}
+	m.Data = append(m.Data, v...)
+	return nil
+}
+
+// dot is the unchecked inner kernel shared by the pairwise and batch paths.
+// Unrolled by 4, callers guarantee len(a) == len(b).
+func dot(a, b []float32) float32 {
+	n := len(a)
+	b = b[:n] // hoist bounds check
+	var sum float32
+	i := 0
+	for ; i+3 < n; i += 4 {
+		sum += a[i]*b[i] + a[i+1]*b[i+1] + a[i+2]*b[i+2] + a[i+3]*b[i+3]
+	}
+	for ; i < n; i++ {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+// DotBatch computes the dot product of q against every row of m.
+// out must have room for m.Rows() values.
+func DotBatch(q Vector, m Matrix, out []float32) error {
+	if len(q) != m.Dim {
+		return ErrDimensionMismatch
+	}
+	rows := m.Rows()
+	if len(out) < rows {
+		return ErrShortBuffer
+	}
+	for i := 0; i < rows; i++ {
+		out[i] = dot(q, m.Data[i*m.Dim:(i+1)*m.Dim])
+	}
+	return nil
+}
+
+// DotGather is DotBatch for rows that are not adjacent in memory (e.g.
+// neighbor lists gathered out of an arena). Dimensions are checked once
+// up front instead of on every pair.
+func DotGather(q Vector, rows []Vector, out []float32) error {
+	if len(out) < len(rows) {
+		return ErrShortBuffer
+	}
+	for _, r := range rows {
+		if len(r) != len(q) {
+			return ErrDimensionMismatch
+		}
+	}
+	for i, r := range rows {
+		out[i] = dot(q, r)
+	}
+	return nil
+}
+
+// CosineBatch computes the cosine similarity of q against every row of m.
+// Rows with zero magnitude get a score of 0.
+func CosineBatch(q Vector, m Matrix, out []float32) error {
+	if err := DotBatch(q, m, out); err != nil {
+		return err
+	}
+	qmag := Magnitude(q)
+	if qmag == 0 {
+		return DivisionByZero
+	}
+	for i := 0; i < m.Rows(); i++ {
+		rmag := Magnitude(m.Row(i))
+		if rmag == 0 {
+			out[i] = 0
+			continue
+		}
+		out[i] /= qmag * rmag
+	}
+	return nil
+}
+
+// DotMatrix computes every pairwise dot product between the rows of a and b.
+// out is row-major: out[i*b.Rows()+j] = a[i] . b[j].
+func DotMatrix(a, b Matrix, out []float32) error {
+	if a.Dim != b.Dim {
+		return ErrDimensionMismatch
+	}
+	ra, rb := a.Rows(), b.Rows()
+	if len(out) < ra*rb {
+		return ErrShortBuffer
+	}
+
+	// Block over b so a tile of its rows stays in cache while we sweep a.
+	const tile = 64
+	for j0 := 0; j0 < rb; j0 += tile {
+		j1 := j0 + tile
+		if j1 > rb {
+			j1 = rb
+		}
+		for i := 0; i < ra; i++ {
+			av := a.Data[i*a.Dim : (i+1)*a.Dim]
+			row := out[i*rb:]
+			for j := j0; j < j1; j++ {
+				row[j] = dot(av, b.Data[j*b.Dim:(j+1)*b.Dim])
+			}
+		}
+	}
+	return nil
+}
+
+// CosineMatrix is DotMatrix normalized by row magnitudes. Pairs involving a
+// zero-magnitude row get a score of 0.
+func CosineMatrix(a, b Matrix, out []float32) error {
+	if err := DotMatrix(a, b, out); err != nil {
+		return err
+	}
+	ra, rb := a.Rows(), b.Rows()
+	bmag := make([]float32, rb)
+	for j := range bmag {
+		bmag[j] = Magnitude(b.Row(j))
+	}
+	for i := 0; i < ra; i++ {
+		amag := Magnitude(a.Row(i))
+		row := out[i*rb : (i+1)*rb]
+		for j := range row {
+			d := amag * bmag[j]
+			if d == 0 {
+				row[j] = 0
+				continue
+			}
+			row[j] /= d
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package vec
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func randMatrix(r *rand.Rand, dim, rows int) Matrix {
+	m := NewMatrix(dim, rows)
+	for i := range m.Data {
+		m.Data[i] = r.Float32()*2 - 1
+	}
+	return m
+}
+
+func TestDotBatch(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	m := randMatrix(r, 37, 20) // odd dim exercises the unroll tail
+	q := randMatrix(r, 37, 1).Row(0)
+
+	out := make([]float32, m.Rows())
+	if err := DotBatch(q, m, out); err != nil {
+		t.Fatalf("DotBatch() error = %v", err)
+	}
+
+	for i := 0; i < m.Rows(); i++ {
+		want, _ := DotProduct(q, m.Row(i))
+		if diff := out[i] - want; diff > eps || diff < -eps {
+			t.Errorf("DotBatch()[%d] = %v, want %v", i, out[i], want)
+		}
+	}
+
+	if err := DotBatch(Vector{1, 2}, m, out); err != ErrDimensionMismatch {
+		t.Errorf("DotBatch() error = %v, want %v", err, ErrDimensionMismatch)
+	}
+	if err := DotBatch(q, m, out[:3]); err != ErrShortBuffer {
+		t.Errorf("DotBatch() error = %v, want %v", err, ErrShortBuffer)
+	}
+}
+
+func TestDotGather(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	m := randMatrix(r, 8, 10)
+	q := m.Row(3)
+	rows := []Vector{m.Row(7), m.Row(0), m.Row(3)}
+
+	out := make([]float32, len(rows))
+	if err := DotGather(q, rows, out); err != nil {
+		t.Fatalf("DotGather() error = %v", err)
+	}
+	for i, row := range rows {
+		want, _ := DotProduct(q, row)
+		if diff := out[i] - want; diff > eps || diff < -eps {
+			t.Errorf("DotGather()[%d] = %v, want %v", i, out[i], want)
+		}
+	}
+
+	if err := DotGather(q, []Vector{{1}}, out); err != ErrDimensionMismatch {
+		t.Errorf("DotGather() error = %v, want %v", err, ErrDimensionMismatch)
+	}
+}
+
+func TestCosineMatrix(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	a := randMatrix(r, 16, 5)
+	b := randMatrix(r, 16, 130) // more than one tile
+	copy(b.Row(4), make([]float32, 16))
+
+	out := make([]float32, a.Rows()*b.Rows())
+	if err := CosineMatrix(a, b, out); err != nil {
+		t.Fatalf("CosineMatrix() error = %v", err)
+	}
+
+	for i := 0; i < a.Rows(); i++ {
+		for j := 0; j < b.Rows(); j++ {
+			got := out[i*b.Rows()+j]
+			want, err := CosineSimilarity(a.Row(i), b.Row(j))
+			if err != nil {
+				want = 0
+			}
+			if diff := got - want; diff > eps || diff < -eps {
+				t.Errorf("CosineMatrix()[%d][%d] = %v, want %v", i, j, got, want)
+			}
+		}
+	}
+
+	if err := CosineMatrix(a, NewMatrix(3, 1), out); err != ErrDimensionMismatch {
+		t.Errorf("CosineMatrix() error = %v, want %v", err, ErrDimensionMismatch)
+	}
+}
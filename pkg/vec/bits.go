@@ -0,0 +1,124 @@
+package vec
+
+import "math/bits"
+
+// BitVector is a packed binary vector, 64 bits per word. Bit i lives in
+// word i/64 at position i%64. Unused high bits must be zero.
+type BitVector []uint64
+
+// NewBitVector allocates a zeroed BitVector able to hold n bits.
+func NewBitVector(n int) BitVector {
+	return make(BitVector, (n+63)/64)
+}
+
+// Len returns the bit capacity of the vector.
+func (b BitVector) Len() int { return 64 * len(b) }
+
+func (b BitVector) Set(i int)      { b[i/64] |= 1 << (i % 64) }
+func (b BitVector) Get(i int) bool { return b[i/64]&(1<<(i%64)) != 0 }
+
+// OnesCount returns the number of set bits.
+func (b BitVector) OnesCount() int {
+	n := 0
+	for _, w := range b {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// BitVectorFromBytes packs b (bit 0 = lowest bit of b[0]) into a BitVector.
+// The tail of the last word is zero-padded.
+func BitVectorFromBytes(b []byte) BitVector {
+	out := make(BitVector, (len(b)+7)/8)
+	for i, x := range b {
+		out[i/8] |= uint64(x) << (8 * (i % 8))
+	}
+	return out
+}
+
+// Bytes unpacks the first n bytes of the vector.
+func (b BitVector) Bytes(n int) []byte {
+	out := make([]byte, n)
+	for i := range out {
+		out[i] = byte(b[i/8] >> (8 * (i % 8)))
+	}
+	return out
+}
+
+// Hamming returns the number of differing bits between a and b.
+func Hamming(a, b BitVector) (int, error) {
+	if len(a) != len(b) {
+		return 0, ErrDimensionMismatch
+	}
+	return hamming(a, b), nil
+}
+
+// JaccardDistance returns 1 - |a AND b| / |a OR b|. Two empty vectors have
+// distance 0.
+func JaccardDistance(a, b BitVector) (float32, error) {
+	if len(a) != len(b) {
+		return 0, ErrDimensionMismatch
+	}
+	return jaccard(a, b), nil
+}
+
+// HammingGather writes the Hamming distance between q and each row into out,
+// normalized by n, the length of the vectors in bits, so results fall in
+// [0, 1]. n may be less than Len when the last word is only partly used.
+func HammingGather(q BitVector, n int, rows []BitVector, out []float32) error {
+	if err := checkGather(q, rows, out); err != nil {
+		return err
+	}
+	if n <= 0 || n > q.Len() {
+		return ErrDimensionMismatch
+	}
+	for i, r := range rows {
+		out[i] = float32(hamming(q, r)) / float32(n)
+	}
+	return nil
+}
+
+// JaccardGather writes the Jaccard distance between q and each row into out.
+func JaccardGather(q BitVector, rows []BitVector, out []float32) error {
+	if err := checkGather(q, rows, out); err != nil {
+		return err
+	}
+	for i, r := range rows {
+		out[i] = jaccard(q, r)
+	}
+	return nil
+}
+
+func checkGather(q BitVector, rows []BitVector, out []float32) error {
+	if len(out) < len(rows) {
+		return ErrShortBuffer
+	}
+	for _, r := range rows {
+		if len(r) != len(q) {
+			return ErrDimensionMismatch
+		}
+	}
+	return nil
+}
+
+func hamming(a, b BitVector) int {
+	b = b[:len(a)]
+	n := 0
+	for i := range a {
+		n += bits.OnesCount64(a[i] ^ b[i])
+	}
+	return n
+}
+
+func jaccard(a, b BitVector) float32 {
+	b = b[:len(a)]
+	var inter, union int
+	for i := range a {
+		inter += bits.OnesCount64(a[i] & b[i])
+		union += bits.OnesCount64(a[i] | b[i])
+	}
+	if union == 0 {
+		return 0
+	}
+	return 1 - float32(inter)/float32(union)
+}
@@ -0,0 +1,58 @@
+package vec
+
+import "testing"
+
+func TestBitDistances(t *testing.T) {
+	a := BitVectorFromBytes([]byte{0b1011, 0xff})
+	b := BitVectorFromBytes([]byte{0b0011, 0x0f})
+
+	h, err := Hamming(a, b)
+	if err != nil {
+		t.Fatalf("Hamming() error = %v", err)
+	}
+	if h != 5 {
+		t.Errorf("Hamming() = %d, want 5", h)
+	}
+
+	// |a AND b| = 2 + 4, |a OR b| = 3 + 8
+	j, _ := JaccardDistance(a, b)
+	if want := float32(1 - 6.0/11.0); j-want > eps || want-j > eps {
+		t.Errorf("JaccardDistance() = %v, want %v", j, want)
+	}
+
+	if j, _ := JaccardDistance(NewBitVector(10), NewBitVector(10)); j != 0 {
+		t.Errorf("JaccardDistance() of empty vectors = %v, want 0", j)
+	}
+
+	if _, err := Hamming(a, NewBitVector(100)); err != ErrDimensionMismatch {
+		t.Errorf("Hamming() error = %v, want %v", err, ErrDimensionMismatch)
+	}
+
+	out := make([]float32, 2)
+	if err := HammingGather(a, 16, []BitVector{a, b}, out); err != nil {
+		t.Fatalf("HammingGather() error = %v", err)
+	}
+	if out[0] != 0 || out[1] != 5.0/16 {
+		t.Errorf("HammingGather() = %v, want [0 %v]", out, 5.0/16)
+	}
+	if err := HammingGather(a, 65, []BitVector{a, b}, out); err != ErrDimensionMismatch {
+		t.Errorf("HammingGather() past the last word: error = %v, want %v", err, ErrDimensionMismatch)
+	}
+}
+
+func TestBitVectorBytes(t *testing.T) {
+	in := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9}
+	b := BitVectorFromBytes(in)
+	if len(b) != 2 {
+		t.Fatalf("expected 2 words, got %d", len(b))
+	}
+	if !b.Get(0) || b.Get(1) || !b.Get(9) {
+		t.Errorf("unexpected bit layout: %x", b)
+	}
+	if got := b.Bytes(len(in)); string(got) != string(in) {
+		t.Errorf("Bytes() = %v, want %v", got, in)
+	}
+	if b.OnesCount() != 15 {
+		t.Errorf("OnesCount() = %d, want 15", b.OnesCount())
+	}
+}
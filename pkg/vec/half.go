@@ -0,0 +1,282 @@
+package vec
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// Float16 is an IEEE 754 half-precision float (1 sign, 5 exponent, 10 mantissa bits).
+type Float16 uint16
+
+// BFloat16 is a brain float: the top 16 bits of a float32
+// (1 sign, 8 exponent, 7 mantissa bits).
+type BFloat16 uint16
+
+type Float16Vector []Float16
+type BFloat16Vector []BFloat16
+
+var ErrPackedLength = errors.New("packed vector length is not a multiple of the element size")
+
+// f16Table decodes every possible Float16 bit pattern. 256KiB buys us a
+// branch-free decode in the distance kernels.
+var f16Table = func() *[1 << 16]float32 {
+	var t [1 << 16]float32
+	for i := range t {
+		t[i] = float16ToFloat32(uint16(i))
+	}
+	return &t
+}()
+
+func float16ToFloat32(h uint16) float32 {
+	sign := uint32(h>>15) << 31
+	exp := uint32(h>>10) & 0x1f
+	mant := uint32(h) & 0x3ff
+
+	switch exp {
+	case 0:
+		if mant == 0 {
+			return math.Float32frombits(sign)
+		}
+		// subnormal: renormalize
+		e := uint32(127 - 15 + 1)
+		for mant&0x400 == 0 {
+			mant <<= 1
+			e--
+		}
+		mant &= 0x3ff
+		return math.Float32frombits(sign | e<<23 | mant<<13)
+	case 0x1f:
+		// inf / nan
+		return math.Float32frombits(sign | 0xff<<23 | mant<<13)
+	}
+	return math.Float32frombits(sign | (exp+127-15)<<23 | mant<<13)
+}
+
+// ToFloat16 converts f to half precision, rounding to nearest even.
+// Values outside the half range become ±Inf.
+func ToFloat16(f float32) Float16 {
+	b := math.Float32bits(f)
+	sign := uint16(b>>16) & 0x8000
+	exp := int32(b>>23) & 0xff
+	mant := b & 0x7fffff
+
+	if exp == 0xff {
+		if mant != 0 {
+			return Float16(sign | 0x7e00) // quiet nan
+		}
+		return Float16(sign | 0x7c00)
+	}
+
+	e := exp - 127 + 15
+	if e >= 0x1f {
+		return Float16(sign | 0x7c00)
+	}
+	if e <= 0 {
+		if e < -10 {
+			return Float16(sign) // underflows to zero
+		}
+		// subnormal: shift the implicit bit in and round
+		mant |= 0x800000
+		shift := uint32(14 - e)
+		half := uint32(1) << (shift - 1)
+		rem := mant & (1<<shift - 1)
+		m := mant >> shift
+		if rem > half || (rem == half && m&1 == 1) {
+			m++
+		}
+		return Float16(sign | uint16(m))
+	}
+
+	m := mant >> 13
+	rem := mant & 0x1fff
+	h := uint32(e)<<10 | m
+	if rem > 0x1000 || (rem == 0x1000 && m&1 == 1) {
+		h++ // may carry into the exponent, which is the correct rounding
+	}
+	return Float16(sign | uint16(h))
+}
+
+func (h Float16) Float32() float32 { return f16Table[h] }
+
+// ToBFloat16 converts f to bfloat16, rounding to nearest even.
+func ToBFloat16(f float32) BFloat16 {
+	b := math.Float32bits(f)
+	if b&0x7fffffff > 0x7f800000 {
+		return BFloat16(b>>16 | 0x40) // keep nan a quiet nan
+	}
+	b += 0x7fff + (b>>16)&1
+	return BFloat16(b >> 16)
+}
+
+func (b BFloat16) Float32() float32 { return math.Float32frombits(uint32(b) << 16) }
+
+// ToFloat16Vector converts v to half precision.
+func ToFloat16Vector(v Vector) Float16Vector {
+	out := make(Float16Vector, len(v))
+	for i, f := range v {
+		out[i] = ToFloat16(f)
+	}
+	return out
+}
+
+// ToBFloat16Vector converts v to bfloat16.
+func ToBFloat16Vector(v Vector) BFloat16Vector {
+	out := make(BFloat16Vector, len(v))
+	for i, f := range v {
+		out[i] = ToBFloat16(f)
+	}
+	return out
+}
+
+// Float32 widens v back to a float32 Vector.
+func (v Float16Vector) Float32() Vector {
+	out := make(Vector, len(v))
+	for i, h := range v {
+		out[i] = f16Table[h]
+	}
+	return out
+}
+
+// Float32 widens v back to a float32 Vector.
+func (v BFloat16Vector) Float32() Vector {
+	out := make(Vector, len(v))
+	for i, b := range v {
+		out[i] = b.Float32()
+	}
+	return out
+}
+
+// Bytes packs v as little-endian 16-bit values.
+func (v Float16Vector) Bytes() []byte {
+	out := make([]byte, 2*len(v))
+	for i, h := range v {
+		binary.LittleEndian.PutUint16(out[2*i:], uint16(h))
+	}
+	return out
+}
+
+// Bytes packs v as little-endian 16-bit values.
+func (v BFloat16Vector) Bytes() []byte {
+	out := make([]byte, 2*len(v))
+	for i, b := range v {
+		binary.LittleEndian.PutUint16(out[2*i:], uint16(b))
+	}
+	return out
+}
+
+// Float32FromBytes unpacks little-endian float32 values.
+func Float32FromBytes(b []byte) (Vector, error) {
+	if len(b)%4 != 0 {
+		return nil, ErrPackedLength
+	}
+	out := make(Vector, len(b)/4)
+	for i := range out {
+		out[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	return out, nil
+}
+
+// Float16FromBytes unpacks little-endian 16-bit values.
+func Float16FromBytes(b []byte) (Float16Vector, error) {
+	if len(b)%2 != 0 {
+		return nil, ErrPackedLength
+	}
+	out := make(Float16Vector, len(b)/2)
+	for i := range out {
+		out[i] = Float16(binary.LittleEndian.Uint16(b[2*i:]))
+	}
+	return out, nil
+}
+
+// BFloat16FromBytes unpacks little-endian 16-bit values.
+func BFloat16FromBytes(b []byte) (BFloat16Vector, error) {
+	if len(b)%2 != 0 {
+		return nil, ErrPackedLength
+	}
+	out := make(BFloat16Vector, len(b)/2)
+	for i := range out {
+		out[i] = BFloat16(binary.LittleEndian.Uint16(b[2*i:]))
+	}
+	return out, nil
+}
+
+// DotFloat16 computes the dot product of a float32 query and a half-precision
+// vector. Accumulation is done in float32.
+func DotFloat16(q Vector, v Float16Vector) (float32, error) {
+	if len(q) != len(v) {
+		return 0, ErrDimensionMismatch
+	}
+	return dotF16(q, v), nil
+}
+
+// DotBFloat16 computes the dot product of a float32 query and a bfloat16
+// vector. Accumulation is done in float32.
+func DotBFloat16(q Vector, v BFloat16Vector) (float32, error) {
+	if len(q) != len(v) {
+		return 0, ErrDimensionMismatch
+	}
+	return dotBF16(q, v), nil
+}
+
+// DotGatherFloat16 is DotGather over half-precision rows.
+func DotGatherFloat16(q Vector, rows []Float16Vector, out []float32) error {
+	if len(out) < len(rows) {
+		return ErrShortBuffer
+	}
+	for _, r := range rows {
+		if len(r) != len(q) {
+			return ErrDimensionMismatch
+		}
+	}
+	for i, r := range rows {
+		out[i] = dotF16(q, r)
+	}
+	return nil
+}
+
+// DotGatherBFloat16 is DotGather over bfloat16 rows.
+func DotGatherBFloat16(q Vector, rows []BFloat16Vector, out []float32) error {
+	if len(out) < len(rows) {
+		return ErrShortBuffer
+	}
+	for _, r := range rows {
+		if len(r) != len(q) {
+			return ErrDimensionMismatch
+		}
+	}
+	for i, r := range rows {
+		out[i] = dotBF16(q, r)
+	}
+	return nil
+}
+
+func dotF16(a []float32, b Float16Vector) float32 {
+	n := len(a)
+	b = b[:n]
+	t := f16Table
+	var sum float32
+	i := 0
+	for ; i+3 < n; i += 4 {
+		sum += a[i]*t[b[i]] + a[i+1]*t[b[i+1]] + a[i+2]*t[b[i+2]] + a[i+3]*t[b[i+3]]
+	}
+	for ; i < n; i++ {
+		sum += a[i] * t[b[i]]
+	}
+	return sum
+}
+
+func dotBF16(a []float32, b BFloat16Vector) float32 {
+	n := len(a)
+	b = b[:n]
+	var sum float32
+	i := 0
+	for ; i+3 < n; i += 4 {
+		sum += a[i]*b[i].Float32() + a[i+1]*b[i+1].Float32() +
+			a[i+2]*b[i+2].Float32() + a[i+3]*b[i+3].Float32()
+	}
+	for ; i < n; i++ {
+		sum += a[i] * b[i].Float32()
+	}
+	return sum
+}
@@ -0,0 +1,75 @@
+package vec
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFloat16RoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		in   float32
+		want float32
+		bits Float16
+	}{
+		{name: "Zero", in: 0, want: 0, bits: 0x0000},
+		{name: "One", in: 1, want: 1, bits: 0x3c00},
+		{name: "Negative", in: -2, want: -2, bits: 0xc000},
+		{name: "Max", in: 65504, want: 65504, bits: 0x7bff},
+		{name: "Overflow", in: 1e6, want: float32(math.Inf(1)), bits: 0x7c00},
+		{name: "Smallest subnormal", in: 5.960464477539063e-08, want: 5.960464477539063e-08, bits: 0x0001},
+		{name: "Rounds to even", in: 1 + 1.0/2048, want: 1, bits: 0x3c00},
+	}
+
+	for _, tt := range tests {
+		h := ToFloat16(tt.in)
+		if h != tt.bits {
+			t.Errorf("%s: ToFloat16(%v) = %#04x, want %#04x", tt.name, tt.in, uint16(h), uint16(tt.bits))
+		}
+		if got := h.Float32(); got != tt.want {
+			t.Errorf("%s: Float32() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if nan := ToFloat16(float32(math.NaN())).Float32(); !math.IsNaN(float64(nan)) {
+		t.Errorf("NaN did not survive the round trip, got %v", nan)
+	}
+}
+
+func TestBFloat16RoundTrip(t *testing.T) {
+	for _, f := range []float32{0, 1, -1, 3.140625, 1 << 40, -0.0078125} {
+		if got := ToBFloat16(f).Float32(); got != f {
+			t.Errorf("BFloat16 round trip of %v = %v", f, got)
+		}
+	}
+	// 1 + 2^-8 is a tie between 1 and 1+2^-7; ties round to even.
+	if got := ToBFloat16(1 + 1.0/256).Float32(); got != 1 {
+		t.Errorf("ToBFloat16(1+2^-8) = %v, want 1", got)
+	}
+}
+
+func TestHalfKernels(t *testing.T) {
+	q := Vector{0.5, -1, 2, 0.25, 1}
+	v := Vector{1, 0.5, -0.25, 4, 2}
+	want, _ := DotProduct(q, v)
+
+	h, err := Float16FromBytes(ToFloat16Vector(v).Bytes())
+	if err != nil {
+		t.Fatalf("Float16FromBytes() error = %v", err)
+	}
+	if got, _ := DotFloat16(q, h); got != want {
+		t.Errorf("DotFloat16() = %v, want %v", got, want)
+	}
+
+	b, err := BFloat16FromBytes(ToBFloat16Vector(v).Bytes())
+	if err != nil {
+		t.Fatalf("BFloat16FromBytes() error = %v", err)
+	}
+	if got, _ := DotBFloat16(q, b); got != want {
+		t.Errorf("DotBFloat16() = %v, want %v", got, want)
+	}
+
+	if _, err := Float16FromBytes([]byte{1, 2, 3}); err != ErrPackedLength {
+		t.Errorf("Float16FromBytes() error = %v, want %v", err, ErrPackedLength)
+	}
+}
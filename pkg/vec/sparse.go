@@ -0,0 +1,79 @@
+package vec
+
+import (
+	"errors"
+	"sort"
+)
+
+var ErrSparseLength = errors.New("sparse vector indices and values differ in length")
+var ErrSparseDuplicate = errors.New("sparse vector has duplicate indices")
+
+// SparseVector holds the non-zero entries of a high-dimensional vector
+// (SPLADE, BM25 term weights, ...). Indices are kept sorted ascending.
+type SparseVector struct {
+	Indices []uint32
+	Values  []float32
+}
+
+// NewSparseVector builds a SparseVector from parallel index/value slices,
+// sorting them by index. The inputs are copied.
+func NewSparseVector(indices []uint32, values []float32) (SparseVector, error) {
+	if len(indices) != len(values) {
+		return SparseVector{}, ErrSparseLength
+	}
+	s := SparseVector{
+		Indices: append([]uint32(nil), indices...),
+		Values:  append([]float32(nil), values...),
+	}
+	sort.Sort(sparseByIndex(s))
+	for i := 1; i < len(s.Indices); i++ {
+		if s.Indices[i] == s.Indices[i-1] {
+			return SparseVector{}, ErrSparseDuplicate
+		}
+	}
+	return s, nil
+}
+
+// SparseFromDense keeps the non-zero entries of v.
+func SparseFromDense(v Vector) SparseVector {
+	var s SparseVector
+	for i, f := range v {
+		if f != 0 {
+			s.Indices = append(s.Indices, uint32(i))
+			s.Values = append(s.Values, f)
+		}
+	}
+	return s
+}
+
+// Len returns the number of stored (non-zero) entries.
+func (s SparseVector) Len() int { return len(s.Indices) }
+
+// SparseDot computes the dot product of two sparse vectors by merging their
+// sorted index lists.
+func SparseDot(a, b SparseVector) float32 {
+	var sum float32
+	i, j := 0, 0
+	for i < len(a.Indices) && j < len(b.Indices) {
+		switch {
+		case a.Indices[i] == b.Indices[j]:
+			sum += a.Values[i] * b.Values[j]
+			i++
+			j++
+		case a.Indices[i] < b.Indices[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	return sum
+}
+
+type sparseByIndex SparseVector
+
+func (s sparseByIndex) Len() int           { return len(s.Indices) }
+func (s sparseByIndex) Less(i, j int) bool { return s.Indices[i] < s.Indices[j] }
+func (s sparseByIndex) Swap(i, j int) {
+	s.Indices[i], s.Indices[j] = s.Indices[j], s.Indices[i]
+	s.Values[i], s.Values[j] = s.Values[j], s.Values[i]
+}
@@ -0,0 +1,29 @@
+package vec
+
+import "testing"
+
+func TestSparseDot(t *testing.T) {
+	a, err := NewSparseVector([]uint32{9, 1, 4}, []float32{2, 1, 3})
+	if err != nil {
+		t.Fatalf("NewSparseVector() error = %v", err)
+	}
+	if a.Indices[0] != 1 || a.Indices[2] != 9 || a.Values[2] != 2 {
+		t.Fatalf("NewSparseVector() did not sort: %+v", a)
+	}
+
+	b := SparseFromDense(Vector{0, 5, 0, 0, 0.5, 0, 0, 0, 0, 0})
+	if b.Len() != 2 {
+		t.Fatalf("SparseFromDense() kept %d entries, want 2", b.Len())
+	}
+
+	if got := SparseDot(a, b); got != 6.5 {
+		t.Errorf("SparseDot() = %v, want 6.5", got)
+	}
+
+	if _, err := NewSparseVector([]uint32{1, 1}, []float32{1, 2}); err != ErrSparseDuplicate {
+		t.Errorf("NewSparseVector() error = %v, want %v", err, ErrSparseDuplicate)
+	}
+	if _, err := NewSparseVector([]uint32{1}, nil); err != ErrSparseLength {
+		t.Errorf("NewSparseVector() error = %v, want %v", err, ErrSparseLength)
+	}
+}
@@ -9,6 +9,7 @@ type Vector []float32
 
 var ErrDimensionMismatch = errors.New("vector dimensions don't match")
 var DivisionByZero = errors.New("attempt to divide by zero")
+var ErrShortBuffer = errors.New("output buffer too small")
 
 // DotProduct calculates the dot product of two vectors.
 func DotProduct(v1, v2 Vector) (float32, error) {
@@ -16,14 +17,7 @@ func DotProduct(v1, v2 Vector) (float32, error) {
 		return 0, ErrDimensionMismatch
 	}
 
-	var result float32
-
-	// scope for Go compiler to produce SIMD instructions.
-	for i := range v1 {
-		result += v1[i] * v2[i]
-	}
-
-	return result, nil
+	return dot(v1, v2), nil
 }
 
 // Magnitude calculates the Euclidean Lenght (L2 norm) of the vector.